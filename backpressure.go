@@ -0,0 +1,68 @@
+package goqueue
+
+import (
+	"time"
+)
+
+// softLimit delays Put operators as the Queue fills up.
+type softLimit struct {
+	ratio float64
+	delay time.Duration
+}
+
+// WithSoftLimit delays every Put which finds the Queue filled over ratio
+// (0 to 1) of maxSize, the delay grows linearly from zero at ratio up to
+// maxDelay seconds when the Queue is full, so producers slow down before
+// they get ErrFullQueue or block. A Put with a positive timeout spends the
+// delay out of its timeout. It has no effect on an infinite Queue, and
+// WithSoftLimit(1, 0) disables it.
+func WithSoftLimit(ratio, maxDelay float64) Option {
+	return func(q *Queue) {
+		q.soft.Store(&softLimit{ratio: ratio, delay: seconds(maxDelay)})
+	}
+}
+
+// backoff returns the delay of a Put, it doesn't take the lock.
+func (q *Queue) backoff() time.Duration {
+	s, _ := q.soft.Load().(*softLimit)
+	if s == nil || s.ratio >= 1.0 {
+		return 0
+	}
+	limit := q.MaxSize()
+	if limit <= 0 {
+		return 0
+	}
+	fill := float64(q.Size()) / float64(limit)
+	if fill <= s.ratio {
+		return 0
+	}
+	if fill > 1.0 {
+		fill = 1.0
+	}
+	return time.Duration(float64(s.delay) * (fill - s.ratio) / (1.0 - s.ratio))
+}
+
+// throttle waits the delay of a Put and returns what is left of timeout,
+// or false if done was closed meanwhile.
+func (q *Queue) throttle(timeout float64, done <-chan struct{}) (float64, bool) {
+	d := q.backoff()
+	if d <= 0 {
+		return timeout, true
+	}
+	left := timeout
+	if timeout > 0.0 {
+		if limit := seconds(timeout); d >= limit {
+			d, left = limit, -1
+		} else {
+			left -= d.Seconds()
+		}
+	}
+	t := acquireTimer(d)
+	defer releaseTimer(t)
+	select {
+	case <-t.C:
+		return left, true
+	case <-done:
+		return timeout, false
+	}
+}
@@ -0,0 +1,68 @@
+package goqueue
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestSoftLimit(t *testing.T) {
+	queue := New(4, WithSoftLimit(0.5, 0.2))
+
+	fmt.Println("Test Put below the soft limit is not delayed...")
+	start := time.Now()
+	queue.PutNoWait(1)
+	queue.PutNoWait(2)
+	queue.PutNoWait(3)
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Fatalf("Put delayed %v below the soft limit\n", elapsed)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test Put over the soft limit is delayed proportionally...")
+	if d := queue.backoff(); d != 100*time.Millisecond {
+		t.Fatalf("Expect 100ms delay at 3/4 full, got %v\n", d)
+	}
+	start = time.Now()
+	if err := queue.PutNoWait(4); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Fatalf("Expect Put delayed 100ms, got %v\n", elapsed)
+	}
+	start = time.Now()
+	if err := queue.PutNoWait(5); err != ErrFullQueue {
+		t.Fatalf("Expect %v, got %v\n", ErrFullQueue, err)
+	}
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Fatalf("Expect Put delayed 200ms, got %v\n", elapsed)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test the delay is spent out of the timeout...")
+	start = time.Now()
+	if err := queue.Put(5, 0.1); err != ErrFullQueue {
+		t.Fatalf("Expect %v, got %v\n", ErrFullQueue, err)
+	}
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Fatalf("Expect Put returned after 100ms, got %v\n", elapsed)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test PutContext gives up the delay when ctx is done...")
+	queue.GetNoWait()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start = time.Now()
+	if err := queue.PutContext(ctx, 5); err != context.DeadlineExceeded {
+		t.Fatalf("Expect %v, got %v\n", context.DeadlineExceeded, err)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Fatalf("Expect PutContext returned after 20ms, got %v\n", elapsed)
+	}
+	if queue.Size() != 3 {
+		t.Fatalf("Expect the value not put, got size %d\n", queue.Size())
+	}
+	fmt.Println("  ...PASSED")
+}
@@ -0,0 +1,85 @@
+package goqueue
+
+type barrier struct {
+	gen    uint64 // values marked with a lower generation are ahead
+	ahead  int    // values ahead still in Queue
+	future *Future
+}
+
+// PutBarrier returns a Future which is completed, with a nil value, once
+// every value in the Queue when the barrier is created has left it, got,
+// dropped or removed, whatever the order Get takes them in: WithLIFO and
+// PutFront are fine. A barrier takes no slot and is never returned by Get,
+// values blocked in Put when the barrier is created are not waited for.
+func (q *Queue) PutBarrier() *Future {
+	q.mutex.Lock()
+	defer q.unlock()
+	f := newFuture()
+	n := q.items.Len()
+	if n == 0 {
+		f.complete(nil, nil)
+		return f
+	}
+	if q.marks == nil {
+		q.marks = newRing(n)
+		for i := 0; i < n; i++ {
+			q.marks.pushBack(q.gen)
+		}
+	}
+	q.gen++
+	q.barriers.PushBack(&barrier{gen: q.gen, ahead: n, future: f})
+	return f
+}
+
+// mark records the generation of a value added at the front if front, or
+// at the back, while barriers are pending.
+func (q *Queue) mark(front bool, gen uint64) {
+	if q.marks == nil {
+		return
+	}
+	if front {
+		q.marks.pushFront(gen)
+	} else {
+		q.marks.pushBack(gen)
+	}
+}
+
+// unmark forgets the generation of the value removed from the back if back,
+// or from the front.
+func (q *Queue) unmark(back bool) (uint64, bool) {
+	if q.marks == nil {
+		return 0, false
+	}
+	if back {
+		return q.marks.popBack().(uint64), true
+	}
+	return q.marks.popFront().(uint64), true
+}
+
+// pass counts a value of generation gen which left Queue for the barriers
+// created after it was added, and completes those it was the last one for.
+func (q *Queue) pass(gen uint64) {
+	for e := q.barriers.Back(); e != nil; {
+		b := e.Value.(*barrier)
+		if b.gen <= gen {
+			break
+		}
+		prev := e.Prev()
+		if b.ahead--; b.ahead == 0 {
+			q.barriers.Remove(e)
+			b.future.complete(nil, nil)
+		}
+		e = prev
+	}
+	if q.barriers.Len() == 0 {
+		q.marks = nil
+	}
+}
+
+// leave is called for every value removed from the back if back, or from
+// the front.
+func (q *Queue) leave(back bool) {
+	if gen, ok := q.unmark(back); ok {
+		q.pass(gen)
+	}
+}
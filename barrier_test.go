@@ -0,0 +1,104 @@
+package goqueue
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestPutBarrier(t *testing.T) {
+	queue := New(0)
+
+	fmt.Println("Test barrier on an empty Queue is passed...")
+	if _, err := queue.PutBarrier().Wait(-1); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test barrier waits for the values put before it...")
+	queue.PutNoWait(1)
+	queue.PutNoWait(2)
+	first := queue.PutBarrier()
+	queue.PutNoWait(3)
+	second := queue.PutBarrier()
+	if queue.Size() != 3 {
+		t.Fatalf("Expect barriers take no slot, got size %d\n", queue.Size())
+	}
+	queue.GetNoWait()
+	if _, err := first.Wait(-1); err != ErrPending {
+		t.Fatalf("Expect %v, got %v\n", ErrPending, err)
+	}
+	if val, _ := queue.GetNoWait(); val.(int) != 2 {
+		t.Fatalf("Expect %v, got %v\n", 2, val)
+	}
+	if _, err := first.Wait(-1); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	if _, err := second.Wait(-1); err != ErrPending {
+		t.Fatalf("Expect %v, got %v\n", ErrPending, err)
+	}
+	if val, _ := queue.GetNoWait(); val.(int) != 3 {
+		t.Fatalf("Expect barrier is never got, got %v\n", val)
+	}
+	if _, err := second.Wait(1); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test values handed to blocked getters pass barriers...")
+	done := make(chan interface{})
+	go func() {
+		val, _ := queue.Get(0)
+		done <- val
+	}()
+	for {
+		queue.mutex.Lock()
+		n := queue.getters.Len()
+		queue.mutex.Unlock()
+		if n != 0 {
+			break
+		}
+	}
+	queue.PutNoWait(4)
+	barrier := queue.PutBarrier()
+	<-done
+	if _, err := barrier.Wait(1); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestPutBarrierOrder(t *testing.T) {
+	fmt.Println("Test barrier on a LIFO Queue waits for the older values...")
+	queue := New(0, WithLIFO())
+	queue.PutNoWait("a")
+	queue.PutNoWait("b")
+	barrier := queue.PutBarrier()
+	queue.PutNoWait("c")
+	queue.GetNoWait()
+	queue.GetNoWait()
+	if _, err := barrier.Wait(-1); err != ErrPending {
+		t.Fatalf("Expect %v with a left, got %v\n", ErrPending, err)
+	}
+	if val, _ := queue.GetNoWait(); val != "a" {
+		t.Fatalf("Expect a, got %v\n", val)
+	}
+	if _, err := barrier.Wait(-1); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test barrier ignores values put at the front after it...")
+	queue = New(0)
+	queue.PutNoWait(1)
+	barrier = queue.PutBarrier()
+	queue.PutFront(0, -1)
+	queue.GetNoWait()
+	if _, err := barrier.Wait(-1); err != ErrPending {
+		t.Fatalf("Expect %v, got %v\n", ErrPending, err)
+	}
+	queue.RemoveFunc(func(val interface{}) bool { return val.(int) == 1 })
+	if _, err := barrier.Wait(-1); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	fmt.Println("  ...PASSED")
+}
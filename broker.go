@@ -0,0 +1,184 @@
+package goqueue
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Broker fans out published values to every subscriber of a topic, each
+// subscriber reads its own Queue. A consumer group is a subscriber whose
+// Queue is shared by the consumers of the group, so every group gets each
+// value once and its consumers compete for them.
+type Broker struct {
+	size   int
+	policy FullPolicy
+	opts   []Option
+	mutex  sync.RWMutex
+	topics map[string][]*Queue
+	groups map[string]map[string]*Queue // group Queues by topic and name
+}
+
+// NewBroker create a Broker whose subscriber Queues are created by
+// New(size, opts...), full ones are handled according to policy: Block
+// slows Publish down to the pace of the slowest subscriber, Reject and
+// DropNewest skip the subscriber for the value, DropOldest makes it the
+// FullPolicy of the subscriber Queues. Values dropped by the policy are
+// passed to the OnDrop hook of the subscriber.
+func NewBroker(size int, policy FullPolicy, opts ...Option) *Broker {
+	return &Broker{
+		size:   size,
+		policy: policy,
+		opts:   opts,
+		topics: make(map[string][]*Queue),
+		groups: make(map[string]map[string]*Queue),
+	}
+}
+
+// Subscribe returns a new Queue receiving the values published to topic
+// from now on.
+func (b *Broker) Subscribe(topic string) *Queue {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.subscribe(topic)
+}
+
+func (b *Broker) subscribe(topic string) *Queue {
+	opts := b.opts
+	if b.policy == DropOldest {
+		// Evict with the policy of the Queue under its own lock, Get may
+		// fail on a paused or rate limited Queue.
+		opts = append(append([]Option(nil), opts...), WithFullPolicy(DropOldest))
+	}
+	q := New(b.size, opts...)
+	b.topics[topic] = append(b.topics[topic], q)
+	return q
+}
+
+// SubscribeGroup returns the Queue of the consumer group of topic, which
+// is subscribed by the first call for the group. Every consumer of the
+// group gets from the same Queue.
+func (b *Broker) SubscribeGroup(topic, group string) *Queue {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	groups := b.groups[topic]
+	if groups == nil {
+		groups = make(map[string]*Queue)
+		b.groups[topic] = groups
+	}
+	q, ok := groups[group]
+	if !ok {
+		q = b.subscribe(topic)
+		groups[group] = q
+	}
+	return q
+}
+
+// Unsubscribe stops delivering the values of topic to q, the values in q
+// are kept. Unsubscribing the Queue of a group removes the whole group.
+func (b *Broker) Unsubscribe(topic string, q *Queue) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for name, sub := range b.groups[topic] {
+		if sub == q {
+			delete(b.groups[topic], name)
+		}
+	}
+	if len(b.groups[topic]) == 0 {
+		delete(b.groups, topic)
+	}
+	subs := b.topics[topic]
+	for i, sub := range subs {
+		if sub == q {
+			// Copy, Publish may be ranging over the old slice.
+			subs = append(append([]*Queue(nil), subs[:i]...), subs[i+1:]...)
+			break
+		}
+	}
+	if len(subs) == 0 {
+		delete(b.topics, topic)
+	} else {
+		b.topics[topic] = subs
+	}
+}
+
+// Publish puts val into the Queue of every subscriber of topic, and
+// returns the number of subscribers it was delivered to.
+func (b *Broker) Publish(topic string, val interface{}) int {
+	b.mutex.RLock()
+	subs := b.topics[topic]
+	b.mutex.RUnlock()
+
+	n := 0
+	for _, q := range subs {
+		if b.deliver(q, val) {
+			n++
+		}
+	}
+	return n
+}
+
+// Shared is a value published once to many subscribers by PublishShared,
+// every subscriber gets the same *Shared.
+type Shared struct {
+	Value   interface{}
+	refs    int32
+	release func(val interface{})
+}
+
+// Settled drops the reference of a subscriber which got, dropped or
+// removed the value.
+func (s *Shared) Settled(fate Fate) {
+	s.unref()
+}
+
+func (s *Shared) unref() {
+	if atomic.AddInt32(&s.refs, -1) == 0 && s.release != nil {
+		s.release(s.Value)
+	}
+}
+
+// PublishShared is Publish which stores val once in a *Shared counting the
+// subscribers holding it, release is called, if not nil, once each of them
+// got it or dropped, evicted or removed it.
+func (b *Broker) PublishShared(topic string, val interface{}, release func(val interface{})) int {
+	b.mutex.RLock()
+	subs := b.topics[topic]
+	b.mutex.RUnlock()
+
+	// Hold a reference while delivering, so release isn't called before
+	// the last subscriber got its one.
+	s := &Shared{Value: val, refs: 1, release: release}
+	n := 0
+	for _, q := range subs {
+		atomic.AddInt32(&s.refs, 1)
+		if b.deliver(q, s) {
+			n++
+		} else if b.policy == Block {
+			// Only the other policies pass the value to OnDrop, which
+			// settles it.
+			s.unref()
+		}
+	}
+	s.unref()
+	return n
+}
+
+func (b *Broker) deliver(q *Queue, val interface{}) bool {
+	switch b.policy {
+	case Block:
+		return q.Put(val, 0) == nil
+	default:
+		if q.PutNoWait(val) != nil {
+			q.fireDrop(val)
+			return false
+		}
+		return true
+	}
+}
+
+// Return the number of subscribers of topic.
+func (b *Broker) Subscribers(topic string) int {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return len(b.topics[topic])
+}
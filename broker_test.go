@@ -0,0 +1,163 @@
+package goqueue
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+var _ Tracker = (*Shared)(nil)
+
+func TestBroker(t *testing.T) {
+	broker := NewBroker(0, DropNewest)
+	a := broker.Subscribe("news")
+	b := broker.Subscribe("news")
+	other := broker.Subscribe("sports")
+
+	fmt.Println("Test a value fans out to every subscriber of the topic...")
+	if n := broker.Publish("news", "hello"); n != 2 {
+		t.Fatalf("Expect 2 deliveries, got %d\n", n)
+	}
+	for _, q := range []*Queue{a, b} {
+		if val, err := q.GetNoWait(); err != nil || val.(string) != "hello" {
+			t.Fatalf("Expect hello, got %v (%v)\n", val, err)
+		}
+	}
+	if !other.IsEmpty() {
+		t.Fatalf("Expect other topics get nothing\n")
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test Unsubscribe stops the delivery...")
+	broker.Unsubscribe("news", a)
+	if n := broker.Publish("news", "bye"); n != 1 || !a.IsEmpty() {
+		t.Fatalf("Expect only b gets bye, got %d deliveries\n", n)
+	}
+	broker.Unsubscribe("news", b)
+	if broker.Subscribers("news") != 0 || broker.Publish("news", "lost") != 0 {
+		t.Fatalf("Expect news has no subscriber\n")
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestBrokerPolicy(t *testing.T) {
+	var dropped []interface{}
+	hooks := WithHooks(Hooks{OnDrop: func(val interface{}) { dropped = append(dropped, val) }})
+
+	fmt.Println("Test DropNewest skips full subscribers...")
+	broker := NewBroker(1, DropNewest, hooks)
+	q := broker.Subscribe("t")
+	broker.Publish("t", 1)
+	if n := broker.Publish("t", 2); n != 0 {
+		t.Fatalf("Expect no delivery, got %d\n", n)
+	}
+	if val, _ := q.GetNoWait(); val.(int) != 1 || len(dropped) != 1 || dropped[0].(int) != 2 {
+		t.Fatalf("Expect 1 kept and 2 dropped, got %v and %v\n", val, dropped)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test DropOldest evicts the oldest value...")
+	dropped = nil
+	broker = NewBroker(1, DropOldest, hooks)
+	q = broker.Subscribe("t")
+	broker.Publish("t", 1)
+	if n := broker.Publish("t", 2); n != 1 {
+		t.Fatalf("Expect 1 delivery, got %d\n", n)
+	}
+	if val, _ := q.GetNoWait(); val.(int) != 2 || len(dropped) != 1 || dropped[0].(int) != 1 {
+		t.Fatalf("Expect 2 kept and 1 dropped, got %v and %v\n", val, dropped)
+	}
+	if s := q.Stats(); s.Gets != 1 || s.Dropped != 1 {
+		t.Fatalf("Expect 1 get and 1 dropped, got %+v\n", s)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test DropOldest delivers to a paused subscriber...")
+	q.Pause()
+	broker.Publish("t", 3)
+	done := make(chan int)
+	go func() { done <- broker.Publish("t", 4) }()
+	select {
+	case n := <-done:
+		if n != 1 {
+			t.Fatalf("Expect 1 delivery, got %d\n", n)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expect Publish to return\n")
+	}
+	q.Resume()
+	if val, _ := q.GetNoWait(); val.(int) != 4 {
+		t.Fatalf("Expect 4 kept, got %v\n", val)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test Block waits for the subscriber...")
+	broker = NewBroker(1, Block)
+	q = broker.Subscribe("t")
+	broker.Publish("t", 1)
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		q.GetNoWait()
+	}()
+	start := time.Now()
+	if n := broker.Publish("t", 2); n != 1 || time.Since(start) < 20*time.Millisecond {
+		t.Fatalf("Expect Publish blocks until delivered, got %d deliveries\n", n)
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestBrokerGroups(t *testing.T) {
+	broker := NewBroker(0, DropNewest)
+	indexer1 := broker.SubscribeGroup("docs", "indexer")
+	indexer2 := broker.SubscribeGroup("docs", "indexer")
+	auditor := broker.SubscribeGroup("docs", "auditor")
+
+	fmt.Println("Test every group gets each value once...")
+	if indexer1 != indexer2 || broker.Subscribers("docs") != 2 {
+		t.Fatalf("Expect the consumers of a group share its Queue\n")
+	}
+	for i := 0; i < 4; i++ {
+		broker.Publish("docs", i)
+	}
+	if indexer1.Size() != 4 || auditor.Size() != 4 {
+		t.Fatalf("Expect 4 values per group, got %d and %d\n", indexer1.Size(), auditor.Size())
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test unsubscribing a group removes it...")
+	broker.Unsubscribe("docs", auditor)
+	if broker.Subscribers("docs") != 1 || broker.SubscribeGroup("docs", "auditor") == auditor {
+		t.Fatalf("Expect the auditor group is gone\n")
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestBrokerShared(t *testing.T) {
+	broker := NewBroker(1, Reject)
+	a := broker.Subscribe("news")
+	b := broker.Subscribe("news")
+	full := broker.Subscribe("news")
+	full.PutNoWait("old")
+	released := make(chan interface{}, 2)
+	release := func(val interface{}) { released <- val }
+
+	fmt.Println("Test a shared value is released once every subscriber is done...")
+	if n := broker.PublishShared("news", "hello", release); n != 2 {
+		t.Fatalf("Expect 2 deliveries, got %d\n", n)
+	}
+	val, err := a.GetNoWait()
+	if err != nil || val.(*Shared).Value.(string) != "hello" {
+		t.Fatalf("Expect hello, got %v (%v)\n", val, err)
+	}
+	if len(released) != 0 {
+		t.Fatalf("Expect the value is still held by b\n")
+	}
+	b.Clear(nil)
+	if len(released) != 1 || <-released != "hello" {
+		t.Fatalf("Expect hello released once\n")
+	}
+	if broker.PublishShared("sports", "nobody", release) != 0 || <-released != "nobody" {
+		t.Fatalf("Expect a value without subscribers released right away\n")
+	}
+	fmt.Println("  ...PASSED")
+}
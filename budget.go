@@ -0,0 +1,151 @@
+package goqueue
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// The Budget of the Manager has no room for the value.
+var ErrOverBudget = errors.New("queue is over budget")
+
+// Budget bounds the total bytes of the values in the queues of a Manager.
+type Budget struct {
+	// Bytes is the total, shared by the queues once they are over their
+	// Config.Reserve. The reservations are not checked against it.
+	Bytes int64
+	// SizeOf returns the bytes of a value, it must return the same size
+	// every time for the same value.
+	SizeOf func(val interface{}) int64
+	// Policy tells what a Put over budget does: DropOldest evicts the oldest
+	// values of the queue which uses the most shared bytes until the value
+	// fits, otherwise Put returns ErrOverBudget. PutFront and the values put
+	// back are never refused.
+	Policy FullPolicy
+}
+
+type budget struct {
+	Budget
+	mutex    sync.Mutex
+	shared   int64 // bytes in use over the reservations
+	reserved int64 // sum of the reservations
+	accounts map[*account]struct{}
+}
+
+// account is the share of one Queue in a budget, reserve and used are
+// guarded by the mutex of the budget.
+type account struct {
+	b       *budget
+	queue   *Queue
+	reserve int64
+	used    int64
+	closed  bool // the Queue was deleted from the Manager
+}
+
+// over returns the shared bytes of an account using used bytes.
+func (a *account) over(used int64) int64 {
+	if used > a.reserve {
+		return used - a.reserve
+	}
+	return 0
+}
+
+func (b *budget) open(q *Queue, reserve int64) *account {
+	a := &account{b: b, queue: q, reserve: reserve}
+	b.mutex.Lock()
+	b.accounts[a] = struct{}{}
+	b.reserved += reserve
+	b.mutex.Unlock()
+	return a
+}
+
+// close gives the bytes of a deleted Queue back, its values don't count
+// anymore.
+func (b *budget) close(a *account) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.shared -= a.over(a.used)
+	b.reserved -= a.reserve
+	a.closed = true
+	delete(b.accounts, a)
+}
+
+// largest returns the account which uses the most shared bytes, or nil.
+func (b *budget) largest() *account {
+	var max *account
+	for a := range b.accounts {
+		if a.over(a.used) > 0 && (max == nil || a.over(a.used) > max.over(max.used)) {
+			max = a
+		}
+	}
+	return max
+}
+
+// charge counts val against the budget, it evicts values by the Policy or
+// returns ErrOverBudget if it doesn't fit, unless force. It must be called
+// without the lock of any Queue.
+func (a *account) charge(val interface{}, force bool) error {
+	b := a.b
+	n := b.SizeOf(val)
+	for {
+		b.mutex.Lock()
+		need := a.over(a.used+n) - a.over(a.used)
+		if a.closed || force || b.shared+need <= b.Bytes-b.reserved {
+			a.used += n
+			if !a.closed {
+				b.shared += need
+			}
+			b.mutex.Unlock()
+			return nil
+		}
+		var victim *account
+		if b.Policy == DropOldest {
+			victim = b.largest()
+		}
+		b.mutex.Unlock()
+		if victim == nil || !victim.queue.evictFront() {
+			atomic.AddInt64(&a.queue.stats.rejected, 1)
+			return ErrOverBudget
+		}
+	}
+}
+
+// release gives the bytes of val back once it leaves the Queue.
+func (a *account) release(val interface{}) {
+	b := a.b
+	n := b.SizeOf(val)
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if !a.closed {
+		b.shared -= a.over(a.used) - a.over(a.used-n)
+	}
+	a.used -= n
+}
+
+// charge counts val against the budget of Queue, if any.
+func (q *Queue) charge(val interface{}, force bool) error {
+	if q.account == nil {
+		return nil
+	}
+	return q.account.charge(val, force)
+}
+
+// release gives the bytes of val back to the budget of Queue, if any.
+func (q *Queue) release(val interface{}) {
+	if q.account != nil {
+		q.account.release(val)
+	}
+}
+
+// evictFront drops the oldest value to make room in the budget, it returns
+// false if Queue is empty.
+func (q *Queue) evictFront() bool {
+	q.mutex.Lock()
+	defer q.unlock()
+	if q.isempty() {
+		return false
+	}
+	q.evictOldest()
+	q.clearPending()
+	return true
+}
@@ -0,0 +1,62 @@
+package goqueue
+
+// OutChan returns a channel fed from Queue, so Get can take part in a
+// select. A goroutine gets values one at a time and sends them on the
+// channel, it stops and closes the channel once stop is closed; a value it
+// got but couldn't send meanwhile is put back at the front of Queue.
+func (q *Queue) OutChan(stop <-chan struct{}) <-chan interface{} {
+	out := make(chan interface{})
+	go func() {
+		defer close(out)
+		for {
+			val, err := q.getUntil(0, stop, q.lifo)
+			if err != nil {
+				return
+			}
+			select {
+			case out <- val:
+			case <-stop:
+				q.putBack(val)
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// InChan returns a channel which feeds Queue, so Put can take part in a
+// select. A goroutine puts the values received, blocking while Queue is
+// full so senders block too, until the channel is closed or stop is
+// closed. A value received but not put yet when stop is closed is dropped,
+// see Hooks.OnDrop, if Queue is still full; so is a value which Queue
+// refuses, then the goroutine goes on with the next one.
+func (q *Queue) InChan(stop <-chan struct{}) chan<- interface{} {
+	in := make(chan interface{})
+	go func() {
+		for {
+			select {
+			case val, ok := <-in:
+				if !ok {
+					return
+				}
+				if timeout, ok := q.throttle(0, stop); ok && q.putUntil(val, timeout, stop, false) == nil {
+					continue
+				}
+				select {
+				case <-stop:
+					if q.PutNoWait(val) != nil {
+						q.fireDrop(val)
+					}
+					return
+				default:
+					// Refused without waiting, e.g. by the Reject policy or
+					// WithPutRate, the channel keeps feeding Queue.
+					q.fireDrop(val)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+	return in
+}
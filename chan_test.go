@@ -0,0 +1,79 @@
+package goqueue
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestChanAdapters(t *testing.T) {
+	queue := New(2)
+	stop := make(chan struct{})
+
+	fmt.Println("Test InChan feeds Queue with backpressure...")
+	in := queue.InChan(stop)
+	in <- 1
+	in <- 2
+	in <- 3
+	select {
+	case in <- 4:
+		t.Fatalf("Expect the sender blocked on a full Queue\n")
+	case <-time.After(50 * time.Millisecond):
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test OutChan takes part in a select...")
+	out := queue.OutChan(stop)
+	for _, expect := range []int{1, 2, 3} {
+		select {
+		case val := <-out:
+			if val.(int) != expect {
+				t.Fatalf("Expect %v, got %v\n", expect, val)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Expect %v, got nothing\n", expect)
+		}
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test stop closes OutChan and keeps the value in hand...")
+	in <- 4
+	time.Sleep(50 * time.Millisecond)
+	close(stop)
+	for range out {
+	}
+	if val, err := queue.GetNoWait(); err != nil || val.(int) != 4 {
+		t.Fatalf("Expect 4 back in Queue, got %v (%v)\n", val, err)
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestInChanRefused(t *testing.T) {
+	dropped := make(chan interface{}, 4)
+	queue := New(1, WithFullPolicy(Reject), WithHooks(Hooks{OnDrop: func(val interface{}) { dropped <- val }}))
+	stop := make(chan struct{})
+	defer close(stop)
+
+	fmt.Println("Test InChan goes on after a refused value...")
+	in := queue.InChan(stop)
+	in <- 1
+	in <- 2
+	select {
+	case val := <-dropped:
+		if val.(int) != 2 {
+			t.Fatalf("Expect 2 dropped, got %v\n", val)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expect 2 dropped\n")
+	}
+	queue.GetNoWait()
+	select {
+	case in <- 3:
+	case <-time.After(time.Second):
+		t.Fatalf("Expect the channel still fed\n")
+	}
+	if val, err := queue.Get(1); err != nil || val.(int) != 3 {
+		t.Fatalf("Expect 3, got %v (%v)\n", val, err)
+	}
+	fmt.Println("  ...PASSED")
+}
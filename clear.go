@@ -0,0 +1,102 @@
+package goqueue
+
+// abort is sent to the blocked Get and Put operators by Reset.
+type abort struct{}
+
+// received returns the result of a Get operator woken up with v.
+func received(v interface{}) (interface{}, error) {
+	if _, ok := v.(abort); ok {
+		return nil, ErrEmptyQueue
+	}
+	return v, nil
+}
+
+// moved returns the result of a Put operator woken up with v.
+func moved(v interface{}) error {
+	if _, ok := v.(abort); ok {
+		return ErrFullQueue
+	}
+	return nil
+}
+
+// RemoveFunc removes the values for which pred returns true under a single
+// lock acquisition, keeping the order of the others, and returns how many
+// were removed. Blocked Put operators are moved in afterwards. pred is
+// called with the lock held, it must not use the Queue.
+func (q *Queue) RemoveFunc(pred func(val interface{}) bool) int {
+	return len(q.removeIf(pred))
+}
+
+// removeIf removes the values matching fn, keeping the order of the others,
+// and returns them. They pass the barriers as got values do and count as
+// done for Join and as Dropped, but no OnGet hook is called, they are
+// settled as Removed.
+func (q *Queue) removeIf(fn func(val interface{}) bool) []interface{} {
+	q.mutex.Lock()
+	defer q.unlock()
+	return q.removeLocked(fn)
+}
+
+func (q *Queue) removeLocked(fn func(val interface{}) bool) []interface{} {
+	var removed []interface{}
+	for n := q.items.Len(); n > 0; n-- {
+		val := q.items.popFront()
+		at := q.latency.pop(false)
+		gen, marked := q.unmark(false)
+		if fn(val) {
+			removed = append(removed, val)
+			q.release(val)
+			q.settle(val, Removed)
+			if marked {
+				q.pass(gen)
+			}
+		} else {
+			q.items.pushBack(val)
+			q.latency.push(false, at)
+			q.mark(false, gen)
+		}
+	}
+	if len(removed) != 0 {
+		q.publish()
+		// Counted as Dropped, not as Gets, so they don't feed the elastic
+		// sizing either.
+		q.observeDrop(len(removed))
+		q.finish(len(removed))
+		q.clearPending()
+	}
+	return removed
+}
+
+// Clear discards every value in Queue, and returns how many were there.
+// Each one is passed to dispose if it is not nil, once the lock is
+// released. Blocked Put operators are moved in afterwards.
+func (q *Queue) Clear(dispose func(val interface{})) int {
+	return disposeAll(q.removeIf(all), dispose)
+}
+
+func all(interface{}) bool { return true }
+
+func disposeAll(vals []interface{}, dispose func(val interface{})) int {
+	if dispose != nil {
+		for _, val := range vals {
+			dispose(val)
+		}
+	}
+	return len(vals)
+}
+
+// Reset is Clear which also aborts the blocked operators: Get returns
+// ErrEmptyQueue and Put returns ErrFullQueue, its value is not put.
+func (q *Queue) Reset(dispose func(val interface{})) int {
+	q.mutex.Lock()
+	for q.putters.Len() != 0 {
+		p := q.putters.Remove(q.putters.Front()).(*putter)
+		p.w <- abort{}
+	}
+	for q.getters.Len() != 0 {
+		q.getters.Remove(q.getters.Front()).(waiter) <- abort{}
+	}
+	vals := q.removeLocked(all)
+	q.unlock()
+	return disposeAll(vals, dispose)
+}
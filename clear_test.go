@@ -0,0 +1,111 @@
+package goqueue
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestClear(t *testing.T) {
+	queue := New(2)
+	queue.PutAll([]interface{}{1, 2})
+	done := make(chan error, 1)
+	go func() { done <- queue.Put(3, 1) }()
+	waitPutters(queue, 1)
+
+	fmt.Println("Test Clear discards the values and moves putters in...")
+	var disposed []interface{}
+	if n := queue.Clear(func(val interface{}) { disposed = append(disposed, val) }); n != 2 {
+		t.Fatalf("Expect 2 values cleared, got %d\n", n)
+	}
+	if len(disposed) != 2 || disposed[0].(int) != 1 {
+		t.Fatalf("Expect [1 2] disposed, got %v\n", disposed)
+	}
+	if err := <-done; err != nil || queue.Size() != 1 {
+		t.Fatalf("Expect 3 moved in, got size %d (%v)\n", queue.Size(), err)
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestReset(t *testing.T) {
+	queue := New(1)
+	queue.PutNoWait(1)
+	done := make(chan error, 1)
+	go func() { done <- queue.Put(2, 0) }()
+	waitPutters(queue, 1)
+
+	fmt.Println("Test Reset aborts blocked putters...")
+	if n := queue.Reset(nil); n != 1 {
+		t.Fatalf("Expect 1 value cleared, got %d\n", n)
+	}
+	if err := <-done; err != ErrFullQueue || !queue.IsEmpty() {
+		t.Fatalf("Expect %v and Queue empty, got %v\n", ErrFullQueue, err)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test Reset aborts blocked getters...")
+	got := make(chan error, 1)
+	go func() {
+		_, err := queue.Get(0)
+		got <- err
+	}()
+	for {
+		queue.mutex.Lock()
+		n := queue.getters.Len()
+		queue.mutex.Unlock()
+		if n == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	queue.Reset(nil)
+	if err := <-got; err != ErrEmptyQueue {
+		t.Fatalf("Expect %v, got %v\n", ErrEmptyQueue, err)
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func waitPutters(q *Queue, n int) {
+	for {
+		q.mutex.Lock()
+		l := q.putters.Len()
+		q.mutex.Unlock()
+		if l == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRemoveFunc(t *testing.T) {
+	queue := New(0)
+	for i := 0; i < 6; i++ {
+		queue.PutNoWait(i)
+	}
+
+	fmt.Println("Test RemoveFunc removes the matching values only...")
+	if n := queue.RemoveFunc(func(val interface{}) bool { return val.(int)%2 == 1 }); n != 3 {
+		t.Fatalf("Expect 3 values removed, got %d\n", n)
+	}
+	for _, expect := range []int{0, 2, 4} {
+		if val, err := queue.GetNoWait(); err != nil || val.(int) != expect {
+			t.Fatalf("Expect %d, got %v (%v)\n", expect, val, err)
+		}
+	}
+	if n := queue.RemoveFunc(func(interface{}) bool { return true }); n != 0 {
+		t.Fatalf("Expect nothing removed from an empty Queue, got %d\n", n)
+	}
+	if s := queue.Stats(); s.Gets != 3 || s.Dropped != 3 {
+		t.Fatalf("Expect 3 Gets and 3 Dropped, got %d and %d\n", s.Gets, s.Dropped)
+	}
+	if err := queue.Join(-1); err != ErrUnfinished {
+		t.Fatalf("Expect %v, got %v\n", ErrUnfinished, err)
+	}
+	for i := 0; i < 3; i++ {
+		queue.TaskDone()
+	}
+	if err := queue.Join(-1); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	fmt.Println("  ...PASSED")
+}
@@ -0,0 +1,164 @@
+/*
+Command goqueuectl operates goqueue backends.
+
+Usage:
+
+	goqueuectl migrate [flags] SRC DST
+
+migrate moves the backlog of the queue SRC to the queue DST, see
+goqueue.Migration. A queue is given as
+
+	wal:PATH                         the walqueue log at PATH
+	http://HOST/queues/NAME          the queue NAME of an httpserver.Server
+
+The SQL and Redis backends need a driver or a client picked by the
+application, migrate them with goqueue.Migration directly.
+
+With -checkpoint the IDs of the moved values are kept in a file, running
+the same command again after a crash doesn't move them twice, -id tells how
+the values are identified.
+*/
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/damnever/goqueue"
+	"github.com/damnever/goqueue/httpserver"
+	"github.com/damnever/goqueue/walqueue"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] != "migrate" {
+		fmt.Fprintln(stderr, "usage: goqueuectl migrate [flags] SRC DST")
+		return 2
+	}
+	if err := migrate(args[1:], stdout, stderr); err != nil {
+		fmt.Fprintf(stderr, "goqueuectl: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// queue is a backend opened by open.
+type queue interface {
+	goqueue.Interface
+	goqueue.Closer
+}
+
+// open returns the queue of spec, see the package doc.
+func open(spec string) (queue, error) {
+	if strings.HasPrefix(spec, "wal:") {
+		return walqueue.Open(strings.TrimPrefix(spec, "wal:"), 0, walqueue.Options{})
+	}
+	u, err := url.Parse(spec)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unknown queue %q", spec)
+	}
+	i := strings.LastIndex(u.Path, "/queues/")
+	if i < 0 || i+len("/queues/") == len(u.Path) {
+		return nil, fmt.Errorf("no queue name in %q", spec)
+	}
+	name := u.Path[i+len("/queues/"):]
+	u.Path = u.Path[:i]
+	return httpserver.NewClient(u.String(), name), nil
+}
+
+// idOf returns the Migration.ID of the -id flag.
+func idOf(kind string) (func(val interface{}) string, error) {
+	switch kind {
+	case "":
+		return nil, nil
+	case "value":
+		return func(val interface{}) string { return fmt.Sprintf("%#v", val) }, nil
+	case "message":
+		return func(val interface{}) string {
+			switch m := val.(type) {
+			case *goqueue.Message:
+				return m.ID
+			case map[string]interface{}: // a Message decoded from JSON
+				if id, ok := m["ID"].(string); ok {
+					return id
+				}
+			}
+			return fmt.Sprintf("%#v", val)
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown -id %q", kind)
+}
+
+func migrate(args []string, stdout, stderr io.Writer) (err error) {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	checkpoint := flags.String("checkpoint", "", "file keeping the IDs of the moved values")
+	id := flags.String("id", "", `identify values by "message" ID or by "value"`)
+	timeout := flags.Float64("timeout", 0, "seconds to wait for each Put into DST, 0 blocks")
+	every := flags.Int("progress", 1000, "report the progress every this many values, 0 never")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		return errors.New("migrate needs SRC and DST")
+	}
+	idFunc, err := idOf(*id)
+	if err != nil {
+		return err
+	}
+	if *checkpoint != "" && idFunc == nil {
+		return errors.New("-checkpoint needs -id")
+	}
+
+	src, err := open(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer closeAll(src, &err)
+	dst, err := open(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+	defer closeAll(dst, &err)
+
+	m := goqueue.NewMigration(src, dst)
+	m.ID = idFunc
+	m.Timeout = *timeout
+	if *every > 0 {
+		m.Progress = func(moved int) {
+			if moved%*every == 0 {
+				fmt.Fprintf(stderr, "moved %d values\n", moved)
+			}
+		}
+	}
+	if *checkpoint != "" {
+		c, err := goqueue.OpenFileCheckpoint(*checkpoint)
+		if err != nil {
+			return err
+		}
+		defer c.Close()
+		m.Checkpoint = c
+	}
+	moved, err := m.Run()
+	fmt.Fprintf(stdout, "%d values moved\n", moved)
+	return err
+}
+
+// closeAll closes q, its error is kept in err unless err is set already.
+func closeAll(q queue, err *error) {
+	if cerr := q.Close(); *err == nil {
+		*err = cerr
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/damnever/goqueue"
+	"github.com/damnever/goqueue/httpserver"
+	"github.com/damnever/goqueue/walqueue"
+)
+
+func TestMigrate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goqueuectl")
+	if err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "src.wal")
+	checkpoint := filepath.Join(dir, "migration.checkpoint")
+
+	q, err := walqueue.Open(path, 0, walqueue.Options{})
+	if err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	messages := []*goqueue.Message{goqueue.NewMessage("a"), goqueue.NewMessage("b")}
+	for _, m := range messages {
+		q.PutNoWait(m)
+	}
+	q.Close()
+
+	s := httpserver.New()
+	dst := goqueue.New(0)
+	s.Add("jobs", dst)
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	fmt.Println("Test migrate moves a wal log to an httpserver queue...")
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	args := []string{"migrate", "-id", "message", "-checkpoint", checkpoint, "wal:" + path, ts.URL + "/queues/jobs"}
+	if code := run(args, stdout, stderr); code != 0 {
+		t.Fatalf("Expect exit code 0, got %d: %s\n", code, stderr)
+	}
+	if stdout.String() != "2 values moved\n" || dst.Size() != 2 {
+		t.Fatalf("Expect 2 values moved, got %q with %d in dst\n", stdout, dst.Size())
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test a rerun skips the checkpointed values...")
+	// As if the first message was redelivered after a crash.
+	if q, err = walqueue.Open(path, 0, walqueue.Options{}); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	q.PutNoWait(messages[0])
+	q.Close()
+	stdout.Reset()
+	if code := run(args, stdout, stderr); code != 0 {
+		t.Fatalf("Expect exit code 0, got %d: %s\n", code, stderr)
+	}
+	if stdout.String() != "0 values moved\n" || dst.Size() != 2 {
+		t.Fatalf("Expect no value moved, got %q with %d in dst\n", stdout, dst.Size())
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test bad arguments...")
+	for _, args := range [][]string{
+		{},
+		{"migrate", "wal:" + path},
+		{"migrate", "-checkpoint", checkpoint, "wal:" + path, "wal:" + path},
+		{"migrate", "wal:" + path, "redis://localhost/jobs"},
+		{"migrate", "wal:" + path, ts.URL + "/queues/"},
+	} {
+		if code := run(args, stdout, stderr); code == 0 {
+			t.Fatalf("Expect an error for %v\n", args)
+		}
+	}
+	fmt.Println("  ...PASSED")
+}
@@ -0,0 +1,129 @@
+package goqueue
+
+import (
+	"container/list"
+	"sync"
+)
+
+type coalescedItem struct {
+	key   string
+	value interface{}
+}
+
+// CoalescingQueue is a GoRoutine safe queue holding at most one value per
+// key: a value put with a key which is pending replaces the pending one,
+// so consumers only see the latest state of each key. Keys are got in the
+// order they were first put since they were last got.
+type CoalescingQueue struct {
+	maxSize int
+	mutex   sync.Mutex
+	items   *list.List               // store *coalescedItem, in FIFO order
+	keys    map[string]*list.Element // pending items by key
+	putters *list.List               // store blocked Put operators
+	getters *list.List               // store blocked Get operators
+
+	// Merge, if not nil, combines the pending value of a key with the new
+	// one instead of replacing it. It is called with the lock held and must
+	// be set before the CoalescingQueue is used.
+	Merge func(old, new interface{}) interface{}
+}
+
+// NewCoalescing create a new CoalescingQueue, the maxSize variable sets the
+// max number of pending keys. If maxSize is zero, CoalescingQueue will be
+// infinite size, and Put always no wait.
+func NewCoalescing(maxSize int) *CoalescingQueue {
+	q := new(CoalescingQueue)
+	q.maxSize = maxSize
+	q.items = list.New()
+	q.keys = make(map[string]*list.Element)
+	q.putters = list.New()
+	q.getters = list.New()
+	return q
+}
+
+func (q *CoalescingQueue) isfull() bool {
+	return (q.maxSize > 0 && q.maxSize <= q.items.Len())
+}
+
+// Same as Get(-1).
+func (q *CoalescingQueue) GetNoWait() (string, interface{}, error) {
+	return q.Get(-1)
+}
+
+// Get returns the key pending for the longest time with its latest value,
+// the timeout semantics are the same as Queue.Get.
+func (q *CoalescingQueue) Get(timeout float64) (string, interface{}, error) {
+	deadline, stop := deadlineOf(timeout)
+	defer stop()
+
+	q.mutex.Lock()
+	for {
+		if q.items.Len() > 0 {
+			item := q.items.Remove(q.items.Front()).(*coalescedItem)
+			delete(q.keys, item.key)
+			wake(q.getters, q.items.Len())
+			wake(q.putters, 1)
+			q.mutex.Unlock()
+			return item.key, item.value, nil
+		}
+		if !await(&q.mutex, q.getters, timeout, deadline) {
+			q.mutex.Unlock()
+			return "", nil, ErrEmptyQueue
+		}
+	}
+}
+
+// Same as Put(key, val, -1).
+func (q *CoalescingQueue) PutNoWait(key string, val interface{}) error {
+	return q.Put(key, val, -1)
+}
+
+// Put the value of key, replacing or merging with the pending one, which
+// never waits. For a new key the timeout semantics are the same as
+// Queue.Put.
+func (q *CoalescingQueue) Put(key string, val interface{}, timeout float64) error {
+	deadline, stop := deadlineOf(timeout)
+	defer stop()
+
+	q.mutex.Lock()
+	for {
+		if e, ok := q.keys[key]; ok {
+			item := e.Value.(*coalescedItem)
+			if q.Merge != nil {
+				val = q.Merge(item.value, val)
+			}
+			item.value = val
+			q.mutex.Unlock()
+			return nil
+		}
+		if !q.isfull() {
+			q.keys[key] = q.items.PushBack(&coalescedItem{key: key, value: val})
+			wake(q.getters, 1)
+			q.mutex.Unlock()
+			return nil
+		}
+		if !await(&q.mutex, q.putters, timeout, deadline) {
+			q.mutex.Unlock()
+			return ErrFullQueue
+		}
+	}
+}
+
+// Return the number of pending keys.
+func (q *CoalescingQueue) Size() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.items.Len()
+}
+
+// Return true if CoalescingQueue is empty.
+func (q *CoalescingQueue) IsEmpty() bool {
+	return q.Size() == 0
+}
+
+// Return true if CoalescingQueue is full.
+func (q *CoalescingQueue) IsFull() bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.isfull()
+}
@@ -0,0 +1,76 @@
+package goqueue
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestCoalescingQueue(t *testing.T) {
+	queue := NewCoalescing(2)
+
+	fmt.Println("Test a pending key is replaced and keeps its place...")
+	queue.PutNoWait("a", 1)
+	queue.PutNoWait("b", 1)
+	if err := queue.PutNoWait("a", 2); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	if queue.Size() != 2 {
+		t.Fatalf("Expect 2 pending keys, got %d\n", queue.Size())
+	}
+	for _, expect := range []struct {
+		key string
+		val int
+	}{{"a", 2}, {"b", 1}} {
+		key, val, err := queue.GetNoWait()
+		if err != nil || key != expect.key || val.(int) != expect.val {
+			t.Fatalf("Expect %s=%d, got %s=%v (%v)\n", expect.key, expect.val, key, val, err)
+		}
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test a new key waits while full...")
+	queue.PutNoWait("a", 1)
+	queue.PutNoWait("b", 1)
+	if err := queue.PutNoWait("c", 1); err != ErrFullQueue {
+		t.Fatalf("Expect %v, got %v\n", ErrFullQueue, err)
+	}
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		queue.GetNoWait()
+	}()
+	if err := queue.Put("c", 1, 1); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test Get blocks until a key is put...")
+	queue.GetNoWait()
+	queue.GetNoWait()
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		queue.PutNoWait("d", 1)
+	}()
+	if key, _, err := queue.Get(1); err != nil || key != "d" {
+		t.Fatalf("Expect d, got %s (%v)\n", key, err)
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestCoalescingMerge(t *testing.T) {
+	queue := NewCoalescing(0)
+	queue.Merge = func(old, new interface{}) interface{} { return old.(int) + new.(int) }
+
+	fmt.Println("Test pending values are merged...")
+	for i := 1; i <= 3; i++ {
+		queue.PutNoWait("sum", i)
+	}
+	if _, val, err := queue.GetNoWait(); err != nil || val.(int) != 6 {
+		t.Fatalf("Expect 6, got %v (%v)\n", val, err)
+	}
+	queue.PutNoWait("sum", 1)
+	if _, val, _ := queue.GetNoWait(); val.(int) != 1 {
+		t.Fatalf("Expect a key got is not merged any more, got %v\n", val)
+	}
+	fmt.Println("  ...PASSED")
+}
@@ -0,0 +1,28 @@
+package goqueue
+
+import (
+	"bytes"
+	"encoding/gob"
+)
+
+// Codec converts values to and from the bytes stored by durable backends.
+type Codec interface {
+	Marshal(val interface{}) ([]byte, error)
+	Unmarshal(data []byte) (interface{}, error)
+}
+
+// GobCodec encodes values with encoding/gob, custom types must be
+// registered by gob.Register.
+type GobCodec struct{}
+
+func (GobCodec) Marshal(val interface{}) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	err := gob.NewEncoder(buf).Encode(&val)
+	return buf.Bytes(), err
+}
+
+func (GobCodec) Unmarshal(data []byte) (interface{}, error) {
+	var val interface{}
+	err := gob.NewDecoder(bytes.NewReader(data)).Decode(&val)
+	return val, err
+}
@@ -0,0 +1,125 @@
+package goqueue
+
+import (
+	"fmt"
+	"runtime/debug"
+	"sync"
+)
+
+// DefaultMaxPanics is used when Consumer.MaxPanics is zero.
+const DefaultMaxPanics = 3
+
+// PanicError is the failure of a handler which panicked.
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Consumer runs a handler for the values of its Queue, a panic in the
+// handler is recovered and the value is put back at the front of the Queue,
+// so one bad value doesn't take a worker down. A value put back counts as
+// neither a new Put nor a new task, it is marked done by TaskDone once it is
+// handled or given up.
+//
+// A value put back is wrapped with its number of panics, so the Queue should
+// be read only by the Consumer, which unwraps it.
+type Consumer struct {
+	queue  *Queue
+	handle func(val interface{})
+
+	// MaxPanics is the number of panics after which a value is moved to
+	// DeadLetter instead of the Queue, zero means DefaultMaxPanics.
+	MaxPanics int
+	// DeadLetter receives the values given up, they are dropped if it is
+	// nil or full. Values are put back even if the Queue is full.
+	DeadLetter *Queue
+	// OnPanic is called, if not nil, every time the handler panics.
+	OnPanic func(val interface{}, err *PanicError)
+}
+
+// delivery is a value put back by a Consumer, it counts the panics of this
+// very value, so equal values don't share their counts.
+type delivery struct {
+	consumer *Consumer
+	val      interface{}
+	panics   int
+}
+
+// NewConsumer create a Consumer which calls handle for every value.
+func (q *Queue) NewConsumer(handle func(val interface{})) *Consumer {
+	return &Consumer{
+		queue:  q,
+		handle: handle,
+	}
+}
+
+// Run starts workers goroutines and blocks until stop is closed and every
+// running handler has returned.
+func (c *Consumer) Run(workers int, stop <-chan struct{}) {
+	wg := &sync.WaitGroup{}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				val, err := c.queue.Get(0.1)
+				if err != nil {
+					continue
+				}
+				d, ok := val.(*delivery)
+				if !ok || d.consumer != c {
+					d = &delivery{consumer: c, val: val}
+				}
+				c.process(d)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func (c *Consumer) process(d *delivery) {
+	err := c.run(d.val)
+	if err == nil {
+		c.queue.TaskDone()
+		return
+	}
+	if c.OnPanic != nil {
+		c.OnPanic(d.val, err)
+	}
+
+	max := c.MaxPanics
+	if max <= 0 {
+		max = DefaultMaxPanics
+	}
+	d.panics++
+	if d.panics < max {
+		c.queue.putBack(d)
+		return
+	}
+	if c.DeadLetter != nil && c.DeadLetter.PutNoWait(d.val) == nil {
+		settled(d.val, DeadLettered)
+	} else {
+		settled(d.val, Dropped)
+	}
+	c.queue.TaskDone()
+}
+
+// run calls the handler with panic recovery.
+func (c *Consumer) run(val interface{}) (err *PanicError) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = &PanicError{Value: p, Stack: debug.Stack()}
+		}
+	}()
+	c.handle(val)
+	return nil
+}
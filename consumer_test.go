@@ -0,0 +1,177 @@
+package goqueue
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestConsumerPanics(t *testing.T) {
+	queue := New(0)
+	dead := New(0)
+	mutex := sync.Mutex{}
+	seen := make(map[string]int)
+	panics := 0
+
+	c := queue.NewConsumer(func(val interface{}) {
+		mutex.Lock()
+		seen[val.(string)]++
+		mutex.Unlock()
+		if val.(string) == "bad" {
+			panic("bad value")
+		}
+	})
+	c.DeadLetter = dead
+	c.OnPanic = func(val interface{}, err *PanicError) {
+		mutex.Lock()
+		panics++
+		mutex.Unlock()
+		if err.Value != "bad value" || len(err.Stack) == 0 {
+			t.Errorf("Unexpect panic error: %v\n", err)
+		}
+	}
+
+	fmt.Println("Test a panicking value is put back, then dead lettered...")
+	queue.PutNoWait("bad")
+	queue.PutNoWait("good")
+	stop := make(chan struct{})
+	finished := make(chan bool)
+	go func() {
+		c.Run(1, stop)
+		finished <- true
+	}()
+	val, err := dead.Get(2)
+	if err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	} else if val.(string) != "bad" {
+		t.Fatalf("Expect %v, got %v\n", "bad", val)
+	}
+	close(stop)
+	<-finished
+	mutex.Lock()
+	defer mutex.Unlock()
+	if seen["bad"] != DefaultMaxPanics || panics != DefaultMaxPanics || seen["good"] != 1 {
+		t.Fatalf("Expect bad run %d times and good once, got %v (%d panics)\n",
+			DefaultMaxPanics, seen, panics)
+	}
+	if !queue.IsEmpty() {
+		t.Fatalf("Expect Queue is empty, got size %d\n", queue.Size())
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestConsumerUncomparable(t *testing.T) {
+	queue := New(0)
+	dead := New(0)
+	mutex := sync.Mutex{}
+	runs := 0
+	c := queue.NewConsumer(func(val interface{}) {
+		mutex.Lock()
+		runs++
+		mutex.Unlock()
+		panic("always")
+	})
+	c.DeadLetter = dead
+
+	fmt.Println("Test an uncomparable value is put back, then dead lettered...")
+	queue.PutNoWait([]int{1})
+	stop := make(chan struct{})
+	go c.Run(2, stop)
+	defer close(stop)
+	val, err := dead.Get(2)
+	if err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	} else if v, ok := val.([]int); !ok || v[0] != 1 {
+		t.Fatalf("Expect the value unwrapped, got %v\n", val)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if !queue.IsEmpty() || !dead.IsEmpty() {
+		t.Fatalf("Expect the value given up once\n")
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	if runs != DefaultMaxPanics {
+		t.Fatalf("Expect %d runs, got %d\n", DefaultMaxPanics, runs)
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestConsumerEqualValues(t *testing.T) {
+	queue := New(0)
+	dead := New(0)
+	mutex := sync.Mutex{}
+	order := []string{}
+	good := make(chan bool, 1)
+	c := queue.NewConsumer(func(val interface{}) {
+		mutex.Lock()
+		order = append(order, val.(string))
+		mutex.Unlock()
+		if val.(string) == "bad" {
+			panic("bad value")
+		}
+		good <- true
+	})
+	c.DeadLetter = dead
+
+	fmt.Println("Test equal values count their panics apart and keep the order...")
+	queue.PutNoWait("bad")
+	queue.PutNoWait("bad")
+	queue.PutNoWait("good")
+	stop := make(chan struct{})
+	finished := make(chan bool)
+	go func() {
+		c.Run(1, stop)
+		finished <- true
+	}()
+	for i := 0; i < 2; i++ {
+		if _, err := dead.Get(2); err != nil {
+			t.Fatalf("Unexpect error: %v\n", err)
+		}
+	}
+	select {
+	case <-good:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Expect good value is handled\n")
+	}
+	close(stop)
+	<-finished
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(order) != 2*DefaultMaxPanics+1 || order[len(order)-1] != "good" {
+		t.Fatalf("Expect bad run %d times each before good, got %v\n", DefaultMaxPanics, order)
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestConsumerRetryStats(t *testing.T) {
+	queue := New(1)
+	var runs int32
+	c := queue.NewConsumer(func(val interface{}) {
+		if atomic.AddInt32(&runs, 1) == 1 {
+			panic("first run")
+		}
+	})
+
+	fmt.Println("Test a retried value is not counted again...")
+	queue.PutNoWait("flaky")
+	stop := make(chan struct{})
+	finished := make(chan bool)
+	go func() {
+		c.Run(1, stop)
+		finished <- true
+	}()
+	if err := queue.Join(2); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	close(stop)
+	<-finished
+	if n := atomic.LoadInt32(&runs); n != 2 {
+		t.Fatalf("Expect 2 runs, got %d\n", n)
+	}
+	if s := queue.Stats(); s.Puts != 1 || s.Gets != 2 || s.Size != 0 {
+		t.Fatalf("Expect 1 Put and 2 Gets, got %+v\n", s)
+	}
+	fmt.Println("  ...PASSED")
+}
@@ -0,0 +1,150 @@
+package goqueue
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// The ID is in the queue already, or was got within the window.
+var ErrDuplicate = errors.New("duplicate id")
+
+type dedupItem struct {
+	id    string
+	value interface{}
+}
+
+type seenID struct {
+	id      string
+	expires time.Time
+}
+
+// DedupQueue is a Queue which refuses a value whose ID is in the queue
+// already, or was got less than window ago, for idempotent ingestion.
+type DedupQueue struct {
+	queue  *Queue
+	window time.Duration
+	mutex  sync.Mutex
+	ids    map[string]time.Time // zero while queued, else when forgotten
+	seen   *list.List           // store *seenID of got values, by expiry
+
+	// ID derives the ID of the values given to Put, default is
+	// fmt.Sprint(val). It must be set before the DedupQueue is used.
+	ID func(val interface{}) string
+}
+
+// NewDedup create a DedupQueue, the maxSize variable sets the max size and
+// IDs are remembered window seconds after their value is got. If window is
+// zero, only the IDs in the queue are refused.
+func NewDedup(maxSize int, window float64) *DedupQueue {
+	return &DedupQueue{
+		queue:  New(maxSize),
+		window: seconds(window),
+		ids:    make(map[string]time.Time),
+		seen:   list.New(),
+	}
+}
+
+// forget drops the IDs whose window passed.
+func (q *DedupQueue) forget(now time.Time) {
+	for q.seen.Len() != 0 {
+		e := q.seen.Front()
+		s := e.Value.(*seenID)
+		if now.Before(s.expires) {
+			return
+		}
+		q.seen.Remove(e)
+		// The ID may have been put again meanwhile.
+		if at, ok := q.ids[s.id]; ok && at.Equal(s.expires) {
+			delete(q.ids, s.id)
+		}
+	}
+}
+
+// reserve claims id, it returns false for a duplicate.
+func (q *DedupQueue) reserve(id string) bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.forget(time.Now())
+	if _, ok := q.ids[id]; ok {
+		return false
+	}
+	q.ids[id] = time.Time{}
+	return true
+}
+
+// release takes id off the queued IDs, if remember it is refused for the
+// window still.
+func (q *DedupQueue) release(id string, remember bool) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if !remember || q.window <= 0 {
+		delete(q.ids, id)
+		return
+	}
+	expires := time.Now().Add(q.window)
+	q.ids[id] = expires
+	q.seen.PushBack(&seenID{id: id, expires: expires})
+}
+
+// Same as Put(val, -1).
+func (q *DedupQueue) PutNoWait(val interface{}) error {
+	return q.Put(val, -1)
+}
+
+// Put is PutID with the ID derived by q.ID.
+func (q *DedupQueue) Put(val interface{}, timeout float64) error {
+	var id string
+	if q.ID != nil {
+		id = q.ID(val)
+	} else {
+		id = fmt.Sprint(val)
+	}
+	return q.PutID(id, val, timeout)
+}
+
+// PutID puts val with the given ID, or returns ErrDuplicate. The timeout
+// semantics are the same as Queue.Put, the ID is free again if it fails.
+func (q *DedupQueue) PutID(id string, val interface{}, timeout float64) error {
+	if !q.reserve(id) {
+		return ErrDuplicate
+	}
+	if err := q.queue.Put(&dedupItem{id: id, value: val}, timeout); err != nil {
+		q.release(id, false)
+		return err
+	}
+	return nil
+}
+
+// Same as Get(-1).
+func (q *DedupQueue) GetNoWait() (interface{}, error) {
+	return q.Get(-1)
+}
+
+// Get has the same timeout semantics as Queue.Get.
+func (q *DedupQueue) Get(timeout float64) (interface{}, error) {
+	val, err := q.queue.Get(timeout)
+	if err != nil {
+		return nil, err
+	}
+	item := val.(*dedupItem)
+	q.release(item.id, true)
+	return item.value, nil
+}
+
+// Return size of DedupQueue.
+func (q *DedupQueue) Size() int {
+	return q.queue.Size()
+}
+
+// Return true if DedupQueue is empty.
+func (q *DedupQueue) IsEmpty() bool {
+	return q.queue.IsEmpty()
+}
+
+// Return true if DedupQueue is full.
+func (q *DedupQueue) IsFull() bool {
+	return q.queue.IsFull()
+}
@@ -0,0 +1,67 @@
+package goqueue
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+var _ Interface = (*DedupQueue)(nil)
+
+func TestDedupQueue(t *testing.T) {
+	queue := NewDedup(0, 0.05)
+
+	fmt.Println("Test an ID in the queue is refused...")
+	if err := queue.PutID("a", 1, -1); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	if err := queue.PutID("a", 2, -1); err != ErrDuplicate {
+		t.Fatalf("Expect %v, got %v\n", ErrDuplicate, err)
+	}
+	if val, err := queue.GetNoWait(); err != nil || val.(int) != 1 {
+		t.Fatalf("Expect 1, got %v (%v)\n", val, err)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test an ID got is refused within the window...")
+	if err := queue.PutID("a", 3, -1); err != ErrDuplicate {
+		t.Fatalf("Expect %v, got %v\n", ErrDuplicate, err)
+	}
+	time.Sleep(60 * time.Millisecond)
+	if err := queue.PutID("a", 4, -1); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	if val, err := queue.GetNoWait(); err != nil || val.(int) != 4 {
+		t.Fatalf("Expect 4, got %v (%v)\n", val, err)
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestDedupID(t *testing.T) {
+	queue := NewDedup(1, 0)
+	queue.ID = func(val interface{}) string { return val.(string)[:1] }
+
+	fmt.Println("Test Put derives the ID...")
+	queue.PutNoWait("a1")
+	if err := queue.PutNoWait("a2"); err != ErrDuplicate {
+		t.Fatalf("Expect %v, got %v\n", ErrDuplicate, err)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test the ID is free again if Put fails...")
+	if err := queue.PutNoWait("b1"); err != ErrFullQueue {
+		t.Fatalf("Expect %v, got %v\n", ErrFullQueue, err)
+	}
+	queue.GetNoWait()
+	if err := queue.PutNoWait("b2"); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test a zero window only refuses queued IDs...")
+	queue.GetNoWait()
+	if err := queue.PutNoWait("b3"); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	fmt.Println("  ...PASSED")
+}
@@ -0,0 +1,115 @@
+package goqueue
+
+import (
+	"time"
+)
+
+// elastic tracks the traffic of the current window.
+type elastic struct {
+	floor    int
+	ceiling  int
+	interval time.Duration
+	start    time.Time
+	puts     int  // items put within the window
+	gets     int  // items got within the window
+	full     int  // Put operators which found the Queue full
+	peak     int  // max size within the window
+	pending  bool // a timer will adapt at the end of the window
+}
+
+// WithElastic makes maxSize adapt to the traffic between floor and ceiling.
+// Every interval seconds, maxSize is doubled if producers found the Queue
+// full and outpaced consumers, or halved if the Queue never got over a
+// quarter of maxSize while consumers kept up. The maxSize given to New is
+// the initial size. An elastic Queue is always bounded, a floor below 1 is
+// raised to 1.
+func WithElastic(floor, ceiling int, interval float64) Option {
+	if floor < 1 {
+		floor = 1
+	}
+	if ceiling < floor {
+		ceiling = floor
+	}
+	return func(q *Queue) {
+		q.elastic = &elastic{
+			floor:    floor,
+			ceiling:  ceiling,
+			interval: seconds(interval),
+			start:    time.Now(),
+		}
+		if q.maxSize < floor {
+			q.maxSize = floor
+		}
+		if q.maxSize > ceiling {
+			q.maxSize = ceiling
+		}
+	}
+}
+
+func (q *Queue) observeElastic(puts, gets int) {
+	e := q.elastic
+	if e == nil {
+		return
+	}
+	e.puts += puts
+	e.gets += gets
+	if n := q.size(); n > e.peak {
+		e.peak = n
+	}
+}
+
+func (q *Queue) observeFull() {
+	if q.elastic != nil {
+		q.elastic.full++
+	}
+}
+
+// adapt resizes the Queue once the window is over, blocked putters are
+// moved in by clearPending if it grows.
+func (q *Queue) adapt() {
+	e := q.elastic
+	if e == nil {
+		return
+	}
+	now := time.Now()
+	if now.Sub(e.start) < e.interval {
+		return
+	}
+
+	switch {
+	case e.full > 0 && e.puts+e.full > e.gets:
+		q.maxSize *= 2
+		if q.maxSize > e.ceiling {
+			q.maxSize = e.ceiling
+		}
+	case e.peak <= q.maxSize/4 && e.gets >= e.puts:
+		q.maxSize /= 2
+		if q.maxSize < e.floor {
+			q.maxSize = e.floor
+		}
+	}
+	q.publish()
+	e.start = now
+	e.puts, e.gets, e.full = 0, 0, 0
+	e.peak = q.size()
+}
+
+// scheduleAdapt makes sure adapt runs at the end of the window while Put
+// operators are blocked, even if nobody else touches the Queue.
+func (q *Queue) scheduleAdapt() {
+	e := q.elastic
+	if e == nil || e.pending {
+		return
+	}
+	e.pending = true
+	time.AfterFunc(e.start.Add(e.interval).Sub(time.Now()), func() {
+		q.mutex.Lock()
+		defer q.unlock()
+		e.pending = false
+		q.adapt()
+		q.clearPending()
+		if q.putters.Len() != 0 {
+			q.scheduleAdapt()
+		}
+	})
+}
@@ -0,0 +1,77 @@
+package goqueue
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestElastic(t *testing.T) {
+	queue := New(1, WithElastic(2, 8, 0.05))
+
+	fmt.Println("Test elastic Queue starts at floor...")
+	if queue.MaxSize() != 2 {
+		t.Fatalf("Expect max size %d, got %d\n", 2, queue.MaxSize())
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test elastic Queue grows under pressure up to ceiling...")
+	for _, expect := range []int{4, 8, 8} {
+		for queue.PutNoWait(0) == nil {
+		}
+		time.Sleep(60 * time.Millisecond)
+		queue.PutNoWait(0)
+		if queue.MaxSize() != expect {
+			t.Fatalf("Expect max size %d, got %d\n", expect, queue.MaxSize())
+		}
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test blocked putter is moved in when Queue grows...")
+	queue2 := New(1, WithElastic(1, 2, 0.05))
+	queue2.PutNoWait(0)
+	if err := queue2.Put(1, 0.2); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	if queue2.Size() != 2 {
+		t.Fatalf("Expect Queue size %d, got %d\n", 2, queue2.Size())
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test elastic Queue shrinks when idle down to floor...")
+	for !queue.IsEmpty() {
+		queue.GetNoWait()
+	}
+	// The window the Queue was drained in still has the peak of 8.
+	time.Sleep(60 * time.Millisecond)
+	queue.GetNoWait()
+	for _, expect := range []int{4, 2, 2} {
+		time.Sleep(60 * time.Millisecond)
+		queue.GetNoWait()
+		if queue.MaxSize() != expect {
+			t.Fatalf("Expect max size %d, got %d\n", expect, queue.MaxSize())
+		}
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestElasticFloor(t *testing.T) {
+	queue := New(0, WithElastic(0, 8, 0.01))
+
+	fmt.Println("Test elastic Queue never shrinks to infinite...")
+	if queue.MaxSize() != 1 {
+		t.Fatalf("Expect max size %d, got %d\n", 1, queue.MaxSize())
+	}
+	time.Sleep(20 * time.Millisecond)
+	queue.PutNoWait(0)
+	queue.GetNoWait()
+	time.Sleep(20 * time.Millisecond)
+	queue.PutNoWait(0)
+	if queue.MaxSize() != 1 {
+		t.Fatalf("Expect max size %d, got %d\n", 1, queue.MaxSize())
+	}
+	if err := queue.PutNoWait(1); err != ErrFullQueue {
+		t.Fatalf("Expect %v, got %v\n", ErrFullQueue, err)
+	}
+	fmt.Println("  ...PASSED")
+}
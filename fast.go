@@ -0,0 +1,191 @@
+package goqueue
+
+import (
+	"sync/atomic"
+)
+
+type fastCell struct {
+	seq uint64
+	val interface{}
+}
+
+// FastQueue is a bounded GoRoutine safe queue whose PutNoWait and GetNoWait
+// never take a lock (Dmitry Vyukov's bounded MPMC queue). Blocking Put and
+// Get only park when they have to, and are woken up by the opposite side.
+// The order is FIFO for values put by one goroutine, it is approximate
+// otherwise.
+type FastQueue struct {
+	cells    []fastCell
+	enqPos   uint64
+	deqPos   uint64
+	getters  int64         // Get operators about to wait
+	putters  int64         // Put operators about to wait
+	notFull  chan struct{} // wakes a blocked Put operator
+	notEmpty chan struct{} // wakes a blocked Get operator
+}
+
+// NewFast create a new FastQueue, maxSize must be greater than 0.
+func NewFast(maxSize int) *FastQueue {
+	if maxSize < 1 {
+		panic("goqueue: FastQueue needs a positive maxSize")
+	}
+	q := &FastQueue{
+		cells:    make([]fastCell, maxSize),
+		notFull:  make(chan struct{}, 1),
+		notEmpty: make(chan struct{}, 1),
+	}
+	for i := range q.cells {
+		q.cells[i].seq = uint64(i)
+	}
+	return q
+}
+
+func (q *FastQueue) tryPut(val interface{}) bool {
+	size := uint64(len(q.cells))
+	pos := atomic.LoadUint64(&q.enqPos)
+	for {
+		c := &q.cells[pos%size]
+		seq := atomic.LoadUint64(&c.seq)
+		switch {
+		case seq == pos:
+			if atomic.CompareAndSwapUint64(&q.enqPos, pos, pos+1) {
+				c.val = val
+				atomic.StoreUint64(&c.seq, pos+1)
+				return true
+			}
+			pos = atomic.LoadUint64(&q.enqPos)
+		case seq < pos:
+			return false
+		default:
+			pos = atomic.LoadUint64(&q.enqPos)
+		}
+	}
+}
+
+func (q *FastQueue) tryGet() (interface{}, bool) {
+	size := uint64(len(q.cells))
+	pos := atomic.LoadUint64(&q.deqPos)
+	for {
+		c := &q.cells[pos%size]
+		seq := atomic.LoadUint64(&c.seq)
+		switch {
+		case seq == pos+1:
+			if atomic.CompareAndSwapUint64(&q.deqPos, pos, pos+1) {
+				val := c.val
+				c.val = nil
+				atomic.StoreUint64(&c.seq, pos+size)
+				return val, true
+			}
+			pos = atomic.LoadUint64(&q.deqPos)
+		case seq < pos+1:
+			return nil, false
+		default:
+			pos = atomic.LoadUint64(&q.deqPos)
+		}
+	}
+}
+
+// wakeOne signals ch if somebody may be waiting on it.
+func wakeOne(waiting *int64, ch chan struct{}) {
+	if atomic.LoadInt64(waiting) > 0 {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Same as Get(-1).
+func (q *FastQueue) GetNoWait() (interface{}, error) {
+	return q.Get(-1)
+}
+
+// Get has the same timeout semantics as Queue.Get.
+func (q *FastQueue) Get(timeout float64) (interface{}, error) {
+	if v, ok := q.tryGet(); ok {
+		wakeOne(&q.putters, q.notFull)
+		return v, nil
+	}
+	if timeout < 0.0 {
+		return nil, ErrEmptyQueue
+	}
+
+	deadline, stop := deadlineOf(timeout)
+	defer stop()
+	atomic.AddInt64(&q.getters, 1)
+	defer atomic.AddInt64(&q.getters, -1)
+	for {
+		// Registered before trying again, so a Put either is seen here or
+		// sees the getter.
+		if v, ok := q.tryGet(); ok {
+			wakeOne(&q.putters, q.notFull)
+			if !q.IsEmpty() {
+				wakeOne(&q.getters, q.notEmpty)
+			}
+			return v, nil
+		}
+		select {
+		case <-q.notEmpty:
+		case <-deadline:
+			return nil, ErrEmptyQueue
+		}
+	}
+}
+
+// Same as Put(val, -1).
+func (q *FastQueue) PutNoWait(val interface{}) error {
+	return q.Put(val, -1)
+}
+
+// Put has the same timeout semantics as Queue.Put.
+func (q *FastQueue) Put(val interface{}, timeout float64) error {
+	if q.tryPut(val) {
+		wakeOne(&q.getters, q.notEmpty)
+		return nil
+	}
+	if timeout < 0.0 {
+		return ErrFullQueue
+	}
+
+	deadline, stop := deadlineOf(timeout)
+	defer stop()
+	atomic.AddInt64(&q.putters, 1)
+	defer atomic.AddInt64(&q.putters, -1)
+	for {
+		if q.tryPut(val) {
+			wakeOne(&q.getters, q.notEmpty)
+			if !q.IsFull() {
+				wakeOne(&q.putters, q.notFull)
+			}
+			return nil
+		}
+		select {
+		case <-q.notFull:
+		case <-deadline:
+			return ErrFullQueue
+		}
+	}
+}
+
+// Return size of FastQueue, it is a snapshot which may be stale already.
+func (q *FastQueue) Size() int {
+	deq := atomic.LoadUint64(&q.deqPos)
+	enq := atomic.LoadUint64(&q.enqPos)
+	if enq < deq {
+		return 0
+	}
+	if n := int(enq - deq); n < len(q.cells) {
+		return n
+	}
+	return len(q.cells)
+}
+
+// Return true if FastQueue is empty.
+func (q *FastQueue) IsEmpty() bool {
+	return q.Size() == 0
+}
+
+// Return true if FastQueue is full.
+func (q *FastQueue) IsFull() bool {
+	return q.Size() >= len(q.cells)
+}
@@ -0,0 +1,85 @@
+package goqueue
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+var _ Interface = (*FastQueue)(nil)
+
+func TestFastNoWait(t *testing.T) {
+	queue := NewFast(3)
+
+	fmt.Println("Test FastQueue is bounded and FIFO...")
+	for i := 0; i < 3; i++ {
+		if err := queue.PutNoWait(i); err != nil {
+			t.Fatalf("Unexpect error: %v\n", err)
+		}
+	}
+	if err := queue.PutNoWait(3); err != ErrFullQueue || !queue.IsFull() {
+		t.Fatalf("Expect %v, got %v\n", ErrFullQueue, err)
+	}
+	for i := 0; i < 3; i++ {
+		if val, err := queue.GetNoWait(); err != nil || val.(int) != i {
+			t.Fatalf("Expect %v, got %v (%v)\n", i, val, err)
+		}
+	}
+	if _, err := queue.Get(0.05); err != ErrEmptyQueue {
+		t.Fatalf("Expect %v, got %v\n", ErrEmptyQueue, err)
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestFastConcurrent(t *testing.T) {
+	queue := NewFast(4)
+	const producers, each = 4, 1000
+
+	fmt.Println("Test blocking producers and consumers lose nothing...")
+	wg := &sync.WaitGroup{}
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < each; i++ {
+				if err := queue.Put(i, 0); err != nil {
+					t.Errorf("Unexpect error: %v\n", err)
+				}
+			}
+		}()
+	}
+	sums := make(chan int, producers)
+	for c := 0; c < producers; c++ {
+		go func() {
+			sum := 0
+			for i := 0; i < each; i++ {
+				val, err := queue.Get(5)
+				if err != nil {
+					t.Errorf("Unexpect error: %v\n", err)
+					break
+				}
+				sum += val.(int)
+			}
+			sums <- sum
+		}()
+	}
+	wg.Wait()
+	total := 0
+	for c := 0; c < producers; c++ {
+		total += <-sums
+	}
+	if expect := producers * each * (each - 1) / 2; total != expect {
+		t.Fatalf("Expect sum %d, got %d\n", expect, total)
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func BenchmarkFastNoWait(b *testing.B) {
+	queue := NewFast(1024)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			queue.PutNoWait(1)
+			queue.GetNoWait()
+		}
+	})
+}
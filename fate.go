@@ -0,0 +1,72 @@
+package goqueue
+
+import (
+	"fmt"
+)
+
+// Fate is how a value leaves a Queue.
+type Fate int
+
+const (
+	// Delivered means the value was got.
+	Delivered Fate = iota
+	// Dropped means the value was given up, see Hooks.OnDrop.
+	Dropped
+	// Removed means the value was removed by RemoveFunc, Clear or Reset.
+	Removed
+	// Expired means the value was skipped or swept by a TTLQueue.
+	Expired
+	// DeadLettered means the value was put into Consumer.DeadLetter.
+	DeadLettered
+)
+
+func (f Fate) String() string {
+	switch f {
+	case Delivered:
+		return "delivered"
+	case Dropped:
+		return "dropped"
+	case Removed:
+		return "removed"
+	case Expired:
+		return "expired"
+	case DeadLettered:
+		return "dead lettered"
+	}
+	return fmt.Sprintf("Fate(%d)", int(f))
+}
+
+// Tracker is implemented by the values which want to know their Fate.
+// Settled is called once the value leaves a Queue, outside the lock like
+// the Hooks, so a value put back or moved to another Queue is settled again.
+type Tracker interface {
+	Settled(fate Fate)
+}
+
+// Tracked attaches a callback to a single Put, Get returns the *Tracked.
+type Tracked struct {
+	Value  interface{}
+	OnFate func(val interface{}, fate Fate)
+}
+
+// Settled calls OnFate with the Value, if OnFate is not nil.
+func (t *Tracked) Settled(fate Fate) {
+	if t.OnFate != nil {
+		t.OnFate(t.Value, fate)
+	}
+}
+
+// settle records the Fate of val, if it is a Tracker, to report once the
+// lock is released.
+func (q *Queue) settle(val interface{}, fate Fate) {
+	if t, ok := val.(Tracker); ok {
+		q.fire(func(interface{}) { t.Settled(fate) }, nil)
+	}
+}
+
+// settled reports the Fate of val right away, if it is a Tracker.
+func settled(val interface{}, fate Fate) {
+	if t, ok := val.(Tracker); ok {
+		t.Settled(fate)
+	}
+}
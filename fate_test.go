@@ -0,0 +1,53 @@
+package goqueue
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+var _ Tracker = (*Tracked)(nil)
+
+func TestTrackedFate(t *testing.T) {
+	fates := make(chan Fate, 4)
+	track := func(val interface{}) *Tracked {
+		return &Tracked{Value: val, OnFate: func(v interface{}, fate Fate) {
+			if v != val {
+				t.Errorf("Expect %v, got %v\n", val, v)
+			}
+			fates <- fate
+		}}
+	}
+	expect := func(fate Fate) {
+		select {
+		case got := <-fates:
+			if got != fate {
+				t.Fatalf("Expect %v, got %v\n", fate, got)
+			}
+		default:
+			t.Fatalf("Expect %v, got nothing\n", fate)
+		}
+	}
+
+	fmt.Println("Test a Tracked value is settled when it leaves Queue...")
+	queue := New(1, WithOverwrite())
+	queue.PutNoWait(track("a"))
+	if val, err := queue.GetNoWait(); err != nil || val.(*Tracked).Value != "a" {
+		t.Fatalf("Unexpect value %v and error %v\n", val, err)
+	}
+	expect(Delivered)
+	queue.PutNoWait(track("b"))
+	queue.PutNoWait("c")
+	expect(Dropped)
+	queue.Clear(nil)
+	queue.PutNoWait(track("d"))
+	queue.Clear(nil)
+	expect(Removed)
+
+	ttl := NewTTL(0)
+	ttl.PutWithTTL(track("e"), time.Millisecond, -1)
+	time.Sleep(5 * time.Millisecond)
+	ttl.Sweep()
+	expect(Expired)
+	fmt.Println("  ...PASSED")
+}
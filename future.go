@@ -0,0 +1,86 @@
+package goqueue
+
+import (
+	"errors"
+	"sync"
+)
+
+// Future is not completed yet.
+var ErrPending = errors.New("future is pending")
+
+// Future is the outcome of a Task, it is completed by the consumer.
+type Future struct {
+	once  sync.Once
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+func newFuture() *Future {
+	return &Future{done: make(chan struct{})}
+}
+
+// Done returns a channel which is closed when the Future is completed.
+func (f *Future) Done() <-chan struct{} {
+	return f.done
+}
+
+// * If timeout less than 0, If Future is pending, return (nil, ErrPending).
+//
+// * If timeout equals to 0, block until Future is completed.
+//
+// * If timeout greater than 0, wait timeout seconds until Future is completed,
+// if timeout passed, return (nil, ErrPending).
+//
+// Otherwise return the value and error given to Task.Complete.
+func (f *Future) Wait(timeout float64) (interface{}, error) {
+	if timeout < 0.0 {
+		select {
+		case <-f.done:
+		default:
+			return nil, ErrPending
+		}
+	} else if timeout == 0.0 {
+		<-f.done
+	} else {
+		t := acquireTimer(seconds(timeout))
+		defer releaseTimer(t)
+		select {
+		case <-f.done:
+		case <-t.C:
+			return nil, ErrPending
+		}
+	}
+	return f.value, f.err
+}
+
+// Task is the envelope put into the Queue by Submit.
+type Task struct {
+	Value  interface{}
+	future *Future
+}
+
+// complete sets the outcome, only the first call counts.
+func (f *Future) complete(val interface{}, err error) {
+	f.once.Do(func() {
+		f.value = val
+		f.err = err
+		close(f.done)
+	})
+}
+
+// Complete completes the Future of the Task, only the first call counts.
+func (t *Task) Complete(val interface{}, err error) {
+	t.future.complete(val, err)
+}
+
+// Submit puts a *Task wrapping val into the Queue and returns its Future,
+// the consumer must call Task.Complete when it finishes processing. The
+// timeout semantics are the same as Put.
+func (q *Queue) Submit(val interface{}, timeout float64) (*Future, error) {
+	task := &Task{Value: val, future: newFuture()}
+	if err := q.Put(task, timeout); err != nil {
+		return nil, err
+	}
+	return task.future, nil
+}
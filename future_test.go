@@ -0,0 +1,59 @@
+package goqueue
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestSubmit(t *testing.T) {
+	queue := New(1)
+
+	fmt.Println("Test Future is completed by the consumer...")
+	future, err := queue.Submit(21, -1)
+	if err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	if _, err := future.Wait(-1); err != ErrPending {
+		t.Fatalf("Expect %v, got %v\n", ErrPending, err)
+	}
+	go func() {
+		val, _ := queue.Get(0)
+		task := val.(*Task)
+		time.Sleep(50 * time.Millisecond)
+		task.Complete(task.Value.(int)*2, nil)
+		task.Complete(0, errors.New("ignored"))
+	}()
+	val, err := future.Wait(2)
+	if err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	} else if val.(int) != 42 {
+		t.Fatalf("Expect %v, got %v\n", 42, val)
+	}
+	select {
+	case <-future.Done():
+	default:
+		t.Fatalf("Expect Done to be closed\n")
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test Future reports the consumer error...")
+	future, _ = queue.Submit("x", -1)
+	val, _ = queue.GetNoWait()
+	val.(*Task).Complete(nil, errors.New("failed"))
+	if _, err := future.Wait(0); err == nil || err.Error() != "failed" {
+		t.Fatalf("Expect error %q, got %v\n", "failed", err)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test Future waits with a deadline...")
+	future, _ = queue.Submit("y", -1)
+	if _, err := future.Wait(0.05); err != ErrPending {
+		t.Fatalf("Expect %v, got %v\n", ErrPending, err)
+	}
+	if _, err := queue.Submit("z", -1); err != ErrFullQueue {
+		t.Fatalf("Expect %v, got %v\n", ErrFullQueue, err)
+	}
+	fmt.Println("  ...PASSED")
+}
@@ -0,0 +1,168 @@
+package goqueue
+
+import (
+	"container/heap"
+	"container/list"
+	"sync"
+)
+
+type groupItem struct {
+	group string
+	value interface{}
+	seq   uint64
+}
+
+// groupBefore orders deliverable items by insertion order.
+func groupBefore(a, b interface{}) bool {
+	return a.(*groupItem).seq < b.(*groupItem).seq
+}
+
+// GroupQueue is a GoRoutine safe queue of message groups: items with the
+// same group ID are delivered strictly in FIFO order, and the next one is
+// held back until Done is called for the one in flight. Items of different
+// groups, and items without a group (the empty ID), interleave freely in
+// FIFO order.
+type GroupQueue struct {
+	maxSize int
+	mutex   sync.Mutex
+	seq     uint64
+	size    int
+	ready   itemHeap              // deliverable items
+	pending map[string]*list.List // items queued behind the head of a group
+	busy    map[string]bool       // groups with an item ready or in flight
+	putters *list.List            // store blocked Put operators
+	getters *list.List            // store blocked Get operators
+}
+
+// NewGrouped create a new GroupQueue, the maxSize variable sets the max
+// number of items held, items in flight excluded. If maxSize is zero,
+// GroupQueue will be infinite size, and Put always no wait.
+func NewGrouped(maxSize int) *GroupQueue {
+	q := new(GroupQueue)
+	q.maxSize = maxSize
+	q.ready.less = groupBefore
+	q.pending = make(map[string]*list.List)
+	q.busy = make(map[string]bool)
+	q.putters = list.New()
+	q.getters = list.New()
+	return q
+}
+
+func (q *GroupQueue) isfull() bool {
+	return (q.maxSize > 0 && q.maxSize <= q.size)
+}
+
+// Same as Get(-1).
+func (q *GroupQueue) GetNoWait() (string, interface{}, error) {
+	return q.Get(-1)
+}
+
+// Get returns the oldest deliverable item and its group, the timeout
+// semantics are the same as Queue.Get. The group stays in flight until
+// Done is called for it.
+func (q *GroupQueue) Get(timeout float64) (string, interface{}, error) {
+	deadline, stop := deadlineOf(timeout)
+	defer stop()
+
+	q.mutex.Lock()
+	for {
+		if q.ready.Len() > 0 {
+			item := heap.Pop(&q.ready).(*groupItem)
+			q.size--
+			wake(q.getters, q.ready.Len())
+			wake(q.putters, 1)
+			q.mutex.Unlock()
+			return item.group, item.value, nil
+		}
+		if !await(&q.mutex, q.getters, timeout, deadline) {
+			q.mutex.Unlock()
+			return "", nil, ErrEmptyQueue
+		}
+	}
+}
+
+// Done releases the item in flight of group, the next item of the group, if
+// any, becomes deliverable. It does nothing for the empty group or a group
+// with nothing in flight.
+func (q *GroupQueue) Done(group string) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if group == "" || !q.busy[group] {
+		return
+	}
+	l := q.pending[group]
+	if l == nil {
+		delete(q.busy, group)
+		return
+	}
+	heap.Push(&q.ready, l.Remove(l.Front()).(*groupItem))
+	if l.Len() == 0 {
+		delete(q.pending, group)
+	}
+	wake(q.getters, 1)
+}
+
+// Same as Put(val, -1).
+func (q *GroupQueue) PutNoWait(val interface{}) error {
+	return q.Put(val, -1)
+}
+
+// Put an item without a group.
+func (q *GroupQueue) Put(val interface{}, timeout float64) error {
+	return q.PutGroup("", val, timeout)
+}
+
+// PutGroup puts an item into group, the timeout semantics are the same as
+// Queue.Put.
+func (q *GroupQueue) PutGroup(group string, val interface{}, timeout float64) error {
+	deadline, stop := deadlineOf(timeout)
+	defer stop()
+
+	q.mutex.Lock()
+	for {
+		if !q.isfull() {
+			q.seq++
+			q.size++
+			item := &groupItem{group: group, value: val, seq: q.seq}
+			if group != "" && q.busy[group] {
+				l := q.pending[group]
+				if l == nil {
+					l = list.New()
+					q.pending[group] = l
+				}
+				l.PushBack(item)
+			} else {
+				if group != "" {
+					q.busy[group] = true
+				}
+				heap.Push(&q.ready, item)
+				wake(q.getters, 1)
+			}
+			q.mutex.Unlock()
+			return nil
+		}
+		if !await(&q.mutex, q.putters, timeout, deadline) {
+			q.mutex.Unlock()
+			return ErrFullQueue
+		}
+	}
+}
+
+// Return the number of items held, items in flight excluded.
+func (q *GroupQueue) Size() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.size
+}
+
+// Return true if GroupQueue is empty.
+func (q *GroupQueue) IsEmpty() bool {
+	return q.Size() == 0
+}
+
+// Return true if GroupQueue is full.
+func (q *GroupQueue) IsFull() bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.isfull()
+}
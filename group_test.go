@@ -0,0 +1,81 @@
+package goqueue
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestGroupOrder(t *testing.T) {
+	queue := NewGrouped(0)
+
+	fmt.Println("Test one item in flight per group, groups interleave...")
+	queue.PutGroup("a", "a1", -1)
+	queue.PutGroup("a", "a2", -1)
+	queue.PutGroup("b", "b1", -1)
+	queue.PutNoWait("x")
+	queue.PutGroup("b", "b2", -1)
+	for _, expect := range []string{"a1", "b1", "x"} {
+		_, val, err := queue.GetNoWait()
+		if err != nil {
+			t.Fatalf("Unexpect error: %v\n", err)
+		} else if val.(string) != expect {
+			t.Fatalf("Expect %v, got %v\n", expect, val)
+		}
+	}
+	if _, _, err := queue.GetNoWait(); err != ErrEmptyQueue {
+		t.Fatalf("Expect %v, got %v\n", ErrEmptyQueue, err)
+	}
+	if queue.Size() != 2 {
+		t.Fatalf("Expect 2 items held, got %d\n", queue.Size())
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test Done releases the next item of the group...")
+	queue.Done("b")
+	queue.Done("b")
+	group, val, err := queue.GetNoWait()
+	if err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	} else if group != "b" || val.(string) != "b2" {
+		t.Fatalf("Expect b/b2, got %v/%v\n", group, val)
+	}
+	queue.Done("a")
+	if _, val, _ := queue.GetNoWait(); val.(string) != "a2" {
+		t.Fatalf("Expect %v, got %v\n", "a2", val)
+	}
+	queue.Done("a")
+	queue.PutGroup("a", "a3", -1)
+	if _, val, _ := queue.GetNoWait(); val.(string) != "a3" {
+		t.Fatalf("Expect %v, got %v\n", "a3", val)
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestGroupBlocking(t *testing.T) {
+	queue := NewGrouped(1)
+
+	fmt.Println("Test blocking Get waits for Done...")
+	queue.PutGroup("a", 1, -1)
+	queue.GetNoWait()
+	queue.PutGroup("a", 2, -1)
+	if err := queue.PutGroup("b", 3, -1); err != ErrFullQueue {
+		t.Fatalf("Expect %v, got %v\n", ErrFullQueue, err)
+	}
+	if _, _, err := queue.Get(0.05); err != ErrEmptyQueue {
+		t.Fatalf("Expect %v, got %v\n", ErrEmptyQueue, err)
+	}
+	done := make(chan interface{}, 1)
+	go func() {
+		_, val, _ := queue.Get(2)
+		done <- val
+	}()
+	queue.Done("a")
+	if val := <-done; val != 2 {
+		t.Fatalf("Expect %v, got %v\n", 2, val)
+	}
+	if queue.putters.Len() != 0 || queue.getters.Len() != 0 {
+		t.Fatalf("Expect no pending operators, got %d putters %d getters\n",
+			queue.putters.Len(), queue.getters.Len())
+	}
+	fmt.Println("  ...PASSED")
+}
@@ -0,0 +1,20 @@
+package goqueue
+
+// itemHeap is a heap.Interface of items ordered by less, for the queues
+// which use container/heap.
+type itemHeap struct {
+	items []interface{}
+	less  func(a, b interface{}) bool
+}
+
+func (h *itemHeap) Len() int           { return len(h.items) }
+func (h *itemHeap) Less(i, j int) bool { return h.less(h.items[i], h.items[j]) }
+func (h *itemHeap) Swap(i, j int)      { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *itemHeap) Push(x interface{}) { h.items = append(h.items, x) }
+func (h *itemHeap) Pop() interface{} {
+	n := len(h.items)
+	item := h.items[n-1]
+	h.items[n-1] = nil
+	h.items = h.items[:n-1]
+	return item
+}
@@ -0,0 +1,90 @@
+package goqueue
+
+// Hooks are callbacks on the lifecycle of values, nil ones are skipped.
+// They are called outside the lock, once the operation which fired them
+// has released it, so they may use the Queue; hooks fired by different
+// goroutines may run concurrently.
+type Hooks struct {
+	// OnPut is called for every value put into Queue, and OnGet for every
+	// value got from it.
+	OnPut func(val interface{})
+	OnGet func(val interface{})
+	// OnFull is called when a Put makes Queue full, and OnEmpty when a Get
+	// makes it empty.
+	OnFull  func()
+	OnEmpty func()
+	// OnDrop is called for a value which is given up, e.g. by InChan.
+	OnDrop func(val interface{})
+}
+
+type hookEvent struct {
+	fn  func(val interface{})
+	val interface{}
+}
+
+// WithHooks sets the Hooks of Queue.
+func WithHooks(h Hooks) Option {
+	return func(q *Queue) {
+		q.hooks = &h
+	}
+}
+
+// fire records a hook to call once the lock is released.
+func (q *Queue) fire(fn func(val interface{}), val interface{}) {
+	if fn != nil {
+		q.events = append(q.events, hookEvent{fn: fn, val: val})
+	}
+}
+
+func (q *Queue) firePut(val interface{}) {
+	if h := q.hooks; h != nil {
+		q.fire(h.OnPut, val)
+		if h.OnFull != nil && q.isfull() {
+			q.fire(func(interface{}) { h.OnFull() }, nil)
+		}
+	}
+}
+
+func (q *Queue) fireGet(val interface{}) {
+	q.settle(val, Delivered)
+	if h := q.hooks; h != nil {
+		q.fire(h.OnGet, val)
+		if h.OnEmpty != nil && q.isempty() {
+			q.fire(func(interface{}) { h.OnEmpty() }, nil)
+		}
+	}
+}
+
+func (q *Queue) fireDrop(val interface{}) {
+	q.mutex.Lock()
+	q.drop(val)
+	q.unlock()
+}
+
+// drop records the OnDrop hook and the Fate of a value given up.
+func (q *Queue) drop(val interface{}) {
+	q.fire(q.dropHook(), val)
+	q.settle(val, Dropped)
+}
+
+func (q *Queue) dropHook() func(val interface{}) {
+	if h := q.hooks; h != nil {
+		return h.OnDrop
+	}
+	return nil
+}
+
+// unlock releases the lock, then calls the hooks recorded meanwhile.
+func (q *Queue) unlock() {
+	events := q.events
+	q.events = nil
+	q.stats.block(q.putters.Len(), q.getters.Len())
+	q.mutex.Unlock()
+	runHooks(events)
+}
+
+func runHooks(events []hookEvent) {
+	for _, e := range events {
+		e.fn(e.val)
+	}
+}
@@ -0,0 +1,42 @@
+package goqueue
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestHooks(t *testing.T) {
+	mutex := sync.Mutex{}
+	var events []string
+	record := func(format string, args ...interface{}) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		events = append(events, fmt.Sprintf(format, args...))
+	}
+	var queue *Queue
+	queue = New(2, WithHooks(Hooks{
+		OnPut: func(val interface{}) {
+			// Hooks are called outside the lock.
+			record("put %v size %d", val, queue.Size())
+			queue.Peek()
+		},
+		OnGet:   func(val interface{}) { record("get %v", val) },
+		OnFull:  func() { record("full") },
+		OnEmpty: func() { record("empty") },
+	}))
+
+	fmt.Println("Test hooks follow the lifecycle of values...")
+	queue.PutNoWait(1)
+	queue.PutNoWait(2)
+	queue.PutNoWait(3)
+	queue.GetNoWait()
+	queue.GetNoWait()
+	expect := []string{"put 1 size 1", "put 2 size 2", "full", "get 1", "get 2", "empty"}
+	mutex.Lock()
+	defer mutex.Unlock()
+	if fmt.Sprint(events) != fmt.Sprint(expect) {
+		t.Fatalf("Expect %v, got %v\n", expect, events)
+	}
+	fmt.Println("  ...PASSED")
+}
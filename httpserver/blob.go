@@ -0,0 +1,128 @@
+package httpserver
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/damnever/goqueue"
+)
+
+// chunkSize is the buffer payloads are copied with.
+const chunkSize = 64 << 10
+
+// Blob is the value a POST to /queues/{name}/blob puts, its payload is kept
+// in a file of Server.BlobDir, the file is deleted once the Blob is got from
+// /queues/{name}/blob. A plain Get returns it as its JSON.
+type Blob struct {
+	Name string `json:"name"` // file name in Server.BlobDir
+	Size int64  `json:"size"`
+}
+
+// putBlob streams the body into a new file, and puts its Blob.
+func (s *Server) putBlob(w http.ResponseWriter, r *http.Request, q *goqueue.Queue) {
+	if s.BlobDir == "" {
+		http.Error(w, "no blob directory", http.StatusNotImplemented)
+		return
+	}
+	ctx, cancel, wait, err := s.context(r)
+	if err != nil {
+		http.Error(w, "invalid timeout", http.StatusBadRequest)
+		return
+	}
+	defer cancel()
+	b, err := s.writeBlob(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if wait {
+		err = q.PutContext(ctx, b)
+	} else {
+		err = q.PutNoWait(b)
+	}
+	if err != nil {
+		os.Remove(filepath.Join(s.BlobDir, b.Name))
+	}
+	switch err {
+	case nil:
+		w.WriteHeader(http.StatusNoContent)
+	case goqueue.ErrRateLimited:
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+	default:
+		http.Error(w, goqueue.ErrFullQueue.Error(), http.StatusServiceUnavailable)
+	}
+}
+
+func (s *Server) writeBlob(body io.Reader) (*Blob, error) {
+	if err := os.MkdirAll(s.BlobDir, 0755); err != nil {
+		return nil, err
+	}
+	name := make([]byte, 16)
+	if _, err := rand.Read(name); err != nil {
+		return nil, err
+	}
+	b := &Blob{Name: hex.EncodeToString(name)}
+	path := filepath.Join(s.BlobDir, b.Name)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return nil, err
+	}
+	b.Size, err = io.CopyBuffer(f, body, make([]byte, chunkSize))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+	return b, nil
+}
+
+// getBlob gets a Blob and streams its payload as the body. A value which is
+// not a Blob, or a payload which can't be sent, is put back at the front.
+func (s *Server) getBlob(w http.ResponseWriter, r *http.Request, q *goqueue.Queue) {
+	ctx, cancel, wait, err := s.context(r)
+	if err != nil {
+		http.Error(w, "invalid timeout", http.StatusBadRequest)
+		return
+	}
+	defer cancel()
+	var val interface{}
+	if wait {
+		val, err = q.GetContext(ctx)
+	} else {
+		val, err = q.GetNoWait()
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	b, ok := val.(*Blob)
+	if !ok || s.BlobDir == "" {
+		q.PutFront(val, -1)
+		http.Error(w, "not a blob", http.StatusConflict)
+		return
+	}
+	path := filepath.Join(s.BlobDir, b.Name)
+	f, err := os.Open(path)
+	if err != nil {
+		q.PutFront(val, -1)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Length", strconv.FormatInt(b.Size, 10))
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.CopyBuffer(w, f, make([]byte, chunkSize)); err != nil {
+		// The client went away, the next one gets the payload again.
+		q.PutFront(val, -1)
+		return
+	}
+	os.Remove(path)
+}
@@ -0,0 +1,76 @@
+package httpserver
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/damnever/goqueue"
+)
+
+func TestBlob(t *testing.T) {
+	dir, err := ioutil.TempDir("", "httpserver")
+	if err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	defer os.RemoveAll(dir)
+	s := New()
+	s.MaxTimeout = 0.05
+	s.BlobDir = dir
+	q := goqueue.New(1)
+	s.Add("blobs", q)
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+	c := NewClient(ts.URL, "blobs")
+	payload := bytes.Repeat([]byte("0123456789"), 200<<10)
+
+	fmt.Println("Test PutReader and GetReader stream the payload...")
+	if err := c.PutReader(bytes.NewReader(payload), -1); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	if err := c.PutReader(bytes.NewReader(payload), -1); err != goqueue.ErrFullQueue {
+		t.Fatalf("Expect %v, got %v\n", goqueue.ErrFullQueue, err)
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 1 {
+		t.Fatalf("Expect 1 payload file, got %d\n", len(files))
+	}
+	if val, _ := q.Peek(); val.(*Blob).Size != int64(len(payload)) {
+		t.Fatalf("Expect a Blob of %d bytes, got %v\n", len(payload), val)
+	}
+	r, err := c.GetReader(-1)
+	if err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	data, err := ioutil.ReadAll(r)
+	r.Close()
+	if err != nil || !bytes.Equal(data, payload) {
+		t.Fatalf("Expect the payload back, got %d bytes (%v)\n", len(data), err)
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 0 {
+		t.Fatalf("Expect the payload file deleted, got %d\n", len(files))
+	}
+	if _, err := c.GetReader(0.1); err != goqueue.ErrEmptyQueue {
+		t.Fatalf("Expect %v, got %v\n", goqueue.ErrEmptyQueue, err)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test GetReader leaves a value which is not a Blob...")
+	q.PutNoWait("a")
+	if _, err := c.GetReader(-1); err == nil {
+		t.Fatalf("Expect an error\n")
+	}
+	if val, err := c.GetNoWait(); err != nil || val != "a" {
+		t.Fatalf("Expect a, got %v (%v)\n", val, err)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test the blob endpoints need a BlobDir...")
+	s.BlobDir = ""
+	if err := c.PutReader(bytes.NewReader(payload), -1); err == nil {
+		t.Fatalf("Expect an error\n")
+	}
+	fmt.Println("  ...PASSED")
+}
@@ -0,0 +1,263 @@
+package httpserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/damnever/goqueue"
+)
+
+// Client is a goqueue.Interface for a queue served by Server.
+type Client struct {
+	url string
+
+	// HTTP is the client sending the requests, http.DefaultClient if nil.
+	HTTP *http.Client
+}
+
+// NewClient create a Client of the queue name served at baseURL, such as
+// "http://localhost:8080".
+func NewClient(baseURL, name string) *Client {
+	return &Client{url: strings.TrimRight(baseURL, "/") + "/queues/" + url.PathEscape(name)}
+}
+
+func (c *Client) client() *http.Client {
+	if c.HTTP != nil {
+		return c.HTTP
+	}
+	return http.DefaultClient
+}
+
+func (c *Client) do(method, path string, body []byte) (*http.Response, error) {
+	if body == nil {
+		return c.send(method, path, "", nil)
+	}
+	return c.send(method, path, "application/json", bytes.NewReader(body))
+}
+
+func (c *Client) send(method, path, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(method, c.url+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set(VersionHeader, strconv.Itoa(Version))
+	resp, err := c.client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if v, err := versionOf(resp.Header); err != nil || v > Version {
+		resp.Body.Close()
+		return nil, fmt.Errorf("httpserver: unsupported protocol version %q", resp.Header.Get(VersionHeader))
+	}
+	return resp, nil
+}
+
+func withTimeout(timeout float64) string {
+	return "?timeout=" + strconv.FormatFloat(timeout, 'f', -1, 64)
+}
+
+// statusError turns an unexpected response into an error.
+func statusError(resp *http.Response) error {
+	msg, _ := ioutil.ReadAll(resp.Body)
+	return fmt.Errorf("httpserver: %s: %s", resp.Status, bytes.TrimSpace(msg))
+}
+
+// poll calls once with the seconds left of timeout until it returns done,
+// the server caps the wait of each request by its MaxTimeout.
+func poll(timeout float64, once func(timeout float64) (done bool, err error)) error {
+	if timeout < 0.0 {
+		_, err := once(-1)
+		return err
+	}
+	var deadline time.Time
+	if timeout > 0.0 {
+		deadline = time.Now().Add(time.Duration(timeout * float64(time.Second)))
+	}
+	for {
+		left := 0.0
+		if !deadline.IsZero() {
+			if left = time.Until(deadline).Seconds(); left <= 0.0 {
+				left = -1
+			}
+		}
+		done, err := once(left)
+		if done || left < 0.0 {
+			return err
+		}
+	}
+}
+
+// Same as Get(-1).
+func (c *Client) GetNoWait() (interface{}, error) {
+	return c.Get(-1)
+}
+
+// Get a value from the remote queue, the timeout semantics are the same as
+// Queue.Get. Values are decoded from JSON.
+func (c *Client) Get(timeout float64) (interface{}, error) {
+	var val interface{}
+	err := poll(timeout, func(timeout float64) (bool, error) {
+		resp, err := c.do(http.MethodGet, withTimeout(timeout), nil)
+		if err != nil {
+			return true, err
+		}
+		defer resp.Body.Close()
+		switch resp.StatusCode {
+		case http.StatusOK:
+			return true, json.NewDecoder(resp.Body).Decode(&val)
+		case http.StatusNoContent:
+			return false, goqueue.ErrEmptyQueue
+		default:
+			return true, statusError(resp)
+		}
+	})
+	return val, err
+}
+
+// Return the value at the head of the remote queue without removing it.
+func (c *Client) Peek() (interface{}, error) {
+	resp, err := c.do(http.MethodGet, "/peek", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		var val interface{}
+		err = json.NewDecoder(resp.Body).Decode(&val)
+		return val, err
+	case http.StatusNoContent:
+		return nil, goqueue.ErrEmptyQueue
+	default:
+		return nil, statusError(resp)
+	}
+}
+
+// Same as Put(val, -1).
+func (c *Client) PutNoWait(val interface{}) error {
+	return c.Put(val, -1)
+}
+
+// Put a value into the remote queue, the timeout semantics are the same as
+// Queue.Put. The value is encoded as JSON.
+func (c *Client) Put(val interface{}, timeout float64) error {
+	body, err := json.Marshal(val)
+	if err != nil {
+		return err
+	}
+	return poll(timeout, func(timeout float64) (bool, error) {
+		resp, err := c.do(http.MethodPost, withTimeout(timeout), body)
+		if err != nil {
+			return true, err
+		}
+		defer resp.Body.Close()
+		switch resp.StatusCode {
+		case http.StatusNoContent:
+			return true, nil
+		case http.StatusServiceUnavailable:
+			return false, goqueue.ErrFullQueue
+		case http.StatusTooManyRequests:
+			return false, goqueue.ErrRateLimited
+		default:
+			return true, statusError(resp)
+		}
+	})
+}
+
+// PutReader puts the payload read from r as a Blob, it is streamed by
+// chunks and never held in memory. The timeout semantics are the same as
+// Queue.Put, but it is capped by the MaxTimeout of the server, as r can't
+// be sent twice.
+func (c *Client) PutReader(r io.Reader, timeout float64) error {
+	resp, err := c.send(http.MethodPost, "/blob"+withTimeout(timeout), "application/octet-stream", r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusNoContent:
+		return nil
+	case http.StatusServiceUnavailable:
+		return goqueue.ErrFullQueue
+	case http.StatusTooManyRequests:
+		return goqueue.ErrRateLimited
+	default:
+		return statusError(resp)
+	}
+}
+
+// GetReader gets a Blob from the remote queue and returns its payload,
+// which is read by chunks from the response and must be closed. The timeout
+// semantics are the same as Queue.Get.
+func (c *Client) GetReader(timeout float64) (io.ReadCloser, error) {
+	var body io.ReadCloser
+	err := poll(timeout, func(timeout float64) (bool, error) {
+		resp, err := c.do(http.MethodGet, "/blob"+withTimeout(timeout), nil)
+		if err != nil {
+			return true, err
+		}
+		switch resp.StatusCode {
+		case http.StatusOK:
+			body = resp.Body
+			return true, nil
+		case http.StatusNoContent:
+			resp.Body.Close()
+			return false, goqueue.ErrEmptyQueue
+		default:
+			defer resp.Body.Close()
+			return true, statusError(resp)
+		}
+	})
+	return body, err
+}
+
+// Info returns the size and the max size of the remote queue.
+func (c *Client) Info() (QueueInfo, error) {
+	var info QueueInfo
+	resp, err := c.do(http.MethodGet, "/info", nil)
+	if err != nil {
+		return info, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return info, statusError(resp)
+	}
+	err = json.NewDecoder(resp.Body).Decode(&info)
+	return info, err
+}
+
+// Return size of the remote queue, zero if it can't be reached, use Info
+// to tell the error.
+func (c *Client) Size() int {
+	info, _ := c.Info()
+	return info.Size
+}
+
+// Return true if the remote queue is empty.
+func (c *Client) IsEmpty() bool {
+	return c.Size() == 0
+}
+
+// Return true if the remote queue is full.
+func (c *Client) IsFull() bool {
+	info, err := c.Info()
+	return err == nil && info.MaxSize > 0 && info.MaxSize <= info.Size
+}
+
+// Close closes the idle connections of the HTTP client, the remote queue is
+// left as is.
+func (c *Client) Close() error {
+	c.client().CloseIdleConnections()
+	return nil
+}
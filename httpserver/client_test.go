@@ -0,0 +1,75 @@
+package httpserver
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/damnever/goqueue"
+)
+
+var (
+	_ goqueue.Interface = (*Client)(nil)
+	_ goqueue.Closer    = (*Client)(nil)
+)
+
+func TestClient(t *testing.T) {
+	s := New()
+	s.MaxTimeout = 0.05
+	q := goqueue.New(2)
+	s.Add("jobs", q)
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+	c := NewClient(ts.URL, "jobs")
+
+	fmt.Println("Test Put and Get through the server...")
+	if err := c.PutNoWait("a"); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	if err := c.Put(1, 1); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	if !c.IsFull() || c.Size() != 2 || c.IsEmpty() {
+		t.Fatalf("Expect full with 2 values, got %d\n", c.Size())
+	}
+	if err := c.PutNoWait("b"); err != goqueue.ErrFullQueue {
+		t.Fatalf("Expect ErrFullQueue, got %v\n", err)
+	}
+	if val, err := c.Peek(); err != nil || val != "a" {
+		t.Fatalf("Expect a, got %v (%v)\n", val, err)
+	}
+	if val, err := c.GetNoWait(); err != nil || val != "a" {
+		t.Fatalf("Expect a, got %v (%v)\n", val, err)
+	}
+	if val, err := c.Get(1); err != nil || val != 1.0 {
+		t.Fatalf("Expect 1, got %v (%v)\n", val, err)
+	}
+	if _, err := c.GetNoWait(); err != goqueue.ErrEmptyQueue {
+		t.Fatalf("Expect ErrEmptyQueue, got %v\n", err)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test waiting longer than MaxTimeout of the server...")
+	go func() {
+		time.Sleep(150 * time.Millisecond)
+		q.PutNoWait("late")
+	}()
+	if val, err := c.Get(0); err != nil || val != "late" {
+		t.Fatalf("Expect late, got %v (%v)\n", val, err)
+	}
+	start := time.Now()
+	if _, err := c.Get(0.12); err != goqueue.ErrEmptyQueue {
+		t.Fatalf("Expect ErrEmptyQueue, got %v\n", err)
+	}
+	if d := time.Since(start); d < 100*time.Millisecond || d > time.Second {
+		t.Fatalf("Expect to wait about 0.12s, waited %v\n", d)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test an unknown queue...")
+	if err := NewClient(ts.URL, "missing").PutNoWait(1); err == nil || err == goqueue.ErrFullQueue {
+		t.Fatalf("Expect an error of status, got %v\n", err)
+	}
+	fmt.Println("  ...PASSED")
+}
@@ -0,0 +1,270 @@
+/*
+Package httpserver serves named goqueue queues over HTTP with JSON bodies,
+for scripts and clients in other languages:
+
+	POST /queues/{name}?timeout=1    put the JSON body
+	GET  /queues/{name}?timeout=30   get a value, long polling
+	GET  /queues/{name}/peek         look at the head without removing it
+	GET  /queues/{name}/stats        goqueue.Stats of the queue
+	GET  /queues/{name}/info         QueueInfo of the queue
+	POST /queues/{name}/blob         put the body as a Blob
+	GET  /queues/{name}/blob         get a Blob, its payload is the body
+	GET  /queues                     QueueInfo of every queue
+
+The timeout is in seconds with the meaning of goqueue, but waiting is
+capped by Server.MaxTimeout, and stops when the client goes away; a
+missing timeout doesn't wait. Get and peek answer 204 No Content when
+there is no value, a Put which fails gets 503 Service Unavailable, or 429
+Too Many Requests over the rate of goqueue.WithPutRate. JSON numbers come
+out of Get as float64 for the Go consumers of the queue.
+
+The blob endpoints stream large payloads by chunks into files of
+Server.BlobDir instead of memory, the queue only holds a *Blob naming the
+file. A Get from /blob of a value which is not a Blob puts it back and
+answers 409 Conflict.
+
+Requests and responses carry the protocol Version in the Goqueue-Version
+header, a request without it is of version 1. A request of a newer version
+than the Server gets 400 Bad Request, so old servers don't misread new
+clients.
+
+Client satisfies goqueue.Interface on top of these endpoints, so code can
+switch between a local and a remote queue by its constructor.
+*/
+
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/damnever/goqueue"
+)
+
+// DefaultMaxTimeout is the default of Server.MaxTimeout.
+const DefaultMaxTimeout = 30.0
+
+const (
+	// Version is the protocol version of the Server and the Client.
+	Version = 1
+	// VersionHeader carries the protocol version of a request or response.
+	VersionHeader = "Goqueue-Version"
+)
+
+// versionOf returns the protocol version of h, 1 if it has none.
+func versionOf(h http.Header) (int, error) {
+	v := h.Get(VersionHeader)
+	if v == "" {
+		return 1, nil
+	}
+	return strconv.Atoi(v)
+}
+
+// Server is an http.Handler serving the queues added to it.
+type Server struct {
+	mutex  sync.RWMutex
+	queues map[string]*goqueue.Queue
+
+	// MaxTimeout caps the seconds a request waits, a timeout of zero
+	// waits that long.
+	MaxTimeout float64
+	// BlobDir is the directory of the Blob payloads, the blob endpoints
+	// answer 501 Not Implemented without it.
+	BlobDir string
+}
+
+// New create a Server without queues.
+func New() *Server {
+	return &Server{
+		queues:     make(map[string]*goqueue.Queue),
+		MaxTimeout: DefaultMaxTimeout,
+	}
+}
+
+// Add serves q as name, it replaces the queue previously added as name.
+func (s *Server) Add(name string, q *goqueue.Queue) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.queues[name] = q
+}
+
+// Remove stops serving name.
+func (s *Server) Remove(name string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.queues, name)
+}
+
+func (s *Server) queue(name string) (*goqueue.Queue, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	q, ok := s.queues[name]
+	return q, ok
+}
+
+// QueueInfo is an entry of GET /queues.
+type QueueInfo struct {
+	Name    string `json:"name"`
+	Size    int    `json:"size"`
+	MaxSize int    `json:"max_size"`
+}
+
+func infoOf(name string, q *goqueue.Queue) QueueInfo {
+	return QueueInfo{Name: name, Size: q.Size(), MaxSize: q.MaxSize()}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set(VersionHeader, strconv.Itoa(Version))
+	if v, err := versionOf(r.Header); err != nil || v > Version {
+		http.Error(w, "unsupported protocol version", http.StatusBadRequest)
+		return
+	}
+	path := strings.Trim(r.URL.Path, "/")
+	parts := strings.Split(path, "/")
+	if parts[0] != "queues" || len(parts) > 3 {
+		http.NotFound(w, r)
+		return
+	}
+	if len(parts) == 1 {
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w, http.MethodGet)
+			return
+		}
+		s.list(w)
+		return
+	}
+
+	q, ok := s.queue(parts[1])
+	if !ok {
+		http.Error(w, "unknown queue", http.StatusNotFound)
+		return
+	}
+	action := ""
+	if len(parts) == 3 {
+		action = parts[2]
+	}
+	switch {
+	case action == "" && r.Method == http.MethodPost:
+		s.put(w, r, q)
+	case action == "" && r.Method == http.MethodGet:
+		s.get(w, r, q)
+	case action == "peek" && r.Method == http.MethodGet:
+		val, err := q.Peek()
+		writeValue(w, val, err)
+	case action == "stats" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, q.Stats())
+	case action == "info" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, infoOf(parts[1], q))
+	case action == "blob" && r.Method == http.MethodPost:
+		s.putBlob(w, r, q)
+	case action == "blob" && r.Method == http.MethodGet:
+		s.getBlob(w, r, q)
+	case action == "" || action == "peek" || action == "stats" || action == "info" || action == "blob":
+		if action == "" || action == "blob" {
+			methodNotAllowed(w, http.MethodGet, http.MethodPost)
+		} else {
+			methodNotAllowed(w, http.MethodGet)
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) list(w http.ResponseWriter) {
+	s.mutex.RLock()
+	infos := make([]QueueInfo, 0, len(s.queues))
+	for name, q := range s.queues {
+		infos = append(infos, infoOf(name, q))
+	}
+	s.mutex.RUnlock()
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	writeJSON(w, http.StatusOK, infos)
+}
+
+// context returns the context of a request which waits as timeout says,
+// and whether it may wait at all.
+func (s *Server) context(r *http.Request) (context.Context, context.CancelFunc, bool, error) {
+	timeout := -1.0
+	if v := r.URL.Query().Get("timeout"); v != "" {
+		var err error
+		if timeout, err = strconv.ParseFloat(v, 64); err != nil {
+			return nil, nil, false, err
+		}
+	}
+	if timeout < 0.0 {
+		return r.Context(), func() {}, false, nil
+	}
+	if timeout == 0.0 || timeout > s.MaxTimeout {
+		timeout = s.MaxTimeout
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), time.Duration(timeout*float64(time.Second)))
+	return ctx, cancel, true, nil
+}
+
+func (s *Server) put(w http.ResponseWriter, r *http.Request, q *goqueue.Queue) {
+	var val interface{}
+	if err := json.NewDecoder(r.Body).Decode(&val); err != nil {
+		http.Error(w, "invalid JSON body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx, cancel, wait, err := s.context(r)
+	if err != nil {
+		http.Error(w, "invalid timeout", http.StatusBadRequest)
+		return
+	}
+	defer cancel()
+	if wait {
+		err = q.PutContext(ctx, val)
+	} else {
+		err = q.PutNoWait(val)
+	}
+	switch err {
+	case nil:
+		w.WriteHeader(http.StatusNoContent)
+	case goqueue.ErrRateLimited:
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+	default:
+		http.Error(w, goqueue.ErrFullQueue.Error(), http.StatusServiceUnavailable)
+	}
+}
+
+func (s *Server) get(w http.ResponseWriter, r *http.Request, q *goqueue.Queue) {
+	ctx, cancel, wait, err := s.context(r)
+	if err != nil {
+		http.Error(w, "invalid timeout", http.StatusBadRequest)
+		return
+	}
+	defer cancel()
+	var val interface{}
+	if wait {
+		val, err = q.GetContext(ctx)
+	} else {
+		val, err = q.GetNoWait()
+	}
+	writeValue(w, val, err)
+}
+
+func writeValue(w http.ResponseWriter, val interface{}, err error) {
+	if err != nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writeJSON(w, http.StatusOK, val)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func methodNotAllowed(w http.ResponseWriter, methods ...string) {
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+}
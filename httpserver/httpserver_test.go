@@ -0,0 +1,112 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/damnever/goqueue"
+)
+
+func do(s *Server, method, url, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(method, url, strings.NewReader(body)))
+	return w
+}
+
+func TestServer(t *testing.T) {
+	s := New()
+	q := goqueue.New(1)
+	s.Add("jobs", q)
+
+	fmt.Println("Test put, peek and get a JSON value...")
+	if w := do(s, "POST", "/queues/jobs", `{"id":1}`); w.Code != http.StatusNoContent {
+		t.Fatalf("Expect 204, got %d %s\n", w.Code, w.Body)
+	}
+	if w := do(s, "POST", "/queues/jobs", `2`); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expect 503 when full, got %d\n", w.Code)
+	}
+	if w := do(s, "GET", "/queues/jobs/peek", ""); w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != `{"id":1}` {
+		t.Fatalf("Expect the head, got %d %s\n", w.Code, w.Body)
+	}
+	if w := do(s, "GET", "/queues/jobs", ""); w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != `{"id":1}` {
+		t.Fatalf("Expect the value, got %d %s\n", w.Code, w.Body)
+	}
+	if w := do(s, "GET", "/queues/jobs", ""); w.Code != http.StatusNoContent {
+		t.Fatalf("Expect 204 when empty, got %d\n", w.Code)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test long polling...")
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		q.PutNoWait("late")
+	}()
+	if w := do(s, "GET", "/queues/jobs?timeout=1", ""); w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != `"late"` {
+		t.Fatalf("Expect late, got %d %s\n", w.Code, w.Body)
+	}
+	s.MaxTimeout = 0.02
+	start := time.Now()
+	if w := do(s, "GET", "/queues/jobs?timeout=0", ""); w.Code != http.StatusNoContent || time.Since(start) > time.Second {
+		t.Fatalf("Expect 204 after MaxTimeout, got %d\n", w.Code)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test stats and the list of queues...")
+	s.Add("other", goqueue.New(0))
+	var stats goqueue.Stats
+	w := do(s, "GET", "/queues/jobs/stats", "")
+	if err := json.Unmarshal(w.Body.Bytes(), &stats); err != nil || stats.Puts != 2 {
+		t.Fatalf("Expect 2 puts, got %+v (%v)\n", stats, err)
+	}
+	var infos []QueueInfo
+	w = do(s, "GET", "/queues", "")
+	if err := json.Unmarshal(w.Body.Bytes(), &infos); err != nil || len(infos) != 2 || infos[0].Name != "jobs" {
+		t.Fatalf("Expect jobs and other, got %+v (%v)\n", infos, err)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test bad requests...")
+	for _, c := range []struct {
+		method, url, body string
+		code              int
+	}{
+		{"GET", "/queues/missing", "", http.StatusNotFound},
+		{"POST", "/queues/jobs", "{", http.StatusBadRequest},
+		{"GET", "/queues/jobs?timeout=x", "", http.StatusBadRequest},
+		{"DELETE", "/queues/jobs", "", http.StatusMethodNotAllowed},
+		{"GET", "/queues/jobs/nope", "", http.StatusNotFound},
+	} {
+		if w := do(s, c.method, c.url, c.body); w.Code != c.code {
+			t.Fatalf("Expect %d for %s %s, got %d\n", c.code, c.method, c.url, w.Code)
+		}
+	}
+	s.Remove("other")
+	if w := do(s, "GET", "/queues/other", ""); w.Code != http.StatusNotFound {
+		t.Fatalf("Expect other removed, got %d\n", w.Code)
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestVersion(t *testing.T) {
+	s := New()
+	s.Add("jobs", goqueue.New(0))
+
+	fmt.Println("Test the protocol version is checked...")
+	w := do(s, "GET", "/queues/jobs", "")
+	if w.Code != http.StatusNoContent || w.Header().Get(VersionHeader) != "1" {
+		t.Fatalf("Expect 204 of version 1, got %d of %q\n", w.Code, w.Header().Get(VersionHeader))
+	}
+	r := httptest.NewRequest("GET", "/queues/jobs", nil)
+	r.Header.Set(VersionHeader, "2")
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expect 400 for a newer version, got %d\n", w.Code)
+	}
+	fmt.Println("  ...PASSED")
+}
@@ -0,0 +1,37 @@
+package goqueue
+
+// Putter is the write side of a queue, Queue and the other backends
+// satisfy it.
+type Putter interface {
+	Put(val interface{}, timeout float64) error
+}
+
+// Getter is the read side of a queue, Queue and the other backends
+// satisfy it.
+type Getter interface {
+	Get(timeout float64) (interface{}, error)
+}
+
+// Interface is the whole API shared by Queue, ScheduledQueue,
+// PriorityQueue and the durable backends, code depending on it rather than
+// on *Queue can swap them, or use a test double.
+type Interface interface {
+	Putter
+	Getter
+	PutNoWait(val interface{}) error
+	GetNoWait() (interface{}, error)
+	Size() int
+	IsEmpty() bool
+	IsFull() bool
+}
+
+// Closer is implemented by the queues which hold resources, such as the
+// log of walqueue or the connections of the httpserver Client. Code
+// depending on Interface closes them with:
+//
+//	if c, ok := q.(goqueue.Closer); ok {
+//		c.Close()
+//	}
+type Closer interface {
+	Close() error
+}
@@ -0,0 +1,7 @@
+package goqueue
+
+var (
+	_ Interface = (*Queue)(nil)
+	_ Interface = (*ScheduledQueue)(nil)
+	_ Interface = (*PriorityQueue)(nil)
+)
@@ -0,0 +1,40 @@
+package goqueue
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Jitter randomizes a delay, so many retries or delayed items computed at
+// the same moment don't fire at the same moment.
+type Jitter func(d time.Duration) time.Duration
+
+func randDuration(n time.Duration) time.Duration {
+	if n <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(n)))
+}
+
+// FullJitter returns a random delay between 0 and d.
+func FullJitter(d time.Duration) time.Duration {
+	return randDuration(d)
+}
+
+// EqualJitter keeps half of d and randomizes the other half.
+func EqualJitter(d time.Duration) time.Duration {
+	return d/2 + randDuration(d-d/2)
+}
+
+// DecorrelatedJitter returns a Jitter which picks a delay between base and
+// three times d, capped at max, so the delays of a growing backoff spread
+// out instead of doubling in lockstep.
+func DecorrelatedJitter(base, max time.Duration) Jitter {
+	return func(d time.Duration) time.Duration {
+		d = base + randDuration(3*d-base)
+		if d > max {
+			d = max
+		}
+		return d
+	}
+}
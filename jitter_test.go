@@ -0,0 +1,38 @@
+package goqueue
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestJitter(t *testing.T) {
+	d := time.Second
+
+	fmt.Println("Test jittered delays stay in their ranges...")
+	decorrelated := DecorrelatedJitter(100*time.Millisecond, 2*time.Second)
+	for i := 0; i < 1000; i++ {
+		if j := FullJitter(d); j < 0 || j >= d {
+			t.Fatalf("Expect full jitter in [0, %v), got %v\n", d, j)
+		}
+		if j := EqualJitter(d); j < d/2 || j >= d {
+			t.Fatalf("Expect equal jitter in [%v, %v), got %v\n", d/2, d, j)
+		}
+		if j := decorrelated(d); j < 100*time.Millisecond || j > 2*time.Second {
+			t.Fatalf("Expect decorrelated jitter in [100ms, 2s], got %v\n", j)
+		}
+	}
+	if FullJitter(0) != 0 {
+		t.Fatalf("Expect no delay is kept\n")
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test ScheduledQueue jitters release times...")
+	queue := NewScheduled(0)
+	queue.Jitter = func(d time.Duration) time.Duration { return 0 }
+	queue.PutScheduled("now", time.Now().Add(time.Hour), 0, -1)
+	if val, err := queue.GetNoWait(); err != nil || val.(string) != "now" {
+		t.Fatalf("Expect the item released, got %v (%v)\n", val, err)
+	}
+	fmt.Println("  ...PASSED")
+}
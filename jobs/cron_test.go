@@ -0,0 +1,52 @@
+package jobs
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	fmt.Println("Test invalid cron expressions...")
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		if _, err := ParseCron(spec); err == nil {
+			t.Fatalf("Expect error for %q\n", spec)
+		}
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestCronNext(t *testing.T) {
+	base := time.Date(2024, 1, 31, 10, 29, 30, 0, time.UTC) // Wednesday
+
+	fmt.Println("Test next matching time of cron expressions...")
+	cases := []struct {
+		spec   string
+		expect time.Time
+	}{
+		{"* * * * *", time.Date(2024, 1, 31, 10, 30, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 1, 31, 10, 30, 0, 0, time.UTC)},
+		{"0 * * * *", time.Date(2024, 1, 31, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 9 * * 1-5", time.Date(2024, 2, 1, 9, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, 2, 4, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"30 8 1,15 * *", time.Date(2024, 2, 1, 8, 30, 0, 0, time.UTC)},
+		// Either day of month or day of week matches.
+		{"0 0 13 * 5", time.Date(2024, 2, 2, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, c := range cases {
+		cron, err := ParseCron(c.spec)
+		if err != nil {
+			t.Fatalf("Unexpect error: %v\n", err)
+		}
+		if next := cron.Next(base); !next.Equal(c.expect) {
+			t.Fatalf("Expect %v for %q, got %v\n", c.expect, c.spec, next)
+		}
+	}
+	cron, _ := ParseCron("0 0 30 2 *")
+	if next := cron.Next(base); !next.IsZero() {
+		t.Fatalf("Expect no next time, got %v\n", next)
+	}
+	fmt.Println("  ...PASSED")
+}
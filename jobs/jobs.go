@@ -0,0 +1,331 @@
+/*
+Package jobs runs typed jobs on top of a goqueue.ScheduledQueue.
+
+A Job names its handler by Type, handlers are registered on a Runner, and
+the Runner's workers take jobs from the queue, run the handler with panic
+recovery and an optional timeout, then retry the job with backoff or move
+it to the dead letter Queue once it runs out of attempts. Jobs which keep
+panicking or timing out are moved to a separate quarantine Queue instead.
+*/
+
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/damnever/goqueue"
+)
+
+// DefaultMaxAttempts is used when Job.MaxAttempts is zero.
+const DefaultMaxAttempts = 3
+
+var (
+	// No handler is registered for the job type.
+	ErrNoHandler = errors.New("no handler for job type")
+	// Handler did not return within the job timeout.
+	ErrTimeout = errors.New("job timed out")
+)
+
+// PanicError is the failure of a handler which panicked.
+type PanicError = goqueue.PanicError
+
+// isCrash reports whether err is a panic or a timeout, unlike an ordinary
+// error those may be caused by the job itself taking down its consumer.
+func isCrash(err error) bool {
+	_, ok := err.(*PanicError)
+	return ok || err == ErrTimeout
+}
+
+// Quarantined is put into Runner.Quarantine for a poison job.
+type Quarantined struct {
+	Job *Job
+	// Reason describes why the job is quarantined, Stack is the stack of
+	// the last panic if any.
+	Reason string
+	Stack  []byte
+}
+
+// Fate is how the Runner is done with a job.
+type Fate int
+
+const (
+	// Succeeded means a run of the job returned nil.
+	Succeeded Fate = iota
+	// DeadLettered means the job was put into Runner.DeadLetter.
+	DeadLettered
+	// Poisoned means the job was put into Runner.Quarantine.
+	Poisoned
+	// Dropped means the job was given up but Runner.DeadLetter is nil or
+	// full, or Runner.Quarantine is full.
+	Dropped
+)
+
+func (f Fate) String() string {
+	switch f {
+	case Succeeded:
+		return "succeeded"
+	case DeadLettered:
+		return "dead lettered"
+	case Poisoned:
+		return "poisoned"
+	case Dropped:
+		return "dropped"
+	}
+	return fmt.Sprintf("Fate(%d)", int(f))
+}
+
+// Job is the envelope put into the queue.
+type Job struct {
+	Type    string
+	Payload interface{}
+	// MaxAttempts is the max number of runs, zero means DefaultMaxAttempts.
+	MaxAttempts int
+	// Timeout is the max seconds of a run, zero means no timeout.
+	Timeout float64
+	// Priority and RunAt are passed to ScheduledQueue.PutScheduled.
+	Priority int
+	RunAt    time.Time
+
+	// Attempts is the number of finished runs, and LastError the error of
+	// the last one, both are maintained by the Runner.
+	Attempts  int
+	LastError string
+	// Crashes are the times of the runs which panicked or timed out within
+	// Runner.PoisonWindow, maintained by the Runner.
+	Crashes []time.Time
+
+	// OnFate is called, if not nil, once the Runner is done with the job,
+	// it is not encoded by backends which use gob.
+	OnFate func(job *Job, fate Fate)
+}
+
+func (j *Job) settle(fate Fate) {
+	if j.OnFate != nil {
+		j.OnFate(j, fate)
+	}
+}
+
+func (j *Job) maxAttempts() int {
+	if j.MaxAttempts > 0 {
+		return j.MaxAttempts
+	}
+	return DefaultMaxAttempts
+}
+
+// Handler processes a job, the ctx is cancelled when the job timed out or
+// the Runner is stopping.
+type Handler func(ctx context.Context, job *Job) error
+
+// ExponentialBackoff returns 1s, 2s, 4s, ... capped at one minute.
+func ExponentialBackoff(attempts int) time.Duration {
+	return goqueue.ExponentialBackoff(attempts)
+}
+
+// Runner consumes jobs from a ScheduledQueue.
+type Runner struct {
+	queue    *goqueue.ScheduledQueue
+	mutex    sync.RWMutex
+	handlers map[string]Handler
+
+	// DeadLetter receives jobs that ran out of attempts or have no handler,
+	// they are dropped if it is nil or full.
+	DeadLetter *goqueue.Queue
+	// Backoff returns the delay before the next run of a job which failed
+	// attempts times, default is ExponentialBackoff.
+	Backoff func(attempts int) time.Duration
+	// Jitter, if not nil, randomizes the Backoff delays, e.g.
+	// goqueue.FullJitter, so jobs failing together aren't retried together.
+	Jitter goqueue.Jitter
+	// OnError is called, if not nil, every time a run fails.
+	OnError func(job *Job, err error)
+
+	// Quarantine receives poison jobs: jobs which panicked or timed out
+	// PoisonThreshold times within PoisonWindow, they are not retried nor
+	// dead lettered. Poison detection is disabled if Quarantine is nil.
+	Quarantine      *goqueue.Queue
+	PoisonThreshold int
+	PoisonWindow    time.Duration
+}
+
+// NewRunner create a Runner with a new ScheduledQueue of maxSize.
+func NewRunner(maxSize int) *Runner {
+	return &Runner{
+		queue:    goqueue.NewScheduled(maxSize),
+		handlers: make(map[string]Handler),
+		Backoff:  ExponentialBackoff,
+
+		PoisonThreshold: 3,
+		PoisonWindow:    10 * time.Minute,
+	}
+}
+
+// Queue returns the underlying ScheduledQueue.
+func (r *Runner) Queue() *goqueue.ScheduledQueue {
+	return r.queue
+}
+
+// Register sets the handler of jobs with type typ.
+func (r *Runner) Register(typ string, h Handler) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.handlers[typ] = h
+}
+
+func (r *Runner) handler(typ string) (Handler, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	h, ok := r.handlers[typ]
+	return h, ok
+}
+
+// Enqueue puts a job into the queue, the timeout semantics are the same as
+// goqueue.Queue.Put.
+func (r *Runner) Enqueue(job *Job, timeout float64) error {
+	return r.queue.PutScheduled(job, job.RunAt, job.Priority, timeout)
+}
+
+// Run starts workers goroutines and blocks until stop is closed and every
+// running job has returned.
+func (r *Runner) Run(workers int, stop <-chan struct{}) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-stop
+		cancel()
+	}()
+
+	wg := &sync.WaitGroup{}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.work(ctx)
+		}()
+	}
+	wg.Wait()
+	cancel()
+}
+
+func (r *Runner) work(ctx context.Context) {
+	for ctx.Err() == nil {
+		val, err := r.queue.Get(0.1)
+		if err != nil {
+			continue
+		}
+		r.process(ctx, val.(*Job))
+	}
+}
+
+func (r *Runner) process(ctx context.Context, job *Job) {
+	h, ok := r.handler(job.Type)
+	if !ok {
+		job.LastError = ErrNoHandler.Error()
+		r.fail(job, ErrNoHandler)
+		r.deadLetter(job)
+		return
+	}
+
+	err := r.run(ctx, h, job)
+	job.Attempts++
+	if err == nil {
+		job.settle(Succeeded)
+		return
+	}
+	job.LastError = err.Error()
+	r.fail(job, err)
+
+	if isCrash(err) && r.poison(job, err) {
+		return
+	}
+	if job.Attempts >= job.maxAttempts() {
+		r.deadLetter(job)
+		return
+	}
+	delay := r.Backoff(job.Attempts)
+	if r.Jitter != nil {
+		delay = r.Jitter(delay)
+	}
+	job.RunAt = time.Now().Add(delay)
+	if err := r.Enqueue(job, -1); err != nil {
+		r.deadLetter(job)
+	}
+}
+
+// run calls h with panic recovery and the job timeout.
+func (r *Runner) run(ctx context.Context, h Handler, job *Job) error {
+	if job.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(job.Timeout*float64(time.Second)))
+		defer cancel()
+	}
+
+	// The handler may outlive a timeout, so it gets its own copy.
+	c := *job
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- &PanicError{Value: p, Stack: debug.Stack()}
+			}
+		}()
+		done <- h(ctx, &c)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		if ctx.Err() == context.DeadlineExceeded {
+			return ErrTimeout
+		}
+		return <-done
+	}
+}
+
+// poison records a crash of job, and quarantines the job if it crashed too
+// many times.
+func (r *Runner) poison(job *Job, err error) bool {
+	now := time.Now()
+	crashes := job.Crashes[:0]
+	for _, t := range job.Crashes {
+		if now.Sub(t) < r.PoisonWindow {
+			crashes = append(crashes, t)
+		}
+	}
+	job.Crashes = append(crashes, now)
+
+	if r.Quarantine == nil || r.PoisonThreshold <= 0 || len(job.Crashes) < r.PoisonThreshold {
+		return false
+	}
+	q := &Quarantined{
+		Job:    job,
+		Reason: fmt.Sprintf("%d crashes within %v, last: %v", len(job.Crashes), r.PoisonWindow, err),
+	}
+	if pe, ok := err.(*PanicError); ok {
+		q.Stack = pe.Stack
+	}
+	if r.Quarantine.PutNoWait(q) == nil {
+		job.settle(Poisoned)
+	} else {
+		job.settle(Dropped)
+	}
+	return true
+}
+
+func (r *Runner) fail(job *Job, err error) {
+	if r.OnError != nil {
+		r.OnError(job, err)
+	}
+}
+
+func (r *Runner) deadLetter(job *Job) {
+	if r.DeadLetter != nil && r.DeadLetter.PutNoWait(job) == nil {
+		job.settle(DeadLettered)
+		return
+	}
+	job.settle(Dropped)
+}
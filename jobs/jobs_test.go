@@ -0,0 +1,265 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/damnever/goqueue"
+)
+
+func noBackoff(int) time.Duration { return 0 }
+
+func startRunner(r *Runner) func() {
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		r.Run(2, stop)
+		close(done)
+	}()
+	return func() {
+		close(stop)
+		<-done
+	}
+}
+
+func deadJob(t *testing.T, dlq *goqueue.Queue) *Job {
+	val, err := dlq.Get(2)
+	if err != nil {
+		t.Fatalf("Expect a dead letter job, got %v\n", err)
+	}
+	return val.(*Job)
+}
+
+func TestRunnerSuccess(t *testing.T) {
+	r := NewRunner(0)
+	results := make(chan interface{}, 1)
+	r.Register("echo", func(ctx context.Context, job *Job) error {
+		results <- job.Payload
+		return nil
+	})
+	stop := startRunner(r)
+	defer stop()
+
+	fmt.Println("Test job is handled by the registered handler...")
+	r.Enqueue(&Job{Type: "echo", Payload: 42}, -1)
+	select {
+	case val := <-results:
+		if val.(int) != 42 {
+			t.Fatalf("Expect %v, got %v\n", 42, val)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Job is not handled\n")
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestRunnerRetry(t *testing.T) {
+	r := NewRunner(0)
+	r.Backoff = noBackoff
+	r.DeadLetter = goqueue.New(0)
+	var calls int32
+	errs := make(chan error, 10)
+	r.OnError = func(job *Job, err error) { errs <- err }
+	r.Register("flaky", func(ctx context.Context, job *Job) error {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			return errors.New("try again")
+		}
+		return nil
+	})
+	r.Register("broken", func(ctx context.Context, job *Job) error {
+		return errors.New("always")
+	})
+	stop := startRunner(r)
+	defer stop()
+
+	fmt.Println("Test failed job is retried until it succeeds...")
+	r.Enqueue(&Job{Type: "flaky", MaxAttempts: 3}, -1)
+	for i := 0; i < 2; i++ {
+		<-errs
+	}
+	time.Sleep(100 * time.Millisecond)
+	if n := atomic.LoadInt32(&calls); n != 3 {
+		t.Fatalf("Expect %d calls, got %d\n", 3, n)
+	}
+	if !r.DeadLetter.IsEmpty() {
+		t.Fatalf("Expect no dead letter jobs\n")
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test job is dead lettered after max attempts...")
+	r.Enqueue(&Job{Type: "broken", MaxAttempts: 2}, -1)
+	job := deadJob(t, r.DeadLetter)
+	if job.Attempts != 2 || job.LastError != "always" {
+		t.Fatalf("Expect 2 attempts with error, got %d %q\n", job.Attempts, job.LastError)
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestRunnerJitter(t *testing.T) {
+	r := NewRunner(0)
+	r.Jitter = func(d time.Duration) time.Duration {
+		if d != time.Second {
+			t.Errorf("Expect the Backoff delay, got %v\n", d)
+		}
+		return 0
+	}
+	var calls int32
+	r.Register("flaky", func(ctx context.Context, job *Job) error {
+		if atomic.AddInt32(&calls, 1) < 2 {
+			return errors.New("try again")
+		}
+		return nil
+	})
+	stop := startRunner(r)
+	defer stop()
+
+	fmt.Println("Test Jitter applies to the retry delay...")
+	r.Enqueue(&Job{Type: "flaky"}, -1)
+	time.Sleep(300 * time.Millisecond)
+	if n := atomic.LoadInt32(&calls); n != 2 {
+		t.Fatalf("Expect %d calls, got %d\n", 2, n)
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestRunnerFate(t *testing.T) {
+	r := NewRunner(0)
+	r.Backoff = noBackoff
+	r.DeadLetter = goqueue.New(1)
+	r.DeadLetter.PutNoWait(nil)
+	r.Register("ok", func(ctx context.Context, job *Job) error { return nil })
+	r.Register("broken", func(ctx context.Context, job *Job) error {
+		return errors.New("always")
+	})
+	stop := startRunner(r)
+	defer stop()
+
+	fates := make(chan Fate, 3)
+	onFate := func(job *Job, fate Fate) { fates <- fate }
+	expectFate := func(expect Fate) {
+		select {
+		case fate := <-fates:
+			if fate != expect {
+				t.Fatalf("Expect %v, got %v\n", expect, fate)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Expect %v, got nothing\n", expect)
+		}
+	}
+
+	fmt.Println("Test OnFate is called once the Runner is done with a job...")
+	r.Enqueue(&Job{Type: "ok", OnFate: onFate}, -1)
+	expectFate(Succeeded)
+	r.Enqueue(&Job{Type: "broken", MaxAttempts: 2, OnFate: onFate}, -1)
+	expectFate(Dropped)
+	r.DeadLetter.GetNoWait()
+	r.Enqueue(&Job{Type: "missing", OnFate: onFate}, -1)
+	expectFate(DeadLettered)
+	fmt.Println("  ...PASSED")
+}
+
+func TestRunnerPanicAndTimeout(t *testing.T) {
+	r := NewRunner(0)
+	r.Backoff = noBackoff
+	r.DeadLetter = goqueue.New(0)
+	r.Register("panic", func(ctx context.Context, job *Job) error {
+		panic("boom")
+	})
+	r.Register("slow", func(ctx context.Context, job *Job) error {
+		<-ctx.Done()
+		return nil
+	})
+	stop := startRunner(r)
+	defer stop()
+
+	fmt.Println("Test panic is recovered and the worker keeps running...")
+	r.Enqueue(&Job{Type: "panic", MaxAttempts: 1}, -1)
+	if job := deadJob(t, r.DeadLetter); job.LastError != "panic: boom" {
+		t.Fatalf("Expect panic error, got %q\n", job.LastError)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test job times out...")
+	r.Enqueue(&Job{Type: "slow", MaxAttempts: 1, Timeout: 0.05}, -1)
+	if job := deadJob(t, r.DeadLetter); job.LastError != ErrTimeout.Error() {
+		t.Fatalf("Expect %v, got %q\n", ErrTimeout, job.LastError)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test job without handler is dead lettered...")
+	r.Enqueue(&Job{Type: "unknown"}, -1)
+	if job := deadJob(t, r.DeadLetter); job.LastError != ErrNoHandler.Error() || job.Attempts != 0 {
+		t.Fatalf("Expect %v, got %q\n", ErrNoHandler, job.LastError)
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	fmt.Println("Test exponential backoff...")
+	for attempts, expect := range map[int]time.Duration{
+		1:  time.Second,
+		2:  2 * time.Second,
+		4:  8 * time.Second,
+		10: time.Minute,
+	} {
+		if d := ExponentialBackoff(attempts); d != expect {
+			t.Fatalf("Expect %v for %d attempts, got %v\n", expect, attempts, d)
+		}
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestRunnerQuarantine(t *testing.T) {
+	r := NewRunner(0)
+	r.Backoff = noBackoff
+	r.DeadLetter = goqueue.New(0)
+	r.Quarantine = goqueue.New(0)
+	r.PoisonThreshold = 2
+	r.PoisonWindow = time.Minute
+	r.Register("poison", func(ctx context.Context, job *Job) error {
+		panic("boom")
+	})
+	r.Register("broken", func(ctx context.Context, job *Job) error {
+		return errors.New("always")
+	})
+	stop := startRunner(r)
+	defer stop()
+
+	fmt.Println("Test crashing job is quarantined...")
+	r.Enqueue(&Job{Type: "poison", MaxAttempts: 10}, -1)
+	val, err := r.Quarantine.Get(2)
+	if err != nil {
+		t.Fatalf("Expect a quarantined job, got %v\n", err)
+	}
+	q := val.(*Quarantined)
+	if q.Job.Attempts != 2 || len(q.Job.Crashes) != 2 {
+		t.Fatalf("Expect 2 crashes, got %d attempts %d crashes\n", q.Job.Attempts, len(q.Job.Crashes))
+	}
+	if q.Reason == "" || !strings.Contains(string(q.Stack), "panic") {
+		t.Fatalf("Expect diagnostics, got reason %q stack %q\n", q.Reason, q.Stack)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test old crashes are forgotten...")
+	old := time.Now().Add(-time.Hour)
+	r.Enqueue(&Job{Type: "poison", MaxAttempts: 1, Crashes: []time.Time{old, old}}, -1)
+	if job := deadJob(t, r.DeadLetter); len(job.Crashes) != 1 {
+		t.Fatalf("Expect 1 recent crash, got %d\n", len(job.Crashes))
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test ordinary failures are dead lettered...")
+	r.Enqueue(&Job{Type: "broken", MaxAttempts: 3}, -1)
+	if job := deadJob(t, r.DeadLetter); job.Attempts != 3 {
+		t.Fatalf("Expect 3 attempts, got %d\n", job.Attempts)
+	}
+	if !r.Quarantine.IsEmpty() {
+		t.Fatalf("Expect empty quarantine\n")
+	}
+	fmt.Println("  ...PASSED")
+}
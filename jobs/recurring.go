@@ -0,0 +1,224 @@
+package jobs
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+// maxCatchUp bounds the runs enqueued for one entry by a single Tick.
+const maxCatchUp = 1000
+
+// MissedPolicy decides what happens to runs which were due while the
+// Scheduler was not running.
+type MissedPolicy int
+
+const (
+	// SkipMissed drops missed runs, only runs which became due while the
+	// Scheduler was running are enqueued.
+	SkipMissed MissedPolicy = iota
+	// CatchUpOnce enqueues a single run for all the missed ones.
+	CatchUpOnce
+	// CatchUpAll enqueues every missed run (at most 1000 per Tick).
+	CatchUpAll
+)
+
+// LastRunStore records the last run of each recurring job, so a restarted
+// Scheduler knows which runs were missed.
+type LastRunStore interface {
+	LastRun(name string) (time.Time, bool, error)
+	SetLastRun(name string, t time.Time) error
+}
+
+// MemoryStore is a LastRunStore which forgets everything on restart.
+type MemoryStore struct {
+	mutex sync.Mutex
+	runs  map[string]time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{runs: make(map[string]time.Time)}
+}
+
+func (s *MemoryStore) LastRun(name string) (time.Time, bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	t, ok := s.runs[name]
+	return t, ok, nil
+}
+
+func (s *MemoryStore) SetLastRun(name string, t time.Time) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.runs[name] = t
+	return nil
+}
+
+// FileStore is a LastRunStore kept in a JSON file, which is replaced
+// atomically on every update.
+type FileStore struct {
+	mutex sync.Mutex
+	path  string
+	runs  map[string]time.Time
+}
+
+// NewFileStore loads the store from path, a missing file is an empty store.
+func NewFileStore(path string) (*FileStore, error) {
+	s := &FileStore{path: path, runs: make(map[string]time.Time)}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &s.runs); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileStore) LastRun(name string) (time.Time, bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	t, ok := s.runs[name]
+	return t, ok, nil
+}
+
+func (s *FileStore) SetLastRun(name string, t time.Time) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.runs[name] = t
+	data, err := json.Marshal(s.runs)
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+type recurring struct {
+	name   string
+	cron   *Cron
+	job    Job
+	policy MissedPolicy
+	added  time.Time
+}
+
+// Scheduler enqueues recurring jobs into a Runner by cron expression.
+type Scheduler struct {
+	runner  *Runner
+	store   LastRunStore
+	mutex   sync.Mutex
+	entries []*recurring
+	started time.Time
+	now     func() time.Time
+
+	// OnError is called, if not nil, when a recurring job can't be enqueued
+	// or its last run can't be recorded.
+	OnError func(name string, err error)
+}
+
+// NewScheduler create a Scheduler which enqueues into r and records runs in
+// store, use NewMemoryStore if nothing needs to survive a restart.
+func NewScheduler(r *Runner, store LastRunStore) *Scheduler {
+	return &Scheduler{
+		runner:  r,
+		store:   store,
+		started: time.Now(),
+		now:     time.Now,
+	}
+}
+
+// Add registers a copy of job to be enqueued at every time matching spec,
+// name identifies the job in the LastRunStore.
+func (s *Scheduler) Add(name, spec string, job Job, policy MissedPolicy) error {
+	cron, err := ParseCron(spec)
+	if err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.entries = append(s.entries, &recurring{
+		name:   name,
+		cron:   cron,
+		job:    job,
+		policy: policy,
+		added:  s.now(),
+	})
+	return nil
+}
+
+// Tick enqueues the runs which became due since the last recorded ones, Run
+// calls it every minute.
+func (s *Scheduler) Tick(now time.Time) {
+	s.mutex.Lock()
+	entries := append([]*recurring(nil), s.entries...)
+	s.mutex.Unlock()
+
+	for _, e := range entries {
+		if err := s.tick(e, now); err != nil && s.OnError != nil {
+			s.OnError(e.name, err)
+		}
+	}
+}
+
+func (s *Scheduler) tick(e *recurring, now time.Time) error {
+	last, ok, err := s.store.LastRun(e.name)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		last = e.added
+	}
+
+	due := make([]time.Time, 0, 1)
+	for t := e.cron.Next(last); !t.IsZero() && !t.After(now) && len(due) < maxCatchUp; t = e.cron.Next(t) {
+		due = append(due, t)
+	}
+	if len(due) == 0 {
+		return nil
+	}
+	latest := due[len(due)-1]
+
+	switch e.policy {
+	case SkipMissed:
+		live := due[:0]
+		for _, t := range due {
+			if !t.Before(s.started.Truncate(time.Minute)) {
+				live = append(live, t)
+			}
+		}
+		due = live
+	case CatchUpOnce:
+		due = due[len(due)-1:]
+	}
+
+	for range due {
+		job := e.job
+		if err := s.runner.Enqueue(&job, -1); err != nil {
+			return err
+		}
+	}
+	return s.store.SetLastRun(e.name, latest)
+}
+
+// Run calls Tick at the start of every minute until stop is closed.
+func (s *Scheduler) Run(stop <-chan struct{}) {
+	for {
+		now := s.now()
+		next := now.Truncate(time.Minute).Add(time.Minute)
+		t := time.NewTimer(next.Sub(now))
+		select {
+		case <-stop:
+			t.Stop()
+			return
+		case <-t.C:
+			s.Tick(s.now())
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package jobs
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestScheduler(started time.Time) (*Scheduler, *Runner) {
+	r := NewRunner(0)
+	s := NewScheduler(r, NewMemoryStore())
+	s.started = started
+	s.now = func() time.Time { return started }
+	return s, r
+}
+
+func TestSchedulerPolicies(t *testing.T) {
+	down := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	up := down.Add(time.Hour)
+
+	for _, c := range []struct {
+		policy MissedPolicy
+		expect int
+	}{
+		{SkipMissed, 2}, // 11:00 and 11:01
+		{CatchUpOnce, 1},
+		{CatchUpAll, 61},
+	} {
+		fmt.Printf("Test missed policy %d...\n", c.policy)
+		s, r := newTestScheduler(up)
+		s.store.SetLastRun("every-minute", down)
+		s.Add("every-minute", "* * * * *", Job{Type: "tick"}, c.policy)
+		s.Tick(up.Add(time.Minute))
+		if n := r.Queue().Size(); n != c.expect {
+			t.Fatalf("Expect %d runs, got %d\n", c.expect, n)
+		}
+		last, _, _ := s.store.LastRun("every-minute")
+		if !last.Equal(up.Add(time.Minute)) {
+			t.Fatalf("Expect last run %v, got %v\n", up.Add(time.Minute), last)
+		}
+		s.Tick(up.Add(time.Minute))
+		if n := r.Queue().Size(); n != c.expect {
+			t.Fatalf("Expect no more runs, got %d\n", n-c.expect)
+		}
+		fmt.Println("  ...PASSED")
+	}
+}
+
+func TestSchedulerNewEntry(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 30, 0, time.UTC)
+	s, r := newTestScheduler(now)
+
+	fmt.Println("Test new entry runs from the time it was added...")
+	if err := s.Add("bad", "* *", Job{}, SkipMissed); err == nil {
+		t.Fatalf("Expect error for bad cron expression\n")
+	}
+	s.Add("hourly", "@hourly", Job{Type: "report"}, CatchUpAll)
+	s.Tick(now.Add(10 * time.Minute))
+	if n := r.Queue().Size(); n != 0 {
+		t.Fatalf("Expect no runs, got %d\n", n)
+	}
+	s.Tick(now.Add(time.Hour))
+	val, err := r.Queue().GetNoWait()
+	if err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	} else if val.(*Job).Type != "report" {
+		t.Fatalf("Expect job %v, got %v\n", "report", val.(*Job).Type)
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goqueue-jobs")
+	if err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "runs.json")
+
+	fmt.Println("Test FileStore keeps last runs across restarts...")
+	s, err := NewFileStore(path)
+	if err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	if _, ok, _ := s.LastRun("a"); ok {
+		t.Fatalf("Expect no last run\n")
+	}
+	at := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	if err := s.SetLastRun("a", at); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	s, err = NewFileStore(path)
+	if err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	if last, ok, _ := s.LastRun("a"); !ok || !last.Equal(at) {
+		t.Fatalf("Expect last run %v, got %v\n", at, last)
+	}
+	fmt.Println("  ...PASSED")
+}
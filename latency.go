@@ -0,0 +1,117 @@
+package goqueue
+
+import (
+	"sort"
+	"time"
+)
+
+// DefaultLatencyBuckets are the upper bounds used by WithLatency when none
+// are given.
+var DefaultLatencyBuckets = []time.Duration{
+	time.Millisecond, 10 * time.Millisecond, 100 * time.Millisecond,
+	time.Second, 10 * time.Second, time.Minute,
+}
+
+// latency stamps the values of Queue and records how long they waited.
+type latency struct {
+	buckets []time.Duration
+	counts  []int64 // per bucket, the last one counts the values over all
+	sum     time.Duration
+	stamps  *ring // time.Time of each value, parallel to items
+}
+
+// LatencyStats is a histogram of the time values spent in Queue before
+// Get took them, a value handed to a blocked Get counts as zero.
+type LatencyStats struct {
+	// Buckets are the upper bounds, Counts[i] counts the values which
+	// waited up to Buckets[i] and more than Buckets[i-1]. The last count is
+	// for the values which waited longer than every bucket.
+	Buckets []time.Duration
+	Counts  []int64
+	Count   int64
+	Sum     time.Duration
+}
+
+// WithLatency stamps every value put into Queue and records how long it
+// waits until it is got, see Latency. buckets are the upper bounds of the
+// histogram, default is DefaultLatencyBuckets. Values already in Queue
+// are stamped when it is applied.
+func WithLatency(buckets ...time.Duration) Option {
+	return func(q *Queue) {
+		if len(buckets) == 0 {
+			buckets = DefaultLatencyBuckets
+		}
+		l := &latency{
+			buckets: append([]time.Duration(nil), buckets...),
+			counts:  make([]int64, len(buckets)+1),
+			stamps:  newRing(q.items.Len()),
+		}
+		sort.Slice(l.buckets, func(i, j int) bool { return l.buckets[i] < l.buckets[j] })
+		if q.latency != nil {
+			l.stamps = q.latency.stamps
+		} else {
+			now := time.Now()
+			for i := 0; i < q.items.Len(); i++ {
+				l.stamps.pushBack(now)
+			}
+		}
+		q.latency = l
+	}
+}
+
+func (l *latency) push(front bool, at time.Time) {
+	if l == nil {
+		return
+	}
+	if front {
+		l.stamps.pushFront(at)
+	} else {
+		l.stamps.pushBack(at)
+	}
+}
+
+func (l *latency) pop(back bool) time.Time {
+	if l == nil {
+		return time.Time{}
+	}
+	if back {
+		return l.stamps.popBack().(time.Time)
+	}
+	return l.stamps.popFront().(time.Time)
+}
+
+func (l *latency) record(d time.Duration) {
+	if l == nil {
+		return
+	}
+	i := sort.Search(len(l.buckets), func(i int) bool { return d <= l.buckets[i] })
+	l.counts[i]++
+	l.sum += d
+}
+
+// stamp records the time val is put at the front if front, or at the back.
+func (q *Queue) stamp(front bool) {
+	if q.latency != nil {
+		q.latency.push(front, time.Now())
+	}
+}
+
+// Latency returns the histogram recorded since WithLatency was applied,
+// or a zero LatencyStats if it wasn't.
+func (q *Queue) Latency() LatencyStats {
+	q.mutex.Lock()
+	defer q.unlock()
+	l := q.latency
+	if l == nil {
+		return LatencyStats{}
+	}
+	s := LatencyStats{
+		Buckets: append([]time.Duration(nil), l.buckets...),
+		Counts:  append([]int64(nil), l.counts...),
+		Sum:     l.sum,
+	}
+	for _, n := range l.counts {
+		s.Count += n
+	}
+	return s
+}
@@ -0,0 +1,56 @@
+package goqueue
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestLatency(t *testing.T) {
+	queue := New(0, WithLatency(10*time.Millisecond, 100*time.Millisecond))
+
+	fmt.Println("Test the time values waited is recorded...")
+	queue.PutNoWait("slow")
+	queue.PutNoWait("dropped")
+	queue.PutNoWait("fast")
+	time.Sleep(20 * time.Millisecond)
+	queue.RemoveFunc(func(val interface{}) bool { return val.(string) == "dropped" })
+	queue.GetNoWait()
+	queue.PutFront("new", -1)
+	queue.GetNoWait()
+	queue.GetNoWait()
+	stats := queue.Latency()
+	if stats.Count != 3 || stats.Counts[0] != 1 || stats.Counts[1] != 2 || stats.Counts[2] != 0 {
+		t.Fatalf("Expect 1 value under 10ms and 2 under 100ms, got %+v\n", stats)
+	}
+	if stats.Sum < 40*time.Millisecond {
+		t.Fatalf("Expect 40ms at least in total, got %v\n", stats.Sum)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test a handoff counts as zero...")
+	done := make(chan struct{})
+	go func() {
+		queue.Get(0)
+		close(done)
+	}()
+	for queue.Stats().Getters != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	queue.PutNoWait("handoff")
+	<-done
+	if stats := queue.Latency(); stats.Count != 4 || stats.Counts[0] != 2 {
+		t.Fatalf("Expect 2 values under 10ms, got %+v\n", stats)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test values already in Queue are stamped...")
+	queue = New(0)
+	queue.PutNoWait(1)
+	queue.Reconfigure(WithLatency())
+	queue.GetNoWait()
+	if stats := queue.Latency(); stats.Count != 1 || len(stats.Buckets) != len(DefaultLatencyBuckets) {
+		t.Fatalf("Expect 1 value recorded, got %+v\n", stats)
+	}
+	fmt.Println("  ...PASSED")
+}
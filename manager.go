@@ -0,0 +1,197 @@
+package goqueue
+
+import (
+	"errors"
+	"sort"
+	"sync"
+)
+
+var (
+	// A queue with the name exists already.
+	ErrQueueExists = errors.New("queue exists")
+	// No queue has the name.
+	ErrNoQueue = errors.New("no such queue")
+)
+
+// Config is the configuration a Manager creates a Queue with.
+type Config struct {
+	MaxSize int
+	Options []Option
+	// Reserve is the bytes of the Manager Budget the Queue can always use,
+	// see NewBudgetManager.
+	Reserve int64
+}
+
+type managed struct {
+	queue  *Queue
+	config Config
+}
+
+// Manager is a GoRoutine safe registry of named queues, for services which
+// create queues on the fly.
+type Manager struct {
+	mutex    sync.RWMutex
+	defaults Config
+	queues   map[string]managed
+	budget   *budget // nil without a Budget
+}
+
+// NewManager create a Manager, queues opened without their own
+// configuration use defaults.
+func NewManager(defaults Config) *Manager {
+	return &Manager{
+		defaults: defaults,
+		queues:   make(map[string]managed),
+	}
+}
+
+// NewBudgetManager create a Manager whose queues share b, the bytes of
+// their values, as told by b.SizeOf, are counted from Put until they leave.
+func NewBudgetManager(defaults Config, b Budget) *Manager {
+	m := NewManager(defaults)
+	m.budget = &budget{Budget: b, accounts: make(map[*account]struct{})}
+	return m
+}
+
+// create must be called with the mutex held.
+func (m *Manager) create(name string, c Config) *Queue {
+	q := New(c.MaxSize, c.Options...)
+	if m.budget != nil {
+		q.account = m.budget.open(q, c.Reserve)
+	}
+	m.queues[name] = managed{queue: q, config: c}
+	return q
+}
+
+// Create a Queue named name with c, ErrQueueExists is returned if the name
+// is taken.
+func (m *Manager) Create(name string, c Config) (*Queue, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if _, ok := m.queues[name]; ok {
+		return nil, ErrQueueExists
+	}
+	return m.create(name, c), nil
+}
+
+// Open returns the Queue named name, it is created with the default
+// configuration if there is none.
+func (m *Manager) Open(name string) *Queue {
+	if q, ok := m.Queue(name); ok {
+		return q
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if e, ok := m.queues[name]; ok {
+		return e.queue
+	}
+	return m.create(name, m.defaults)
+}
+
+// Queue returns the Queue named name.
+func (m *Manager) Queue(name string) (*Queue, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	e, ok := m.queues[name]
+	return e.queue, ok
+}
+
+// Resolve returns the Queue named name or ErrNoQueue, so a Manager is a
+// Resolver for Request.Reply.
+func (m *Manager) Resolve(name string) (Putter, error) {
+	q, ok := m.Queue(name)
+	if !ok {
+		return nil, ErrNoQueue
+	}
+	return q, nil
+}
+
+// Config returns the configuration the Queue named name was created with.
+func (m *Manager) Config(name string) (Config, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	e, ok := m.queues[name]
+	if !ok {
+		return Config{}, ErrNoQueue
+	}
+	return e.config, nil
+}
+
+// Delete removes the Queue named name from Manager and returns it. The Queue
+// itself is left as is, Drain or Reset it to deal with its values and
+// blocked operators; they don't count against the Budget anymore.
+func (m *Manager) Delete(name string) (*Queue, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	e, ok := m.queues[name]
+	if !ok {
+		return nil, ErrNoQueue
+	}
+	delete(m.queues, name)
+	if m.budget != nil {
+		m.budget.close(e.queue.account)
+	}
+	return e.queue, nil
+}
+
+// Return the bytes of the values in the queues, zero without a Budget.
+func (m *Manager) Used() int64 {
+	if m.budget == nil {
+		return 0
+	}
+	m.budget.mutex.Lock()
+	defer m.budget.mutex.Unlock()
+	var n int64
+	for a := range m.budget.accounts {
+		n += a.used
+	}
+	return n
+}
+
+// Return the sorted names of the queues.
+func (m *Manager) Names() []string {
+	m.mutex.RLock()
+	names := make([]string, 0, len(m.queues))
+	for name := range m.queues {
+		names = append(names, name)
+	}
+	m.mutex.RUnlock()
+	sort.Strings(names)
+	return names
+}
+
+// Return the number of queues.
+func (m *Manager) Len() int {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return len(m.queues)
+}
+
+// Stats returns the Stats of every Queue by name.
+func (m *Manager) Stats() map[string]Stats {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	stats := make(map[string]Stats, len(m.queues))
+	for name, e := range m.queues {
+		stats[name] = e.queue.Stats()
+	}
+	return stats
+}
+
+// Total returns the sum of the Stats of the queues, HighWater included.
+func (m *Manager) Total() Stats {
+	var total Stats
+	for _, s := range m.Stats() {
+		total.Size += s.Size
+		total.HighWater += s.HighWater
+		total.Putters += s.Putters
+		total.Getters += s.Getters
+		total.Puts += s.Puts
+		total.Gets += s.Gets
+		total.PutTimeouts += s.PutTimeouts
+		total.GetTimeouts += s.GetTimeouts
+		total.Rejected += s.Rejected
+		total.Dropped += s.Dropped
+	}
+	return total
+}
@@ -0,0 +1,136 @@
+package goqueue
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestManager(t *testing.T) {
+	m := NewManager(Config{MaxSize: 2})
+
+	fmt.Println("Test Create, Open and Queue...")
+	q, err := m.Create("jobs", Config{MaxSize: 1, Options: []Option{WithFullPolicy(DropOldest)}})
+	if err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	if _, err := m.Create("jobs", Config{}); err != ErrQueueExists {
+		t.Fatalf("Expect ErrQueueExists, got %v\n", err)
+	}
+	if got := m.Open("jobs"); got != q {
+		t.Fatalf("Expect Open to return the created queue\n")
+	}
+	q.PutNoWait(1)
+	if err := q.PutNoWait(2); err != nil || q.Size() != 1 {
+		t.Fatalf("Expect the configured policy, got %v with size %d\n", err, q.Size())
+	}
+	events := m.Open("events")
+	if events.MaxSize() != 2 {
+		t.Fatalf("Expect the default max size 2, got %d\n", events.MaxSize())
+	}
+	if c, err := m.Config("events"); err != nil || c.MaxSize != 2 {
+		t.Fatalf("Expect the default config, got %+v (%v)\n", c, err)
+	}
+	if _, ok := m.Queue("missing"); ok {
+		t.Fatalf("Expect no queue named missing\n")
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test Names, Stats and Total...")
+	if names := m.Names(); len(names) != 2 || names[0] != "events" || names[1] != "jobs" {
+		t.Fatalf("Expect [events jobs], got %v\n", names)
+	}
+	events.PutNoWait("a")
+	events.PutNoWait("b")
+	stats := m.Stats()
+	if stats["jobs"].Size != 1 || stats["events"].Size != 2 {
+		t.Fatalf("Expect sizes 1 and 2, got %+v\n", stats)
+	}
+	if total := m.Total(); total.Size != 3 || total.Puts != 4 {
+		t.Fatalf("Expect 3 values and 4 puts, got %+v\n", total)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test Delete...")
+	if got, err := m.Delete("events"); err != nil || got != events {
+		t.Fatalf("Expect the deleted queue, got %v\n", err)
+	}
+	if _, err := m.Delete("events"); err != ErrNoQueue {
+		t.Fatalf("Expect ErrNoQueue, got %v\n", err)
+	}
+	if _, err := m.Config("events"); err != ErrNoQueue {
+		t.Fatalf("Expect ErrNoQueue, got %v\n", err)
+	}
+	if m.Len() != 1 {
+		t.Fatalf("Expect 1 queue, got %d\n", m.Len())
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test Open from many goroutines...")
+	queues := make([]*Queue, 10)
+	wg := &sync.WaitGroup{}
+	for i := range queues {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			queues[i] = m.Open("shared")
+		}(i)
+	}
+	wg.Wait()
+	for _, q := range queues {
+		if q != queues[0] {
+			t.Fatalf("Expect a single shared queue\n")
+		}
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestManagerBudget(t *testing.T) {
+	sizeOf := func(val interface{}) int64 { return int64(len(val.(string))) }
+	m := NewBudgetManager(Config{}, Budget{Bytes: 10, SizeOf: sizeOf})
+	a, _ := m.Create("a", Config{Reserve: 4})
+	b := m.Open("b")
+
+	fmt.Println("Test a Budget rejects Puts over it, but keeps the reservations...")
+	if err := b.PutNoWait("bbbbbb"); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	if err := b.PutNoWait("b"); err != ErrOverBudget {
+		t.Fatalf("Expect %v, got %v\n", ErrOverBudget, err)
+	}
+	if err := a.PutNoWait("aaaa"); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	if n, err := a.PutAll([]interface{}{"a"}); n != 0 || err != ErrOverBudget {
+		t.Fatalf("Expect %v, got %d values put (%v)\n", ErrOverBudget, n, err)
+	}
+	if m.Used() != 10 || b.Stats().Rejected != 1 {
+		t.Fatalf("Expect 10 bytes used and 1 rejected, got %d and %d\n", m.Used(), b.Stats().Rejected)
+	}
+	b.GetNoWait()
+	if err := a.PutNoWait("a"); err != nil || m.Used() != 5 {
+		t.Fatalf("Expect 5 bytes used, got %d (%v)\n", m.Used(), err)
+	}
+	m.Delete("a")
+	if m.Used() != 0 {
+		t.Fatalf("Expect 0 bytes used, got %d\n", m.Used())
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test a DropOldest Budget evicts from the largest queue...")
+	m = NewBudgetManager(Config{}, Budget{Bytes: 6, SizeOf: sizeOf, Policy: DropOldest})
+	small, large := m.Open("small"), m.Open("large")
+	small.PutNoWait("s")
+	large.PutNoWait("ll")
+	large.PutNoWait("LLL")
+	if err := small.PutNoWait("ss"); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	if val, _ := large.Peek(); large.Size() != 1 || val.(string) != "LLL" {
+		t.Fatalf("Expect the oldest value of large evicted, got %v\n", large.ToSlice())
+	}
+	if small.Size() != 2 || m.Used() != 6 {
+		t.Fatalf("Expect 6 bytes used, got %d\n", m.Used())
+	}
+	fmt.Println("  ...PASSED")
+}
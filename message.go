@@ -0,0 +1,75 @@
+package goqueue
+
+import (
+	"crypto/rand"
+	"encoding/gob"
+	"encoding/hex"
+	"time"
+)
+
+func init() {
+	gob.Register(&Message{})
+}
+
+// Message is an envelope carrying metadata with a value, e.g. its source,
+// trace IDs or retry count. It is encodable by GobCodec as long as Body is,
+// so it travels through the durable backends too.
+type Message struct {
+	ID        string
+	Timestamp time.Time
+	Headers   map[string]string
+	Body      interface{}
+}
+
+// NewMessage create a Message of body with a random ID, the current time
+// and no header.
+func NewMessage(body interface{}) *Message {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return &Message{
+		ID:        hex.EncodeToString(b),
+		Timestamp: time.Now(),
+		Headers:   make(map[string]string),
+		Body:      body,
+	}
+}
+
+// Header returns the value of the header key, or "".
+func (m *Message) Header(key string) string {
+	return m.Headers[key]
+}
+
+// SetHeader sets the header key to value.
+func (m *Message) SetHeader(key, value string) {
+	if m.Headers == nil {
+		m.Headers = make(map[string]string)
+	}
+	m.Headers[key] = value
+}
+
+// PutMessage puts a new Message of body with headers into q, and returns
+// it. The timeout semantics are the same as Queue.Put.
+func PutMessage(q Putter, body interface{}, headers map[string]string, timeout float64) (*Message, error) {
+	m := NewMessage(body)
+	for k, v := range headers {
+		m.Headers[k] = v
+	}
+	if err := q.Put(m, timeout); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// GetMessage gets a Message from q, a value which is not a *Message is
+// returned as the Body of a Message without ID. The timeout semantics are
+// the same as Queue.Get.
+func GetMessage(q Getter, timeout float64) (*Message, error) {
+	val, err := q.Get(timeout)
+	if err != nil {
+		return nil, err
+	}
+	if m, ok := val.(*Message); ok {
+		return m, nil
+	}
+	return &Message{Headers: make(map[string]string), Body: val}, nil
+}
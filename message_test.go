@@ -0,0 +1,43 @@
+package goqueue
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestMessage(t *testing.T) {
+	queue := New(0)
+
+	fmt.Println("Test the headers travel with the body...")
+	put, err := PutMessage(queue, "hello", map[string]string{"source": "test"}, -1)
+	if err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	m, err := GetMessage(queue, -1)
+	if err != nil || m != put || m.Body.(string) != "hello" || m.Header("source") != "test" {
+		t.Fatalf("Expect the message put, got %+v (%v)\n", m, err)
+	}
+	if len(m.ID) != 32 || time.Since(m.Timestamp) > time.Second {
+		t.Fatalf("Expect a random ID and the current time, got %+v\n", m)
+	}
+	if other := NewMessage(nil); other.ID == m.ID {
+		t.Fatalf("Expect different IDs\n")
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test a plain value is wrapped...")
+	queue.PutNoWait(1)
+	m, _ = GetMessage(queue, -1)
+	if m.ID != "" || m.Body.(int) != 1 {
+		t.Fatalf("Expect 1 without ID, got %+v\n", m)
+	}
+	m.SetHeader("retry", "1")
+	if m.Header("retry") != "1" {
+		t.Fatalf("Expect the header set\n")
+	}
+	if _, err := GetMessage(queue, -1); err != ErrEmptyQueue {
+		t.Fatalf("Expect %v, got %v\n", ErrEmptyQueue, err)
+	}
+	fmt.Println("  ...PASSED")
+}
@@ -0,0 +1,81 @@
+package goqueue
+
+// PutFunc is the Put of a queue, as seen by a Middleware.
+type PutFunc func(val interface{}, timeout float64) error
+
+// GetFunc is the Get of a queue, as seen by a Middleware.
+type GetFunc func(timeout float64) (interface{}, error)
+
+// Middleware wraps the Put and Get of a queue, like http middleware wraps
+// a handler, e.g. to validate, enrich or encrypt values. A nil field
+// leaves the operation alone.
+type Middleware struct {
+	Put func(next PutFunc) PutFunc
+	Get func(next GetFunc) GetFunc
+}
+
+// Intercepted is a queue whose Put and Get go through a chain of
+// Middleware, see Intercept.
+type Intercepted struct {
+	queue Interface
+	put   PutFunc
+	get   GetFunc
+}
+
+// Intercept wraps q with mws, the first one is the outermost: it sees the
+// value put first and the value got last.
+func Intercept(q Interface, mws ...Middleware) *Intercepted {
+	i := &Intercepted{queue: q, put: q.Put, get: q.Get}
+	for n := len(mws) - 1; n >= 0; n-- {
+		if mws[n].Put != nil {
+			i.put = mws[n].Put(i.put)
+		}
+		if mws[n].Get != nil {
+			i.get = mws[n].Get(i.get)
+		}
+	}
+	return i
+}
+
+// Same as Get(-1).
+func (i *Intercepted) GetNoWait() (interface{}, error) {
+	return i.Get(-1)
+}
+
+// Get through the chain, the timeout semantics are the same as Queue.Get.
+func (i *Intercepted) Get(timeout float64) (interface{}, error) {
+	return i.get(timeout)
+}
+
+// Same as Put(val, -1).
+func (i *Intercepted) PutNoWait(val interface{}) error {
+	return i.Put(val, -1)
+}
+
+// Put through the chain, the timeout semantics are the same as Queue.Put.
+func (i *Intercepted) Put(val interface{}, timeout float64) error {
+	return i.put(val, timeout)
+}
+
+// Return size of the queue.
+func (i *Intercepted) Size() int {
+	return i.queue.Size()
+}
+
+// Return true if the queue is empty.
+func (i *Intercepted) IsEmpty() bool {
+	return i.queue.IsEmpty()
+}
+
+// Return true if the queue is full.
+func (i *Intercepted) IsFull() bool {
+	return i.queue.IsFull()
+}
+
+// Close closes the wrapped queue if it is a Closer.
+func (i *Intercepted) Close() error {
+	if c, ok := i.queue.(Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
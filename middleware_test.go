@@ -0,0 +1,85 @@
+package goqueue
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+var (
+	_ Interface = (*Intercepted)(nil)
+	_ Closer    = (*Intercepted)(nil)
+)
+
+func TestIntercept(t *testing.T) {
+	var trace []string
+	tag := func(name string) Middleware {
+		return Middleware{
+			Put: func(next PutFunc) PutFunc {
+				return func(val interface{}, timeout float64) error {
+					trace = append(trace, "put "+name)
+					return next(val.(string)+"+"+name, timeout)
+				}
+			},
+			Get: func(next GetFunc) GetFunc {
+				return func(timeout float64) (interface{}, error) {
+					val, err := next(timeout)
+					trace = append(trace, "get "+name)
+					return val, err
+				}
+			},
+		}
+	}
+	errEmpty := errors.New("empty value")
+	validate := Middleware{Put: func(next PutFunc) PutFunc {
+		return func(val interface{}, timeout float64) error {
+			if val.(string) == "" {
+				return errEmpty
+			}
+			return next(val, timeout)
+		}
+	}}
+	queue := Intercept(New(0), validate, tag("a"), tag("b"))
+
+	fmt.Println("Test the chain runs in order...")
+	if err := queue.PutNoWait("v"); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	val, err := queue.GetNoWait()
+	if err != nil || val.(string) != "v+a+b" {
+		t.Fatalf("Expect v+a+b, got %v (%v)\n", val, err)
+	}
+	if got := strings.Join(trace, ","); got != "put a,put b,get b,get a" {
+		t.Fatalf("Expect put a,put b,get b,get a, got %s\n", got)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test a middleware can refuse a value...")
+	if err := queue.PutNoWait(""); err != errEmpty || !queue.IsEmpty() {
+		t.Fatalf("Expect %v, got %v\n", errEmpty, err)
+	}
+	fmt.Println("  ...PASSED")
+}
+
+type closingQueue struct {
+	*Queue
+	closed bool
+}
+
+func (q *closingQueue) Close() error {
+	q.closed = true
+	return nil
+}
+
+func TestInterceptClose(t *testing.T) {
+	fmt.Println("Test Close is passed to a wrapped Closer...")
+	if err := Intercept(New(0)).Close(); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	q := &closingQueue{Queue: New(0)}
+	if err := Intercept(q).Close(); err != nil || !q.closed {
+		t.Fatalf("Expect the wrapped queue closed, got %v\n", err)
+	}
+	fmt.Println("  ...PASSED")
+}
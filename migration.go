@@ -0,0 +1,213 @@
+package goqueue
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+)
+
+// UndeliveredError is returned by Migration.Run when a value could neither
+// be put into dst nor put back into src, the value is only kept here.
+type UndeliveredError struct {
+	Value interface{}
+	Err   error
+}
+
+func (e *UndeliveredError) Error() string {
+	return fmt.Sprintf("undelivered value: %v", e.Err)
+}
+
+// Migration moves the backlog of a queue to another one, e.g. from a Queue
+// to a sqlqueue.Queue when changing backends. It keeps its progress, so Run
+// can be called again after an error to resume.
+type Migration struct {
+	src   Getter
+	dst   Putter
+	moved int
+	seen  memoryCheckpoint
+
+	// Timeout of each Put into dst, with the semantics of Queue.Put,
+	// default is 0 (block until it fits).
+	Timeout float64
+	// Progress is called, if not nil, with the number of values moved so
+	// far after each one.
+	Progress func(moved int)
+	// ID, if not nil, identifies the values: a value whose ID was moved
+	// already, e.g. redelivered by src, is dropped instead of moved again.
+	ID func(val interface{}) string
+	// Checkpoint keeps the moved IDs, default is in memory for the life of
+	// the Migration. Set a FileCheckpoint to resume a Migration restarted
+	// after a crash without moving the values twice.
+	Checkpoint Checkpoint
+}
+
+// NewMigration create a Migration from src to dst.
+func NewMigration(src Getter, dst Putter) *Migration {
+	return &Migration{src: src, dst: dst, seen: make(memoryCheckpoint)}
+}
+
+func (m *Migration) checkpoint() Checkpoint {
+	if m.Checkpoint != nil {
+		return m.Checkpoint
+	}
+	return m.seen
+}
+
+// frontPutter is implemented by the sources which can take a value back at
+// the front, as Queue.PutFront does.
+type frontPutter interface {
+	PutFront(val interface{}, timeout float64) error
+}
+
+// Run moves values one by one from src to dst until src is empty, and
+// returns the number of values moved by this call.
+//
+// A value which doesn't make it into dst is put back into src, at the
+// front if src has PutFront so the order is kept, and the Put error is
+// returned. A crash between the Get and the Put loses the value in flight
+// unless src redelivers it, set ID to drop the duplicates then. A crash
+// between the Put and the Checkpoint moves the value in flight twice.
+func (m *Migration) Run() (int, error) {
+	moved := 0
+	for {
+		val, err := m.src.Get(-1)
+		if err == ErrEmptyQueue {
+			return moved, nil
+		} else if err != nil {
+			return moved, err
+		}
+		var id string
+		if m.ID != nil {
+			id = m.ID(val)
+			if seen, err := m.checkpoint().Seen(id); err != nil {
+				return moved, m.putBack(val, err)
+			} else if seen {
+				continue
+			}
+		}
+		if err := m.dst.Put(val, m.Timeout); err != nil {
+			return moved, m.putBack(val, err)
+		}
+		if m.ID != nil {
+			// The value is in dst already, it is not put back.
+			if err := m.checkpoint().Done(id); err != nil {
+				return moved, err
+			}
+		}
+		moved++
+		m.moved++
+		if m.Progress != nil {
+			m.Progress(m.moved)
+		}
+	}
+}
+
+func (m *Migration) putBack(val interface{}, err error) error {
+	var perr error
+	if p, ok := m.src.(frontPutter); ok {
+		perr = p.PutFront(val, -1)
+	} else if p, ok := m.src.(Putter); ok {
+		perr = p.Put(val, -1)
+	} else {
+		perr = err
+	}
+	if perr != nil {
+		return &UndeliveredError{Value: val, Err: err}
+	}
+	return err
+}
+
+// Return the number of values moved by every Run.
+func (m *Migration) Moved() int {
+	return m.moved
+}
+
+// Checkpoint keeps the IDs of the values moved by a Migration.
+type Checkpoint interface {
+	// Seen reports whether the value id was moved already.
+	Seen(id string) (bool, error)
+	// Done records the value id once it is in dst.
+	Done(id string) error
+}
+
+type memoryCheckpoint map[string]bool
+
+func (c memoryCheckpoint) Seen(id string) (bool, error) {
+	return c[id], nil
+}
+
+func (c memoryCheckpoint) Done(id string) error {
+	c[id] = true
+	return nil
+}
+
+// FileCheckpoint is a Checkpoint kept in a file next to the backends, one
+// quoted ID per line. Each ID is synced to disk before Done returns.
+type FileCheckpoint struct {
+	f    *os.File
+	seen map[string]bool
+}
+
+// OpenFileCheckpoint opens the FileCheckpoint at path, creating it if it
+// doesn't exist, with the IDs recorded by earlier runs. A line torn by a
+// crash is discarded.
+func OpenFileCheckpoint(path string) (*FileCheckpoint, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	data = data[:bytes.LastIndexByte(data, '\n')+1]
+	c := &FileCheckpoint{seen: make(map[string]bool)}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		id, err := strconv.Unquote(scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("bad checkpoint line %q: %v", scanner.Text(), err)
+		}
+		c.seen[id] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if c.f, err = os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644); err != nil {
+		return nil, err
+	}
+	if err := c.f.Truncate(int64(len(data))); err != nil {
+		c.f.Close()
+		return nil, err
+	}
+	if _, err := c.f.Seek(0, io.SeekEnd); err != nil {
+		c.f.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *FileCheckpoint) Seen(id string) (bool, error) {
+	return c.seen[id], nil
+}
+
+func (c *FileCheckpoint) Done(id string) error {
+	if _, err := c.f.WriteString(strconv.Quote(id) + "\n"); err != nil {
+		return err
+	}
+	if err := c.f.Sync(); err != nil {
+		return err
+	}
+	c.seen[id] = true
+	return nil
+}
+
+// Return the number of IDs recorded.
+func (c *FileCheckpoint) Len() int {
+	return len(c.seen)
+}
+
+// Close the file of FileCheckpoint.
+func (c *FileCheckpoint) Close() error {
+	return c.f.Close()
+}
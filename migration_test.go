@@ -0,0 +1,126 @@
+package goqueue
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMigration(t *testing.T) {
+	src, dst := New(0), New(3)
+	for i := 0; i < 5; i++ {
+		src.PutNoWait(i)
+	}
+
+	fmt.Println("Test Run stops at a full dst and puts the value back in front...")
+	reported := 0
+	m := NewMigration(src, dst)
+	m.Timeout = -1
+	m.Progress = func(n int) { reported = n }
+	moved, err := m.Run()
+	if err != ErrFullQueue || moved != 3 || reported != 3 {
+		t.Fatalf("Expect 3 moved and %v, got %d (%v), reported %d\n", ErrFullQueue, moved, err, reported)
+	}
+	if src.Size() != 2 {
+		t.Fatalf("Expect 2 values left in src, got %d\n", src.Size())
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test calling Run again resumes in order...")
+	dst.Reconfigure(WithMaxSize(0))
+	if moved, err := m.Run(); err != nil || moved != 2 || m.Moved() != 5 || reported != 5 {
+		t.Fatalf("Expect 2 moved, got %d (%v), %d in all\n", moved, err, m.Moved())
+	}
+	for _, expect := range []int{0, 1, 2, 3, 4} {
+		if val, _ := dst.GetNoWait(); val.(int) != expect {
+			t.Fatalf("Expect %v, got %v\n", expect, val)
+		}
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test ID drops the values moved already...")
+	m.ID = func(val interface{}) string { return fmt.Sprint(val) }
+	for _, val := range []int{5, 6, 5, 6, 7} {
+		src.PutNoWait(val)
+	}
+	if moved, err := m.Run(); err != nil || moved != 3 || dst.Size() != 3 {
+		t.Fatalf("Expect 3 moved, got %d (%v) with %d in dst\n", moved, err, dst.Size())
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test a value which can't be put back is returned...")
+	full := New(1)
+	full.PutNoWait("x")
+	if _, err := NewMigration(getterOnly{New(0)}, full).Run(); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	one := New(0)
+	one.PutNoWait("y")
+	m = NewMigration(getterOnly{one}, full)
+	m.Timeout = -1
+	_, err = m.Run()
+	if ue, ok := err.(*UndeliveredError); !ok || ue.Value.(string) != "y" || ue.Err != ErrFullQueue {
+		t.Fatalf("Expect UndeliveredError, got %v\n", err)
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestFileCheckpoint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkpoint")
+	if err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "migration.checkpoint")
+	id := func(val interface{}) string { return fmt.Sprint(val) }
+
+	fmt.Println("Test a restarted Migration doesn't move the checkpointed values again...")
+	c, err := OpenFileCheckpoint(path)
+	if err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	src, dst := New(0), New(0)
+	for _, val := range []string{"a", "b\nc", "d"} {
+		src.PutNoWait(val)
+	}
+	m := NewMigration(src, dst)
+	m.ID, m.Checkpoint = id, c
+	if moved, err := m.Run(); err != nil || moved != 3 {
+		t.Fatalf("Expect 3 moved, got %d (%v)\n", moved, err)
+	}
+	c.Close()
+
+	// A crash tore the last line.
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	f.WriteString(`"e`)
+	f.Close()
+	if c, err = OpenFileCheckpoint(path); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	defer c.Close()
+	if c.Len() != 3 {
+		t.Fatalf("Expect 3 IDs, got %d\n", c.Len())
+	}
+	for _, val := range []string{"b\nc", "e", "d"} {
+		src.PutNoWait(val)
+	}
+	m = NewMigration(src, dst)
+	m.ID, m.Checkpoint = id, c
+	if moved, err := m.Run(); err != nil || moved != 1 || dst.Size() != 4 {
+		t.Fatalf("Expect 1 moved, got %d (%v) with %d in dst\n", moved, err, dst.Size())
+	}
+	if seen, _ := c.Seen("e"); !seen {
+		t.Fatalf("Expect e checkpointed\n")
+	}
+	fmt.Println("  ...PASSED")
+}
+
+type getterOnly struct {
+	q *Queue
+}
+
+func (g getterOnly) Get(timeout float64) (interface{}, error) {
+	return g.q.Get(timeout)
+}
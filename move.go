@@ -0,0 +1,81 @@
+package goqueue
+
+import (
+	"time"
+	"unsafe"
+)
+
+// movePoll is how often Move retries while nothing can be moved.
+const movePoll = 10 * time.Millisecond
+
+// Move transfers up to n values from the front of src to the back of dst in
+// queue order, no Get can see a value in between. It returns the number of
+// values moved, which is limited by the free slots of dst.
+//
+// * If timeout less than 0, return (0, ErrEmptyQueue) if src is empty or
+// (0, ErrFullQueue) if dst is full.
+//
+// * If timeout equals to 0, block until at least one value is moved.
+//
+// * If timeout greater than 0, wait timeout seconds until at least one value
+// is moved, then return the error as above.
+//
+// Waiting polls both queues every 10 milliseconds.
+func Move(src, dst *Queue, n int, timeout float64) (int, error) {
+	if src == dst || n <= 0 {
+		return 0, nil
+	}
+	var deadline time.Time
+	if timeout > 0.0 {
+		deadline = time.Now().Add(seconds(timeout))
+	}
+	for {
+		moved, err := move(src, dst, n)
+		if moved > 0 || timeout < 0.0 {
+			return moved, err
+		}
+		if timeout > 0.0 && !time.Now().Before(deadline) {
+			return 0, err
+		}
+		time.Sleep(movePoll)
+	}
+}
+
+func move(src, dst *Queue, n int) (int, error) {
+	// Lock by address so concurrent opposite moves can't deadlock.
+	first, second := src, dst
+	if uintptr(unsafe.Pointer(dst)) < uintptr(unsafe.Pointer(src)) {
+		first, second = dst, src
+	}
+	first.mutex.Lock()
+	second.mutex.Lock()
+	defer func() {
+		events := append(src.events, dst.events...)
+		src.events, dst.events = nil, nil
+		src.stats.block(src.putters.Len(), src.getters.Len())
+		dst.stats.block(dst.putters.Len(), dst.getters.Len())
+		second.mutex.Unlock()
+		first.mutex.Unlock()
+		runHooks(events)
+	}()
+
+	src.clearPending()
+	dst.clearPending()
+	moved := 0
+	for ; moved < n && !src.isempty() && !dst.isfull(); moved++ {
+		// src.get gives the bytes back to the Budget of src, a value which
+		// stays in dst is charged there, forced as the slot is taken already.
+		if val := src.get(); !dst.notifyGetter(val) {
+			dst.charge(val, true)
+			dst.put(val)
+		}
+	}
+	src.finish(moved)
+	src.clearPending()
+	if moved > 0 {
+		return moved, nil
+	} else if src.isempty() {
+		return 0, ErrEmptyQueue
+	}
+	return 0, ErrFullQueue
+}
@@ -0,0 +1,121 @@
+package goqueue
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestMove(t *testing.T) {
+	src, dst := New(0), New(3)
+	for i := 0; i < 5; i++ {
+		src.PutNoWait(i)
+	}
+	dst.PutNoWait(-1)
+
+	fmt.Println("Test Move keeps order and respects dst capacity...")
+	if moved, err := Move(src, dst, 4, -1); err != nil || moved != 2 {
+		t.Fatalf("Expect 2 moved, got %d (%v)\n", moved, err)
+	}
+	for _, expect := range []int{-1, 0, 1} {
+		if val, _ := dst.GetNoWait(); val.(int) != expect {
+			t.Fatalf("Expect %v, got %v\n", expect, val)
+		}
+	}
+	if src.Size() != 3 {
+		t.Fatalf("Expect 3 values left in src, got %d\n", src.Size())
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test Move reports empty src and full dst...")
+	if _, err := Move(New(0), dst, 1, -1); err != ErrEmptyQueue {
+		t.Fatalf("Expect %v, got %v\n", ErrEmptyQueue, err)
+	}
+	for i := 0; i < 3; i++ {
+		dst.PutNoWait(i)
+	}
+	if _, err := Move(src, dst, 1, 0.05); err != ErrFullQueue {
+		t.Fatalf("Expect %v, got %v\n", ErrFullQueue, err)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test Move hands values to blocked getters of dst...")
+	empty := New(1)
+	done := make(chan interface{})
+	go func() {
+		val, _ := empty.Get(0)
+		done <- val
+	}()
+	if moved, err := Move(src, empty, 1, 0); err != nil || moved != 1 {
+		t.Fatalf("Expect 1 moved, got %d (%v)\n", moved, err)
+	}
+	if val := <-done; val.(int) != 2 {
+		t.Fatalf("Expect %v, got %v\n", 2, val)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test opposite concurrent moves don't deadlock...")
+	a, b := New(0), New(0)
+	for i := 0; i < 100; i++ {
+		a.PutNoWait(i)
+		b.PutNoWait(i)
+	}
+	finished := make(chan bool)
+	go func() {
+		for i := 0; i < 1000; i++ {
+			Move(a, b, 1, -1)
+		}
+		finished <- true
+	}()
+	for i := 0; i < 1000; i++ {
+		Move(b, a, 1, -1)
+	}
+	<-finished
+	if a.Size()+b.Size() != 200 {
+		t.Fatalf("Expect 200 values in total, got %d\n", a.Size()+b.Size())
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestMoveBudget(t *testing.T) {
+	sizeOf := func(val interface{}) int64 { return int64(len(val.(string))) }
+	m := NewBudgetManager(Config{}, Budget{Bytes: 10, SizeOf: sizeOf})
+	src, dst := m.Open("src"), m.Open("dst")
+
+	fmt.Println("Test Move keeps the moved values in the Budget...")
+	src.PutNoWait("aaaa")
+	src.PutNoWait("bb")
+	if moved, err := Move(src, dst, 2, -1); err != nil || moved != 2 {
+		t.Fatalf("Expect 2 moved, got %d (%v)\n", moved, err)
+	}
+	if m.Used() != 6 {
+		t.Fatalf("Expect 6 bytes used, got %d\n", m.Used())
+	}
+	if err := src.PutNoWait("ccccc"); err != ErrOverBudget {
+		t.Fatalf("Expect %v, got %v\n", ErrOverBudget, err)
+	}
+	dst.GetNoWait()
+	dst.GetNoWait()
+	if m.Used() != 0 {
+		t.Fatalf("Expect 0 bytes used, got %d\n", m.Used())
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test a value Move hands to a Get of dst isn't charged...")
+	src.PutNoWait("aaaa")
+	got := make(chan interface{})
+	go func() {
+		val, _ := dst.Get(0)
+		got <- val
+	}()
+	for dst.Stats().Getters != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	if moved, _ := Move(src, dst, 1, -1); moved != 1 || (<-got).(string) != "aaaa" {
+		t.Fatalf("Expect aaaa moved to the Get\n")
+	}
+	if m.Used() != 0 {
+		t.Fatalf("Expect 0 bytes used, got %d\n", m.Used())
+	}
+	fmt.Println("  ...PASSED")
+}
@@ -0,0 +1,195 @@
+/*
+Package outbox implements the transactional outbox pattern on top of goqueue.
+
+Producers write messages into an outbox table inside their own database/sql
+transaction, so the message exists if and only if the business change was
+committed. A Relay then moves committed rows into a goqueue.Queue and deletes
+them, skipping any row it has already enqueued, so each message is put into
+the Queue exactly once even if a delete fails and the row is read again.
+
+The outbox table must have the following columns (types may be adjusted for
+the database in use):
+
+	CREATE TABLE outbox (
+		id      VARCHAR(255) PRIMARY KEY,
+		payload BLOB NOT NULL,
+		created BIGINT NOT NULL
+	)
+*/
+
+package outbox
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/damnever/goqueue"
+)
+
+// Message is the value a Relay puts into the Queue.
+type Message struct {
+	ID      string
+	Payload []byte
+}
+
+// Relay moves rows from an outbox table into a Queue.
+type Relay struct {
+	db    *sql.DB
+	table string
+	queue *goqueue.Queue
+	mutex sync.Mutex
+	seen  map[string]struct{} // enqueued, but the row is not deleted yet
+
+	// BatchSize is the max number of rows moved by one Poll, default is 100.
+	BatchSize int
+	// Placeholder returns the bind variable for the n-th (1-based) argument,
+	// default is "?", use DollarPlaceholder for PostgreSQL.
+	Placeholder func(n int) string
+	// PutTimeout is passed to Queue.Put, default is -1 (no wait). When the
+	// Queue is full, the remaining rows are left for the next Poll.
+	PutTimeout float64
+}
+
+// DollarPlaceholder returns PostgreSQL style bind variables: $1, $2, ...
+func DollarPlaceholder(n int) string {
+	return fmt.Sprintf("$%d", n)
+}
+
+func questionPlaceholder(n int) string {
+	return "?"
+}
+
+// NewRelay create a Relay which reads from table and puts into queue.
+func NewRelay(db *sql.DB, table string, queue *goqueue.Queue) *Relay {
+	return &Relay{
+		db:          db,
+		table:       table,
+		queue:       queue,
+		seen:        make(map[string]struct{}),
+		BatchSize:   100,
+		Placeholder: questionPlaceholder,
+		PutTimeout:  -1,
+	}
+}
+
+// Write inserts a message into the outbox table within tx, the message will
+// be visible to the Relay only after tx is committed.
+func (r *Relay) Write(tx *sql.Tx, id string, payload []byte) error {
+	query := fmt.Sprintf("INSERT INTO %s (id, payload, created) VALUES (%s, %s, %s)",
+		r.table, r.Placeholder(1), r.Placeholder(2), r.Placeholder(3))
+	_, err := tx.Exec(query, id, payload, time.Now().UnixNano())
+	return err
+}
+
+// Poll moves at most BatchSize committed rows into the Queue, returns the
+// number of rows removed from the outbox table.
+func (r *Relay) Poll() (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	msgs, err := r.fetch(tx)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	done := make([]string, 0, len(msgs))
+	for _, msg := range msgs {
+		if _, ok := r.seen[msg.ID]; !ok {
+			if err := r.queue.Put(msg, r.PutTimeout); err != nil {
+				break
+			}
+			r.seen[msg.ID] = struct{}{}
+		}
+		done = append(done, msg.ID)
+	}
+	if len(done) == 0 {
+		tx.Rollback()
+		return 0, nil
+	}
+
+	if err := r.delete(tx, done); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	for _, id := range done {
+		delete(r.seen, id)
+	}
+	return len(done), nil
+}
+
+// Run calls Poll until stop is closed, it sleeps interval seconds whenever
+// the outbox table is drained. The first error returned by Poll stops Run.
+func (r *Relay) Run(interval float64, stop <-chan struct{}) error {
+	d := time.Duration(interval * float64(time.Second))
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	for {
+		n, err := r.Poll()
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			select {
+			case <-stop:
+				return nil
+			default:
+			}
+			continue
+		}
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(d)
+		select {
+		case <-stop:
+			return nil
+		case <-timer.C:
+		}
+	}
+}
+
+func (r *Relay) fetch(tx *sql.Tx) ([]Message, error) {
+	query := fmt.Sprintf("SELECT id, payload FROM %s ORDER BY created, id LIMIT %d",
+		r.table, r.BatchSize)
+	rows, err := tx.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	msgs := make([]Message, 0)
+	for rows.Next() {
+		var msg Message
+		if err := rows.Scan(&msg.ID, &msg.Payload); err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs, rows.Err()
+}
+
+func (r *Relay) delete(tx *sql.Tx, ids []string) error {
+	args := make([]interface{}, len(ids))
+	marks := make([]string, len(ids))
+	for i, id := range ids {
+		args[i] = id
+		marks[i] = r.Placeholder(i + 1)
+	}
+	query := fmt.Sprintf("DELETE FROM %s WHERE id IN (%s)", r.table, strings.Join(marks, ", "))
+	_, err := tx.Exec(query, args...)
+	return err
+}
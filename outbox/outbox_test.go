@@ -0,0 +1,294 @@
+package outbox
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/damnever/goqueue"
+)
+
+// A tiny in-memory driver which only understands the statements used by Relay.
+
+type fakeRow struct {
+	id      string
+	payload []byte
+	created int64
+}
+
+type fakeStore struct {
+	mutex      sync.Mutex
+	rows       []fakeRow
+	failCommit bool
+}
+
+var (
+	storesMu sync.Mutex
+	stores   = map[string]*fakeStore{}
+)
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	storesMu.Lock()
+	defer storesMu.Unlock()
+	s, ok := stores[name]
+	if !ok {
+		s = &fakeStore{}
+		stores[name] = s
+	}
+	return &fakeConn{store: s}, nil
+}
+
+type fakeConn struct {
+	store *fakeStore
+	tx    []fakeRow // rows seen by the running transaction
+	intx  bool
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{conn: c, query: query}, nil
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	c.store.mutex.Lock()
+	c.tx = append([]fakeRow(nil), c.store.rows...)
+	c.store.mutex.Unlock()
+	c.intx = true
+	return c, nil
+}
+
+func (c *fakeConn) Commit() error {
+	c.intx = false
+	c.store.mutex.Lock()
+	defer c.store.mutex.Unlock()
+	if c.store.failCommit {
+		return errors.New("commit failed")
+	}
+	c.store.rows = c.tx
+	return nil
+}
+
+func (c *fakeConn) Rollback() error {
+	c.intx = false
+	return nil
+}
+
+func (c *fakeConn) table() *[]fakeRow {
+	if c.intx {
+		return &c.tx
+	}
+	return &c.store.rows
+}
+
+type fakeStmt struct {
+	conn  *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.conn.store.mutex.Lock()
+	defer s.conn.store.mutex.Unlock()
+	rows := s.conn.table()
+	switch {
+	case strings.HasPrefix(s.query, "INSERT"):
+		*rows = append(*rows, fakeRow{
+			id:      args[0].(string),
+			payload: args[1].([]byte),
+			created: args[2].(int64),
+		})
+	case strings.HasPrefix(s.query, "DELETE"):
+		ids := map[string]bool{}
+		for _, arg := range args {
+			ids[arg.(string)] = true
+		}
+		kept := (*rows)[:0]
+		for _, row := range *rows {
+			if !ids[row.id] {
+				kept = append(kept, row)
+			}
+		}
+		*rows = kept
+	default:
+		return nil, fmt.Errorf("unsupported: %s", s.query)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.conn.store.mutex.Lock()
+	defer s.conn.store.mutex.Unlock()
+	fields := strings.Fields(s.query)
+	limit, err := strconv.Atoi(fields[len(fields)-1])
+	if err != nil {
+		return nil, err
+	}
+	rows := append([]fakeRow(nil), (*s.conn.table())...)
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].created != rows[j].created {
+			return rows[i].created < rows[j].created
+		}
+		return rows[i].id < rows[j].id
+	})
+	if len(rows) > limit {
+		rows = rows[:limit]
+	}
+	return &fakeRows{rows: rows}, nil
+}
+
+type fakeRows struct {
+	rows []fakeRow
+}
+
+func (r *fakeRows) Columns() []string { return []string{"id", "payload"} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	dest[0] = r.rows[0].id
+	dest[1] = r.rows[0].payload
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func init() {
+	sql.Register("outboxfake", fakeDriver{})
+}
+
+func openDB(t *testing.T, name string) (*sql.DB, *fakeStore) {
+	db, err := sql.Open("outboxfake", name)
+	if err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	db.SetMaxOpenConns(1)
+	db.Ping()
+	return db, stores[name]
+}
+
+func write(t *testing.T, relay *Relay, db *sql.DB, commit bool, ids ...string) {
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	for _, id := range ids {
+		if err := relay.Write(tx, id, []byte("payload-"+id)); err != nil {
+			t.Fatalf("Unexpect error: %v\n", err)
+		}
+	}
+	if commit {
+		tx.Commit()
+	} else {
+		tx.Rollback()
+	}
+}
+
+func TestRelayPoll(t *testing.T) {
+	db, store := openDB(t, "poll")
+	queue := goqueue.New(0)
+	relay := NewRelay(db, "outbox", queue)
+
+	fmt.Println("Test only committed messages are relayed...")
+	write(t, relay, db, true, "a", "b")
+	write(t, relay, db, false, "c")
+	n, err := relay.Poll()
+	if err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	} else if n != 2 {
+		t.Fatalf("Expect %d rows relayed, got %d\n", 2, n)
+	}
+	for _, id := range []string{"a", "b"} {
+		val, err := queue.GetNoWait()
+		if err != nil {
+			t.Fatalf("Unexpect error: %v\n", err)
+		}
+		msg := val.(Message)
+		if msg.ID != id || string(msg.Payload) != "payload-"+id {
+			t.Fatalf("Expect message %v, got %v\n", id, msg)
+		}
+	}
+	if !queue.IsEmpty() || len(store.rows) != 0 {
+		t.Fatalf("Expect queue and outbox to be empty, got %d and %d\n", queue.Size(), len(store.rows))
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test messages are not enqueued twice if delete failed...")
+	write(t, relay, db, true, "d")
+	store.failCommit = true
+	if _, err := relay.Poll(); err == nil {
+		t.Fatalf("Expect commit error, got nil\n")
+	}
+	store.failCommit = false
+	if n, err := relay.Poll(); err != nil || n != 1 {
+		t.Fatalf("Expect 1 row relayed, got %d (%v)\n", n, err)
+	}
+	if queue.Size() != 1 {
+		t.Fatalf("Expect Queue size %d, got %d\n", 1, queue.Size())
+	}
+	if len(relay.seen) != 0 {
+		t.Fatalf("Expect no pending ids, got %d\n", len(relay.seen))
+	}
+	queue.GetNoWait()
+	fmt.Println("  ...PASSED")
+}
+
+func TestRelayFullQueue(t *testing.T) {
+	db, store := openDB(t, "full")
+	queue := goqueue.New(2)
+	relay := NewRelay(db, "outbox", queue)
+
+	fmt.Println("Test rows are kept when Queue is full...")
+	write(t, relay, db, true, "a", "b", "c")
+	if n, err := relay.Poll(); err != nil || n != 2 {
+		t.Fatalf("Expect 2 rows relayed, got %d (%v)\n", n, err)
+	}
+	if len(store.rows) != 1 {
+		t.Fatalf("Expect 1 row left, got %d\n", len(store.rows))
+	}
+	if n, err := relay.Poll(); err != nil || n != 0 {
+		t.Fatalf("Expect 0 rows relayed, got %d (%v)\n", n, err)
+	}
+	queue.GetNoWait()
+	if n, err := relay.Poll(); err != nil || n != 1 {
+		t.Fatalf("Expect 1 row relayed, got %d (%v)\n", n, err)
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestRelayRun(t *testing.T) {
+	db, _ := openDB(t, "run")
+	queue := goqueue.New(0)
+	relay := NewRelay(db, "outbox", queue)
+	stop := make(chan struct{})
+	done := make(chan error, 1)
+
+	fmt.Println("Test Run relays messages until stopped...")
+	go func() {
+		done <- relay.Run(0.05, stop)
+	}()
+	write(t, relay, db, true, "a")
+	val, err := queue.Get(2)
+	if err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	} else if val.(Message).ID != "a" {
+		t.Fatalf("Expect %v, got %v\n", "a", val)
+	}
+	close(stop)
+	if err := <-done; err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	fmt.Println("  ...PASSED")
+}
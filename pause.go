@@ -0,0 +1,39 @@
+package goqueue
+
+import (
+	"errors"
+)
+
+// Queue is paused, see Pause.
+var ErrPaused = errors.New("queue is paused")
+
+// Pause stops handing values out: Get waits until Resume with its own
+// timeout semantics, and returns ErrPaused instead of waiting if timeout is
+// less than 0. Put is not affected. Drain, Clear and Move still take
+// values.
+func (q *Queue) Pause() {
+	q.mutex.Lock()
+	defer q.unlock()
+	q.paused = true
+}
+
+// Resume hands values out again, to the Get operators which waited first.
+func (q *Queue) Resume() {
+	q.mutex.Lock()
+	defer q.unlock()
+	if !q.paused {
+		return
+	}
+	q.paused = false
+	q.clearPending()
+	if !q.isempty() {
+		q.notifyWatchers()
+	}
+}
+
+// Return true if Queue is paused.
+func (q *Queue) IsPaused() bool {
+	q.mutex.Lock()
+	defer q.unlock()
+	return q.paused
+}
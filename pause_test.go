@@ -0,0 +1,92 @@
+package goqueue
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestPause(t *testing.T) {
+	queue := New(0)
+	queue.PutNoWait(1)
+	queue.Pause()
+
+	fmt.Println("Test Get while paused...")
+	if _, err := queue.GetNoWait(); err != ErrPaused {
+		t.Fatalf("Expect %v, got %v\n", ErrPaused, err)
+	}
+	if _, err := queue.Get(0.01); err != ErrEmptyQueue {
+		t.Fatalf("Expect %v, got %v\n", ErrEmptyQueue, err)
+	}
+	if vals, err := queue.GetN(2, -1); err != ErrPaused || len(vals) != 0 {
+		t.Fatalf("Expect %v, got %v (%v)\n", ErrPaused, vals, err)
+	}
+	if err := queue.PutNoWait(2); err != nil || queue.Size() != 2 {
+		t.Fatalf("Expect Put is not affected, got size %d (%v)\n", queue.Size(), err)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test Resume releases the blocked getters...")
+	got := make(chan interface{}, 1)
+	go func() {
+		val, _ := queue.Get(1)
+		got <- val
+	}()
+	time.Sleep(10 * time.Millisecond)
+	select {
+	case val := <-got:
+		t.Fatalf("Expect Get blocks while paused, got %v\n", val)
+	default:
+	}
+	queue.Resume()
+	if val := <-got; val.(int) != 1 || queue.IsPaused() {
+		t.Fatalf("Expect 1, got %v\n", val)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test Select is woken up by Resume...")
+	queue.Pause()
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		queue.Resume()
+	}()
+	if _, val, err := Select(1, queue); err != nil || val.(int) != 2 {
+		t.Fatalf("Expect 2, got %v (%v)\n", val, err)
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestResumeStats(t *testing.T) {
+	puts, gets := 0, 0
+	queue := New(0, WithHooks(Hooks{
+		OnPut: func(interface{}) { puts++ },
+		OnGet: func(interface{}) { gets++ },
+	}))
+	queue.Pause()
+
+	fmt.Println("Test a Get released by Resume counts its value once...")
+	got := make(chan interface{}, 1)
+	go func() {
+		val, _ := queue.Get(1)
+		got <- val
+	}()
+	for queue.Stats().Getters != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	queue.PutNoWait(1)
+	queue.Resume()
+	if val := <-got; val.(int) != 1 {
+		t.Fatalf("Expect 1, got %v\n", val)
+	}
+	if stats := queue.Stats(); stats.Puts != 1 || stats.Gets != 1 {
+		t.Fatalf("Expect 1 put and 1 get, got %+v\n", stats)
+	}
+	if puts != 1 || gets != 1 {
+		t.Fatalf("Expect OnPut and OnGet once, got %d and %d\n", puts, gets)
+	}
+	queue.TaskDone()
+	if err := queue.Join(-1); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	fmt.Println("  ...PASSED")
+}
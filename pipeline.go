@@ -0,0 +1,103 @@
+package goqueue
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// Pipeline moves the values of a source Queue through transform stages
+// into an output Queue, see NewPipeline.
+type Pipeline struct {
+	src     *Queue
+	out     *Queue
+	stages  []func(val interface{}) (interface{}, bool)
+	workers int
+}
+
+// NewPipeline create a Pipeline reading from src, stages are added with
+// Map and Filter, the output is set by To:
+//
+//	NewPipeline(in).Map(parse).Filter(valid).To(out).Run(ctx)
+func NewPipeline(src *Queue) *Pipeline {
+	return &Pipeline{src: src, workers: 1}
+}
+
+// Map adds a stage replacing each value with f(val).
+func (p *Pipeline) Map(f func(val interface{}) interface{}) *Pipeline {
+	p.stages = append(p.stages, func(val interface{}) (interface{}, bool) {
+		return f(val), true
+	})
+	return p
+}
+
+// Filter adds a stage dropping the values for which keep returns false.
+func (p *Pipeline) Filter(keep func(val interface{}) bool) *Pipeline {
+	p.stages = append(p.stages, func(val interface{}) (interface{}, bool) {
+		return val, keep(val)
+	})
+	return p
+}
+
+// Workers sets the number of goroutines running the stages, default is 1
+// which keeps the order of the values.
+func (p *Pipeline) Workers(n int) *Pipeline {
+	p.workers = n
+	return p
+}
+
+// To sets the output Queue, values which pass every stage are put into it.
+func (p *Pipeline) To(out *Queue) *Pipeline {
+	p.out = out
+	return p
+}
+
+// Run moves values through the stages until ctx is done, and returns the
+// number of values put into the output. Workers wait while the output is
+// full, so the source fills up and its producers are slowed down. When ctx
+// is done, Run returns once the values in flight are handled: each one is
+// put if the output has room, or dropped, see Hooks.OnDrop. It panics if
+// To wasn't called.
+func (p *Pipeline) Run(ctx context.Context) int {
+	if p.out == nil {
+		panic("goqueue: Pipeline has no output, call To before Run")
+	}
+	var n int64
+	wg := &sync.WaitGroup{}
+	for i := 0; i < p.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				val, err := p.src.getUntil(0, ctx.Done(), p.src.lifo)
+				if err != nil {
+					return
+				}
+				if p.process(ctx, val) {
+					atomic.AddInt64(&n, 1)
+				}
+				p.src.TaskDone()
+			}
+		}()
+	}
+	wg.Wait()
+	return int(n)
+}
+
+// process runs the stages on val and puts the result, it returns false if
+// the value was filtered out or dropped.
+func (p *Pipeline) process(ctx context.Context, val interface{}) bool {
+	for _, stage := range p.stages {
+		var keep bool
+		if val, keep = stage(val); !keep {
+			return false
+		}
+	}
+	if timeout, ok := p.out.throttle(0, ctx.Done()); !ok || p.out.putUntil(val, timeout, ctx.Done(), false) != nil {
+		if p.out.PutNoWait(val) != nil {
+			p.out.fireDrop(val)
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,65 @@
+package goqueue
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestPipeline(t *testing.T) {
+	src, out := New(0), New(3)
+	for i := 0; i < 20; i++ {
+		src.PutNoWait(i)
+	}
+	var dropped []interface{}
+	out.Reconfigure(WithHooks(Hooks{OnDrop: func(val interface{}) { dropped = append(dropped, val) }}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan int)
+	go func() {
+		done <- NewPipeline(src).
+			Filter(func(val interface{}) bool { return val.(int)%2 == 0 }).
+			Map(func(val interface{}) interface{} { return val.(int) * 10 }).
+			To(out).Run(ctx)
+	}()
+
+	fmt.Println("Test values go through the stages in order...")
+	for _, expect := range []int{0, 20} {
+		if val, err := out.Get(1); err != nil || val.(int) != expect {
+			t.Fatalf("Expect %d, got %v (%v)\n", expect, val, err)
+		}
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test the pipeline waits while the output is full...")
+	for !out.IsFull() {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if src.Size() != 9 {
+		t.Fatalf("Expect 9 values left in the source, got %d\n", src.Size())
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test Run returns once stopped, dropping the value in flight...")
+	cancel()
+	if n := <-done; n != 5 {
+		t.Fatalf("Expect 5 values put, got %d\n", n)
+	}
+	if len(dropped) != 1 || dropped[0].(int) != 100 {
+		t.Fatalf("Expect 100 dropped, got %v\n", dropped)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test Run without output panics up front...")
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatalf("Expect a panic without To\n")
+			}
+		}()
+		NewPipeline(New(0)).Run(context.Background())
+	}()
+	fmt.Println("  ...PASSED")
+}
@@ -0,0 +1,63 @@
+package goqueue
+
+// FullPolicy tells what Put does when the Queue is full.
+type FullPolicy int
+
+const (
+	// Block follows the timeout of Put: wait, or return ErrFullQueue.
+	Block FullPolicy = iota
+	// Reject returns ErrFullQueue right away, whatever the timeout.
+	Reject
+	// DropNewest drops the value put, which is passed to Hooks.OnDrop,
+	// and Put returns nil.
+	DropNewest
+	// DropOldest evicts the oldest value, which is passed to Hooks.OnDrop,
+	// to make room, like a circular buffer. The oldest value is at the
+	// front, even with WithLIFO.
+	DropOldest
+)
+
+// WithFullPolicy sets what Put does when Queue is full, default is Block.
+// It applies to PutAll too, which never waits.
+func WithFullPolicy(p FullPolicy) Option {
+	return func(q *Queue) {
+		q.policy = p
+	}
+}
+
+// WithOverwrite is WithFullPolicy(DropOldest).
+func WithOverwrite() Option {
+	return WithFullPolicy(DropOldest)
+}
+
+// SetMaxSize changes the max size of a Queue in use, blocked Put operators
+// are moved in right away if it grows. If it shrinks below the size, the
+// excess values are handled by the FullPolicy: DropOldest evicts them from
+// the front and DropNewest from the back, passing them to Hooks.OnDrop,
+// otherwise they are kept and Put waits until Get brings Queue below the
+// new max size.
+func (q *Queue) SetMaxSize(n int) {
+	q.mutex.Lock()
+	defer q.unlock()
+	q.maxSize = n
+	for n > 0 && q.size() > n {
+		switch q.policy {
+		case DropOldest:
+			q.evictOldest()
+			continue
+		case DropNewest:
+			val := q.items.popBack()
+			q.latency.pop(true)
+			q.leave(true)
+			q.publish()
+			q.observeDrop(1)
+			q.finish(1)
+			q.release(val)
+			q.drop(val)
+			continue
+		}
+		break
+	}
+	q.publish()
+	q.clearPending()
+}
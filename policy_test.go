@@ -0,0 +1,82 @@
+package goqueue
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestFullPolicy(t *testing.T) {
+	var dropped []interface{}
+	hooks := WithHooks(Hooks{OnDrop: func(val interface{}) { dropped = append(dropped, val) }})
+
+	fmt.Println("Test Reject never waits...")
+	queue := New(1, WithFullPolicy(Reject))
+	queue.PutNoWait(1)
+	if err := queue.Put(2, 0); err != ErrFullQueue {
+		t.Fatalf("Expect %v, got %v\n", ErrFullQueue, err)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test DropNewest drops the value put...")
+	queue = New(1, WithFullPolicy(DropNewest), hooks)
+	queue.PutNoWait(1)
+	if err := queue.Put(2, 0); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	if n, err := queue.PutAll([]interface{}{3, 4}); n != 2 || err != nil {
+		t.Fatalf("Expect 2 values put, got %d (%v)\n", n, err)
+	}
+	if val, _ := queue.GetNoWait(); val.(int) != 1 || len(dropped) != 3 {
+		t.Fatalf("Expect 1 kept and 3 dropped, got %v and %v\n", val, dropped)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test Reconfigure back to Block...")
+	queue.Reconfigure(WithFullPolicy(Block))
+	queue.PutNoWait(1)
+	if err := queue.PutNoWait(2); err != ErrFullQueue {
+		t.Fatalf("Expect %v, got %v\n", ErrFullQueue, err)
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestSetMaxSize(t *testing.T) {
+	var dropped []interface{}
+	hooks := WithHooks(Hooks{OnDrop: func(val interface{}) { dropped = append(dropped, val) }})
+	queue := New(1)
+	queue.PutNoWait(1)
+
+	fmt.Println("Test growing moves blocked putters in...")
+	done := make(chan error, 1)
+	go func() { done <- queue.Put(2, 1) }()
+	time.Sleep(10 * time.Millisecond)
+	queue.SetMaxSize(2)
+	if err := <-done; err != nil || queue.Size() != 2 || queue.MaxSize() != 2 {
+		t.Fatalf("Expect 2 values of 2, got %d of %d (%v)\n", queue.Size(), queue.MaxSize(), err)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test shrinking keeps the excess values with Block...")
+	queue.SetMaxSize(1)
+	if queue.Size() != 2 || queue.PutNoWait(3) != ErrFullQueue {
+		t.Fatalf("Expect 2 values kept and Put refused, got %d\n", queue.Size())
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test shrinking drops the excess values per policy...")
+	queue = New(0, WithFullPolicy(DropOldest), hooks)
+	queue.PutAll([]interface{}{1, 2, 3})
+	queue.SetMaxSize(1)
+	if val, _ := queue.Peek(); val.(int) != 3 || len(dropped) != 2 || dropped[0].(int) != 1 {
+		t.Fatalf("Expect 3 kept and [1 2] dropped, got %v and %v\n", val, dropped)
+	}
+	dropped = nil
+	queue = New(0, WithFullPolicy(DropNewest), hooks)
+	queue.PutAll([]interface{}{1, 2, 3})
+	queue.SetMaxSize(1)
+	if val, _ := queue.Peek(); val.(int) != 1 || len(dropped) != 2 || dropped[0].(int) != 3 {
+		t.Fatalf("Expect 1 kept and [3 2] dropped, got %v and %v\n", val, dropped)
+	}
+	fmt.Println("  ...PASSED")
+}
@@ -0,0 +1,126 @@
+package goqueue
+
+import (
+	"container/heap"
+	"container/list"
+	"sync"
+)
+
+type priorityItem struct {
+	value interface{}
+	seq   uint64
+}
+
+// priorityBefore orders items by less, then by insertion order.
+func priorityBefore(less func(a, b interface{}) bool) func(a, b interface{}) bool {
+	return func(x, y interface{}) bool {
+		a, b := x.(*priorityItem), y.(*priorityItem)
+		if less(a.value, b.value) {
+			return true
+		} else if less(b.value, a.value) {
+			return false
+		}
+		return a.seq < b.seq
+	}
+}
+
+// PriorityQueue is a GoRoutine safe queue which returns the least value
+// first according to a user supplied function, equal values are returned
+// in FIFO order.
+type PriorityQueue struct {
+	maxSize int
+	mutex   sync.Mutex
+	seq     uint64
+	heap    itemHeap
+	putters *list.List // store blocked Put operators
+	getters *list.List // store blocked Get operators
+}
+
+// NewPriority create a new PriorityQueue ordered by less, less(a, b)
+// reports whether a must be got before b. The maxSize variable sets the
+// max size, if maxSize is zero, PriorityQueue will be infinite size, and
+// Put always no wait.
+func NewPriority(maxSize int, less func(a, b interface{}) bool) *PriorityQueue {
+	q := new(PriorityQueue)
+	q.maxSize = maxSize
+	q.heap.less = priorityBefore(less)
+	q.putters = list.New()
+	q.getters = list.New()
+	return q
+}
+
+func (q *PriorityQueue) isfull() bool {
+	return (q.maxSize > 0 && q.maxSize <= q.heap.Len())
+}
+
+// Same as Get(-1).
+func (q *PriorityQueue) GetNoWait() (interface{}, error) {
+	return q.Get(-1)
+}
+
+// Get returns the least value, the timeout semantics are the same as
+// Queue.Get.
+func (q *PriorityQueue) Get(timeout float64) (interface{}, error) {
+	deadline, stop := deadlineOf(timeout)
+	defer stop()
+
+	q.mutex.Lock()
+	for {
+		if q.heap.Len() > 0 {
+			item := heap.Pop(&q.heap).(*priorityItem)
+			wake(q.getters, q.heap.Len())
+			wake(q.putters, 1)
+			q.mutex.Unlock()
+			return item.value, nil
+		}
+		if !await(&q.mutex, q.getters, timeout, deadline) {
+			q.mutex.Unlock()
+			return nil, ErrEmptyQueue
+		}
+	}
+}
+
+// Same as Put(val, -1).
+func (q *PriorityQueue) PutNoWait(val interface{}) error {
+	return q.Put(val, -1)
+}
+
+// Put a value, the timeout semantics are the same as Queue.Put.
+func (q *PriorityQueue) Put(val interface{}, timeout float64) error {
+	deadline, stop := deadlineOf(timeout)
+	defer stop()
+
+	q.mutex.Lock()
+	for {
+		if !q.isfull() {
+			q.seq++
+			heap.Push(&q.heap, &priorityItem{value: val, seq: q.seq})
+			wake(q.getters, 1)
+			q.mutex.Unlock()
+			return nil
+		}
+		if !await(&q.mutex, q.putters, timeout, deadline) {
+			q.mutex.Unlock()
+			return ErrFullQueue
+		}
+	}
+}
+
+// Return size of PriorityQueue.
+func (q *PriorityQueue) Size() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.heap.Len()
+}
+
+// Return true if PriorityQueue is empty.
+func (q *PriorityQueue) IsEmpty() bool {
+	return q.Size() == 0
+}
+
+// Return true if PriorityQueue is full.
+func (q *PriorityQueue) IsFull() bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.isfull()
+}
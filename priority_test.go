@@ -0,0 +1,63 @@
+package goqueue
+
+import (
+	"fmt"
+	"testing"
+)
+
+type task struct {
+	name     string
+	priority int
+}
+
+func TestPriorityOrder(t *testing.T) {
+	queue := NewPriority(0, func(a, b interface{}) bool {
+		return a.(task).priority > b.(task).priority
+	})
+
+	fmt.Println("Test values are got by less, then FIFO...")
+	queue.PutNoWait(task{"low", 1})
+	queue.PutNoWait(task{"high-1", 5})
+	queue.PutNoWait(task{"mid", 3})
+	queue.PutNoWait(task{"high-2", 5})
+	for _, expect := range []string{"high-1", "high-2", "mid", "low"} {
+		val, err := queue.GetNoWait()
+		if err != nil {
+			t.Fatalf("Unexpect error: %v\n", err)
+		} else if val.(task).name != expect {
+			t.Fatalf("Expect %v, got %v\n", expect, val)
+		}
+	}
+	if _, err := queue.Get(0.05); err != ErrEmptyQueue {
+		t.Fatalf("Expect %v, got %v\n", ErrEmptyQueue, err)
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestPriorityBlocking(t *testing.T) {
+	queue := NewPriority(1, func(a, b interface{}) bool { return a.(int) < b.(int) })
+
+	fmt.Println("Test blocking Put waits for a free slot...")
+	queue.PutNoWait(2)
+	if err := queue.PutNoWait(1); err != ErrFullQueue {
+		t.Fatalf("Expect %v, got %v\n", ErrFullQueue, err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- queue.Put(1, 2)
+	}()
+	if val, err := queue.Get(2); err != nil || val.(int) != 2 {
+		t.Fatalf("Expect 2, got %v (%v)\n", val, err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	if err := queue.Put(3, 0.05); err != ErrFullQueue {
+		t.Fatalf("Expect %v, got %v\n", ErrFullQueue, err)
+	}
+	if queue.putters.Len() != 0 || queue.getters.Len() != 0 {
+		t.Fatalf("Expect no pending operators, got %d putters %d getters\n",
+			queue.putters.Len(), queue.getters.Len())
+	}
+	fmt.Println("  ...PASSED")
+}
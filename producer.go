@@ -0,0 +1,99 @@
+package goqueue
+
+import (
+	"sync"
+	"time"
+)
+
+// Producer buffers values locally and puts them into its Queue in batches,
+// so many goroutines putting small values don't fight for the Queue lock on
+// every value. Each goroutine should use its own Producer.
+type Producer struct {
+	queue     *Queue
+	mutex     sync.Mutex
+	buf       []interface{}
+	batchSize int
+	interval  time.Duration
+	timer     *time.Timer
+	lastErr   error
+
+	// FlushTimeout is the timeout of the Put for a value which doesn't fit
+	// when flushing, default is 0 (block until it fits).
+	FlushTimeout float64
+}
+
+// NewProducer create a Producer which flushes when batchSize values are
+// buffered, or interval seconds after the first buffered value if interval
+// is greater than 0.
+func (q *Queue) NewProducer(batchSize int, interval float64) *Producer {
+	return &Producer{
+		queue:     q,
+		buf:       make([]interface{}, 0, batchSize),
+		batchSize: batchSize,
+		interval:  seconds(interval),
+	}
+}
+
+// Put buffers val, it returns the error of a flush triggered by it, or of
+// the last flush triggered by the interval timer.
+func (p *Producer) Put(val interface{}) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.buf = append(p.buf, val)
+	if len(p.buf) >= p.batchSize {
+		return p.flush()
+	}
+	if p.interval > 0 && p.timer == nil {
+		p.timer = time.AfterFunc(p.interval, func() {
+			p.mutex.Lock()
+			defer p.mutex.Unlock()
+			p.timer = nil
+			p.lastErr = p.flush()
+		})
+	}
+	err := p.lastErr
+	p.lastErr = nil
+	return err
+}
+
+// Flush puts the buffered values into the Queue, values which didn't make it
+// within FlushTimeout stay in the buffer and ErrFullQueue, or ErrRateLimited,
+// is returned.
+func (p *Producer) Flush() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.flush()
+}
+
+func (p *Producer) flush() error {
+	if p.timer != nil {
+		p.timer.Stop()
+		p.timer = nil
+	}
+	for len(p.buf) > 0 {
+		// Each batch is delayed by WithSoftLimit and limited by WithPutRate
+		// as PutAll is.
+		timeout, _ := p.queue.throttle(p.FlushTimeout, nil)
+		n, _ := p.queue.PutAll(p.buf)
+		if n == 0 {
+			// Full or over the rate, wait for a slot and a token.
+			if err := p.queue.putUntil(p.buf[0], timeout, nil, false); err != nil {
+				return err
+			}
+			n = 1
+		}
+		copy(p.buf, p.buf[n:])
+		for i := len(p.buf) - n; i < len(p.buf); i++ {
+			p.buf[i] = nil
+		}
+		p.buf = p.buf[:len(p.buf)-n]
+	}
+	return nil
+}
+
+// Buffered returns the number of values not flushed yet.
+func (p *Producer) Buffered() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return len(p.buf)
+}
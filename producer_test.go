@@ -0,0 +1,155 @@
+package goqueue
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestProducer(t *testing.T) {
+	queue := New(0)
+	producer := queue.NewProducer(3, 0)
+
+	fmt.Println("Test Producer flushes by batch size...")
+	producer.Put(1)
+	producer.Put(2)
+	if queue.Size() != 0 || producer.Buffered() != 2 {
+		t.Fatalf("Expect 2 buffered values, got %d in Queue\n", queue.Size())
+	}
+	producer.Put(3)
+	if queue.Size() != 3 || producer.Buffered() != 0 {
+		t.Fatalf("Expect 3 values in Queue, got %d\n", queue.Size())
+	}
+	for i := 1; i <= 3; i++ {
+		if val, _ := queue.GetNoWait(); val.(int) != i {
+			t.Fatalf("Expect %v, got %v\n", i, val)
+		}
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test Producer flushes by interval...")
+	producer2 := queue.NewProducer(100, 0.05)
+	producer2.Put(1)
+	if val, err := queue.Get(1); err != nil || val.(int) != 1 {
+		t.Fatalf("Expect 1, got %v (%v)\n", val, err)
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestProducerFull(t *testing.T) {
+	queue := New(2)
+	producer := queue.NewProducer(3, 0)
+	producer.FlushTimeout = -1
+
+	fmt.Println("Test values which don't fit stay buffered...")
+	producer.Put(1)
+	producer.Put(2)
+	if err := producer.Put(3); err != ErrFullQueue {
+		t.Fatalf("Expect %v, got %v\n", ErrFullQueue, err)
+	}
+	if queue.Size() != 2 || producer.Buffered() != 1 {
+		t.Fatalf("Expect 1 buffered value, got %d\n", producer.Buffered())
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test blocking flush waits for a slot...")
+	producer.FlushTimeout = 0
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		queue.GetNoWait()
+	}()
+	if err := producer.Flush(); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	for _, expect := range []int{2, 3} {
+		if val, _ := queue.GetNoWait(); val.(int) != expect {
+			t.Fatalf("Expect %v, got %v\n", expect, val)
+		}
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestProducerLimits(t *testing.T) {
+	fmt.Println("Test flush is limited by WithPutRate...")
+	queue := New(0, WithPutRate(20, 1))
+	producer := queue.NewProducer(3, 0)
+	producer.FlushTimeout = -1
+	producer.Put(1)
+	producer.Put(2)
+	if err := producer.Put(3); err != ErrRateLimited {
+		t.Fatalf("Expect %v, got %v\n", ErrRateLimited, err)
+	}
+	if queue.Size() != 1 || producer.Buffered() != 2 {
+		t.Fatalf("Expect 1 value put and 2 buffered, got %d and %d\n", queue.Size(), producer.Buffered())
+	}
+	producer.FlushTimeout = 0
+	start := time.Now()
+	if err := producer.Flush(); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Fatalf("Expect flush paced by the rate, took %v\n", elapsed)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test flush is delayed by WithSoftLimit...")
+	queue = New(4, WithSoftLimit(0.25, 0.2))
+	queue.PutNoWait(0)
+	queue.PutNoWait(0)
+	producer = queue.NewProducer(1, 0)
+	start = time.Now()
+	if err := producer.Put(1); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Fatalf("Expect flush delayed, took %v\n", elapsed)
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func benchmarkPut(b *testing.B, put func(q *Queue) func(int)) {
+	queue := New(0)
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			queue.Get(0.01)
+		}
+	}()
+	b.ResetTimer()
+	wg := &sync.WaitGroup{}
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			f := put(queue)
+			for i := 0; i < b.N/8; i++ {
+				f(i)
+			}
+		}()
+	}
+	wg.Wait()
+	b.StopTimer()
+	close(stop)
+	<-done
+}
+
+func BenchmarkPut(b *testing.B) {
+	benchmarkPut(b, func(q *Queue) func(int) {
+		return func(i int) { q.PutNoWait(i) }
+	})
+}
+
+func BenchmarkProducerPut(b *testing.B) {
+	benchmarkPut(b, func(q *Queue) func(int) {
+		p := q.NewProducer(64, 0.01)
+		return func(i int) { p.Put(i) }
+	})
+}
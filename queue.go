@@ -6,8 +6,11 @@ package goqueue
 
 import (
 	"container/list"
+	"context"
 	"errors"
+	"math/rand"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -25,82 +28,245 @@ func newWaiter() waiter {
 	return w
 }
 
+// Waiters and putters of Queue are pooled, a waiter is put back once it is
+// off the list and empty.
+var (
+	waiterPool = sync.Pool{New: func() interface{} { return newWaiter() }}
+	putterPool = sync.Pool{New: func() interface{} { return &putter{w: newWaiter()} }}
+)
+
 type Queue struct {
+	// length and limit mirror items.Len() and maxSize for the observers
+	// which don't take the mutex, they are stored by publish.
+	length int64
+	limit  int64
+	stats  counters
+
 	maxSize int
 	mutex   sync.Mutex
-	items   *list.List // store items
-	putters *list.List // store blocked Put operators
-	getters *list.List // store blocked Get operators
+	items   *ring        // store items
+	putters *list.List   // store blocked Put operators
+	getters *list.List   // store blocked Get operators
+	elastic *elastic     // adapts maxSize, nil if disabled
+	soft    atomic.Value // *softLimit, delays Put near maxSize if set
+	getRate atomic.Value // *tokenBucket, limits Get if set
+	putRate atomic.Value // *tokenBucket, limits Put if set
+	lifo    bool         // Get takes the value put last
+	paused  bool         // Get waits until Resume
+	policy  FullPolicy   // what Put does when Queue is full
+	hooks   *Hooks       // nil if disabled
+	latency *latency     // nil if disabled
+	events  []hookEvent  // hooks to call once the lock is released
+	account *account     // counts values against a Manager Budget, nil if none
+
+	gen      uint64     // number of barriers ever created
+	marks    *ring      // generation of each value, parallel to items, nil without barriers
+	barriers *list.List // store pending barriers, by generation
+	watchers *list.List // store waiting Select operators
+	sizeWait *list.List // store *sizeWaiter, see WaitSizeBelow
+
+	unfinished int           // values put and not marked done by TaskDone
+	idle       chan struct{} // closed once unfinished drops to zero
 }
 
+// Option configures a Queue, see New and Reconfigure.
+type Option func(*Queue)
+
 // New create a new Queue, The maxSize variable sets the max Queue size.
 // If maxSize is zero, Queue will be infinite size, and Put always no wait.
-func New(maxSize int) *Queue {
+func New(maxSize int, opts ...Option) *Queue {
 	q := new(Queue)
 	q.mutex = sync.Mutex{}
 	q.maxSize = maxSize
-	q.items = list.New()
+	q.items = newRing(maxSize)
 	q.putters = list.New()
 	q.getters = list.New()
+	q.barriers = list.New()
+	q.watchers = list.New()
+	q.sizeWait = list.New()
+	for _, opt := range opts {
+		opt(q)
+	}
+	q.publish()
 	return q
 }
 
-func (q *Queue) newPutter() *list.Element {
-	w := newWaiter()
-	return q.putters.PushBack(w)
+// WithMaxSize sets the max size, mostly useful with Reconfigure.
+func WithMaxSize(maxSize int) Option {
+	return func(q *Queue) {
+		q.maxSize = maxSize
+	}
 }
 
-func (q *Queue) newGetter() *list.Element {
-	w := newWaiter()
-	return q.getters.PushBack(w)
+// WithLIFO makes Get take the value put last, like a stack, with the same
+// blocking and timeout semantics. Peek and PeekN look at that end too.
+func WithLIFO() Option {
+	return func(q *Queue) {
+		q.lifo = true
+	}
 }
 
-func (q *Queue) notifyPutter(getter *list.Element) bool {
-	if getter != nil {
-		q.getters.Remove(getter)
+// Reconfigure applies opts to a Queue in use, the contents are kept. If
+// maxSize grows, blocked Put operators are moved in right away.
+func (q *Queue) Reconfigure(opts ...Option) {
+	q.mutex.Lock()
+	defer q.unlock()
+	for _, opt := range opts {
+		opt(q)
 	}
+	q.publish()
+	q.clearPending()
+}
+
+// publish must be called with the mutex held after items or maxSize
+// changed.
+func (q *Queue) publish() {
+	atomic.StoreInt64(&q.length, int64(q.items.Len()))
+	atomic.StoreInt64(&q.limit, int64(q.maxSize))
+	if n := int64(q.items.Len()); n > atomic.LoadInt64(&q.stats.peak) {
+		atomic.StoreInt64(&q.stats.peak, n)
+	}
+	q.notifySizeWaiters()
+}
+
+// putter is a blocked Put operator, its value is moved into the Queue by
+// the Get operator which frees a slot.
+type putter struct {
+	val   interface{}
+	w     waiter
+	front bool // moved to the front by PutFront
+}
+
+func (q *Queue) newPutter(val interface{}, front bool) *list.Element {
+	p := putterPool.Get().(*putter)
+	p.val, p.front = val, front
+	return q.putters.PushBack(p)
+}
+
+func releasePutter(p *putter) {
+	p.val = nil
+	putterPool.Put(p)
+}
+
+func (q *Queue) newGetter() *list.Element {
+	return q.getters.PushBack(waiterPool.Get().(waiter))
+}
+
+// notifyPutter moves the value of the first blocked Put operator into the
+// Queue and wakes it up.
+func (q *Queue) notifyPutter() bool {
 	if q.putters.Len() == 0 {
 		return false
 	}
 	e := q.putters.Front()
 	q.putters.Remove(e)
-	w := e.Value.(waiter)
-	w <- true
+	p := e.Value.(*putter)
+	q.add(p.val, p.front)
+	p.w <- true
 	return true
 }
 
-func (q *Queue) notifyGetter(putter *list.Element, val interface{}) bool {
-	if putter != nil {
-		q.putters.Remove(putter)
-	}
-	if q.getters.Len() == 0 {
+// notifyGetter hands val to the first blocked Get operator.
+func (q *Queue) notifyGetter(val interface{}) bool {
+	return q.handOver(val, true)
+}
+
+// handOver gives val to the first blocked Get operator, counted as a Put
+// too if put.
+func (q *Queue) handOver(val interface{}, put bool) bool {
+	if q.getters.Len() == 0 || q.paused {
 		return false
 	}
 	e := q.getters.Front()
 	q.getters.Remove(e)
 	w := e.Value.(waiter)
 	w <- val
+	q.latency.record(0)
+	if put {
+		q.observe(1, 1)
+		q.firePut(val)
+	} else {
+		q.observe(0, 1)
+	}
+	q.fireGet(val)
 	return true
 }
 
 func (q *Queue) clearPending() {
 	for !q.isfull() && q.putters.Len() != 0 {
-		q.notifyPutter(nil)
+		q.notifyPutter()
 	}
-	for !q.isempty() && q.getters.Len() != 0 {
-		v := q.get()
-		q.notifyGetter(nil, v)
+	for !q.isempty() && q.getters.Len() != 0 && !q.paused {
+		// take counts the Get already, hand the value over as is.
+		e := q.getters.Front()
+		q.getters.Remove(e)
+		e.Value.(waiter) <- q.get()
 	}
 }
 
+// observe must be called with the mutex held for every value put into or
+// got from the Queue.
+func (q *Queue) observe(puts, gets int) {
+	q.stats.add(puts, gets)
+	q.unfinished += puts
+	q.observeElastic(puts, gets)
+}
+
+// observeDrop records n values discarded from Queue, they leave it as got
+// ones do but are not counted as Gets.
+func (q *Queue) observeDrop(n int) {
+	atomic.AddInt64(&q.stats.dropped, int64(n))
+}
+
 func (q *Queue) get() interface{} {
-	e := q.items.Front()
-	q.items.Remove(e)
-	return e.Value
+	return q.take(q.lifo)
+}
+
+// take removes the value at the back of Queue if back, or at the front.
+func (q *Queue) take(back bool) interface{} {
+	var val interface{}
+	if back {
+		val = q.items.popBack()
+	} else {
+		val = q.items.popFront()
+	}
+	if q.latency != nil {
+		q.latency.record(time.Since(q.latency.pop(back)))
+	}
+	q.leave(back)
+	q.publish()
+	q.observe(0, 1)
+	q.release(val)
+	q.fireGet(val)
+	return val
 }
 
 func (q *Queue) put(val interface{}) {
-	q.items.PushBack(val)
+	q.add(val, false)
+}
+
+// add inserts val at the front of Queue if front, or at the back.
+func (q *Queue) add(val interface{}, front bool) {
+	q.insert(val, front)
+	q.observe(1, 0)
+	q.firePut(val)
+}
+
+// insert adds val without counting a Put, as putBack does.
+func (q *Queue) insert(val interface{}, front bool) {
+	if front {
+		q.items.pushFront(val)
+	} else {
+		q.items.pushBack(val)
+	}
+	q.stamp(front)
+	q.mark(front, q.gen)
+	q.publish()
+	q.notifyWatchers()
+}
+
+func seconds(timeout float64) time.Duration {
+	return time.Duration(timeout * float64(time.Second))
 }
 
 // Same as Get(-1).
@@ -115,39 +281,106 @@ func (q *Queue) GetNoWait() (interface{}, error) {
 // * If timeout greater tahn 0, wait timeout seconds until get a value from Queue,
 // if timeout passed, return (nil, ErrEmptyQueue).
 func (q *Queue) Get(timeout float64) (interface{}, error) {
+	return q.getUntil(timeout, nil, q.lifo)
+}
+
+// GetBack is Get which takes the value at the back of Queue, the one put
+// last.
+func (q *Queue) GetBack(timeout float64) (interface{}, error) {
+	return q.getUntil(timeout, nil, true)
+}
+
+// GetTimeout is Get with the timeout as a time.Duration, with the same
+// meaning of negative and zero values.
+func (q *Queue) GetTimeout(d time.Duration) (interface{}, error) {
+	return q.Get(d.Seconds())
+}
+
+// GetContext blocks until get a value from Queue or ctx is done, then it
+// returns (nil, ctx.Err()).
+func (q *Queue) GetContext(ctx context.Context) (interface{}, error) {
+	v, err := q.getUntil(0, ctx.Done(), q.lifo)
+	if err == ErrEmptyQueue {
+		return nil, ctx.Err()
+	}
+	return v, err
+}
+
+// getUntil is Get which also gives up waiting once done is closed, and
+// takes from the back if back.
+func (q *Queue) getUntil(timeout float64, done <-chan struct{}, back bool) (interface{}, error) {
+	b := q.getLimiter()
+	if b == nil {
+		return q.getWait(timeout, done, back)
+	}
+	timeout, ok := b.acquire(timeout, done)
+	if !ok {
+		if timeout >= 0.0 {
+			atomic.AddInt64(&q.stats.getTimeouts, 1)
+		}
+		return nil, ErrEmptyQueue
+	}
+	v, err := q.getWait(timeout, done, back)
+	if err != nil {
+		b.give(1)
+	}
+	return v, err
+}
+
+// getWait is getUntil regardless of the rate limit.
+func (q *Queue) getWait(timeout float64, done <-chan struct{}, back bool) (interface{}, error) {
 	q.mutex.Lock()
+	q.adapt()
 	q.clearPending()
 	isempty := q.isempty()
+	if timeout < 0.0 && q.paused {
+		defer q.unlock()
+		return nil, ErrPaused
+	}
 	if timeout < 0.0 && isempty {
-		defer q.mutex.Unlock()
+		defer q.unlock()
 		return nil, ErrEmptyQueue
 	}
 
-	if !isempty {
-		defer q.mutex.Unlock()
-		v := q.get()
-		q.notifyPutter(nil)
+	if !isempty && !q.paused {
+		defer q.unlock()
+		v := q.take(back)
+		q.notifyPutter()
 		return v, nil
 	}
 
 	e := q.newGetter()
-	q.mutex.Unlock()
+	q.unlock()
 	w := e.Value.(waiter)
+	defer waiterPool.Put(w)
 
-	var v interface{}
-	if timeout == 0.0 {
-		v = <-w
-	} else {
-		select {
-		case v = <-w:
-		case <-time.After(time.Duration(timeout) * time.Second):
-			return nil, ErrEmptyQueue
-		}
+	if timeout == 0.0 && done == nil {
+		return received(<-w)
+	}
+	var expired <-chan time.Time
+	if timeout > 0.0 {
+		t := acquireTimer(seconds(timeout))
+		defer releaseTimer(t)
+		expired = t.C
+	}
+	select {
+	case v := <-w:
+		return received(v)
+	case <-expired:
+	case <-done:
 	}
+
 	q.mutex.Lock()
-	q.notifyPutter(e)
-	q.mutex.Unlock()
-	return v, nil
+	defer q.unlock()
+	// A value may be handed over while waiting for the lock.
+	select {
+	case v := <-w:
+		return received(v)
+	default:
+	}
+	q.getters.Remove(e)
+	atomic.AddInt64(&q.stats.getTimeouts, 1)
+	return nil, ErrEmptyQueue
 }
 
 // Same as Put(-1).
@@ -162,51 +395,335 @@ func (q *Queue) PutNoWait(val interface{}) error {
 // * If timeout greater than 0, wait timeout seconds until put a value into Queue,
 // if timeout passed, return (nil, ErrFullQueue).
 func (q *Queue) Put(val interface{}, timeout float64) error {
+	timeout, _ = q.throttle(timeout, nil)
+	return q.putUntil(val, timeout, nil, false)
+}
+
+// PutFront is Put which inserts val at the front of Queue, so it is got
+// next.
+func (q *Queue) PutFront(val interface{}, timeout float64) error {
+	timeout, _ = q.throttle(timeout, nil)
+	return q.putUntil(val, timeout, nil, true)
+}
+
+// PutTimeout is Put with the timeout as a time.Duration, with the same
+// meaning of negative and zero values.
+func (q *Queue) PutTimeout(val interface{}, d time.Duration) error {
+	return q.Put(val, d.Seconds())
+}
+
+// PutContext blocks until put a value into Queue or ctx is done, then it
+// returns ctx.Err().
+func (q *Queue) PutContext(ctx context.Context, val interface{}) error {
+	timeout, ok := q.throttle(0, ctx.Done())
+	if !ok {
+		return ctx.Err()
+	}
+	err := q.putUntil(val, timeout, ctx.Done(), false)
+	if err == ErrFullQueue {
+		return ctx.Err()
+	}
+	return err
+}
+
+// putUntil is Put which also gives up waiting once done is closed, and
+// inserts at the front if front.
+func (q *Queue) putUntil(val interface{}, timeout float64, done <-chan struct{}, front bool) error {
+	if err := q.charge(val, front); err != nil {
+		return err
+	}
+	err := q.putLimited(val, timeout, done, front)
+	if err != nil {
+		q.release(val)
+	}
+	return err
+}
+
+// putLimited is putUntil regardless of the Budget.
+func (q *Queue) putLimited(val interface{}, timeout float64, done <-chan struct{}, front bool) error {
+	b := q.putLimiter()
+	if b == nil {
+		return q.putWait(val, timeout, done, front)
+	}
+	timeout, ok := b.acquire(timeout, done)
+	if !ok {
+		select {
+		case <-done:
+			return ErrFullQueue
+		default:
+		}
+		atomic.AddInt64(&q.stats.rejected, 1)
+		return ErrRateLimited
+	}
+	err := q.putWait(val, timeout, done, front)
+	if err != nil {
+		b.give(1)
+	}
+	return err
+}
+
+// putWait is putUntil regardless of the rate limit.
+func (q *Queue) putWait(val interface{}, timeout float64, done <-chan struct{}, front bool) error {
 	q.mutex.Lock()
+	q.adapt()
 	q.clearPending()
 	isfull := q.isfull()
+	if isfull {
+		q.observeFull()
+		switch q.policy {
+		case Reject:
+			timeout = -1
+		case DropNewest:
+			defer q.unlock()
+			q.release(val)
+			q.drop(val)
+			return nil
+		case DropOldest:
+			q.evictOldest()
+			isfull = false
+		}
+	}
 	if timeout < 0.0 && isfull {
+		defer q.unlock()
+		atomic.AddInt64(&q.stats.rejected, 1)
 		return ErrFullQueue
 	}
 
 	if !isfull {
-		defer q.mutex.Unlock()
-		if !q.notifyGetter(nil, val) {
-			q.put(val)
+		defer q.unlock()
+		if q.notifyGetter(val) {
+			q.release(val)
+		} else {
+			q.add(val, front)
 		}
 		return nil
 	}
 
-	e := q.newPutter()
-	q.mutex.Unlock()
-	w := e.Value.(waiter)
-	if timeout == 0.0 {
-		<-w
+	e := q.newPutter(val, front)
+	q.scheduleAdapt()
+	q.unlock()
+	p := e.Value.(*putter)
+	defer releasePutter(p)
+	w := p.w
+
+	if timeout == 0.0 && done == nil {
+		return moved(<-w)
+	}
+	var expired <-chan time.Time
+	if timeout > 0.0 {
+		t := acquireTimer(seconds(timeout))
+		defer releaseTimer(t)
+		expired = t.C
+	}
+	select {
+	case v := <-w:
+		return moved(v)
+	case <-expired:
+	case <-done:
+	}
+
+	q.mutex.Lock()
+	defer q.unlock()
+	// The value may be moved in while waiting for the lock.
+	select {
+	case v := <-w:
+		return moved(v)
+	default:
+	}
+	q.putters.Remove(e)
+	atomic.AddInt64(&q.stats.putTimeouts, 1)
+	return ErrFullQueue
+}
+
+// evictOldest drops the value at the front of a full Queue to make room.
+func (q *Queue) evictOldest() {
+	val := q.items.popFront()
+	q.latency.pop(false)
+	q.leave(false)
+	q.publish()
+	q.observeDrop(1)
+	q.finish(1)
+	q.release(val)
+	q.drop(val)
+}
+
+// putBack inserts val at the front of Queue even if it is full, for values
+// which were in the Queue already.
+func (q *Queue) putBack(val interface{}) {
+	q.charge(val, true)
+	q.mutex.Lock()
+	defer q.unlock()
+	q.clearPending()
+	// The value was counted as a Put, and for Join, when it was put first.
+	if q.handOver(val, false) {
+		q.release(val)
 	} else {
-		select {
-		case <-w:
-		case <-time.After(time.Duration(timeout) * time.Second):
-			return ErrFullQueue
+		q.insert(val, true)
+	}
+}
+
+// Return the current max size of Queue, zero means infinite.
+func (q *Queue) MaxSize() int {
+	return int(atomic.LoadInt64(&q.limit))
+}
+
+// putMany puts the leading values of vals which fit into the Queue under a
+// single lock acquisition, returns the number of values put.
+func (q *Queue) putMany(vals []interface{}) int {
+	q.mutex.Lock()
+	defer q.unlock()
+	q.adapt()
+	q.clearPending()
+	n := 0
+	for ; n < len(vals); n++ {
+		if q.isfull() {
+			if q.policy == DropNewest {
+				for _, val := range vals[n:] {
+					q.release(val)
+					q.drop(val)
+				}
+				return len(vals)
+			} else if q.policy != DropOldest {
+				break
+			}
+			q.evictOldest()
+		}
+		if q.notifyGetter(vals[n]) {
+			q.release(vals[n])
+		} else {
+			q.put(vals[n])
 		}
 	}
+	if n < len(vals) {
+		q.observeFull()
+	}
+	return n
+}
+
+// PutAll puts the leading values of vals which fit into Queue under a single
+// lock acquisition, it never waits. It returns the number of values put,
+// and ErrFullQueue if some didn't fit, or ErrRateLimited if the rate set
+// by WithPutRate didn't allow them, or ErrOverBudget if the Budget of its
+// Manager didn't.
+func (q *Queue) PutAll(vals []interface{}) (int, error) {
+	k := len(vals)
+	var over error
+	for i, val := range vals {
+		if over = q.charge(val, false); over != nil {
+			k = i
+			break
+		}
+	}
+	n, err := q.putAll(vals[:k])
+	for _, val := range vals[n:k] {
+		q.release(val)
+	}
+	if err == nil && over != nil {
+		err = over
+	}
+	return n, err
+}
+
+// putAll is PutAll regardless of the Budget.
+func (q *Queue) putAll(vals []interface{}) (int, error) {
+	b := q.putLimiter()
+	if b == nil {
+		n := q.putMany(vals)
+		if n < len(vals) {
+			return n, ErrFullQueue
+		}
+		return n, nil
+	}
+	allowed := b.take(len(vals))
+	n := q.putMany(vals[:allowed])
+	b.give(allowed - n)
+	if n < allowed {
+		return n, ErrFullQueue
+	} else if n < len(vals) {
+		atomic.AddInt64(&q.stats.rejected, 1)
+		return n, ErrRateLimited
+	}
+	return n, nil
+}
+
+// getMany appends up to n values to vals under a single lock acquisition.
+func (q *Queue) getMany(vals []interface{}, n int) []interface{} {
+	q.mutex.Lock()
+	defer q.unlock()
+	q.adapt()
+	q.clearPending()
+	for ; n > 0 && !q.isempty() && !q.paused; n-- {
+		vals = append(vals, q.get())
+		q.notifyPutter()
+	}
+	return vals
+}
+
+// GetN returns up to n values, all the ones available under a single lock
+// acquisition. If Queue is empty it waits for the first one with the
+// timeout semantics of Get, then takes the others which are available.
+func (q *Queue) GetN(n int, timeout float64) ([]interface{}, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	vals := q.getAllowed(make([]interface{}, 0, n), n)
+	if len(vals) > 0 {
+		return vals, nil
+	}
+	v, err := q.Get(timeout)
+	if err != nil {
+		return nil, err
+	}
+	return q.getAllowed(append(vals, v), n-1), nil
+}
+
+// getAllowed is getMany limited to the values the rate allows right now.
+func (q *Queue) getAllowed(vals []interface{}, n int) []interface{} {
+	b := q.getLimiter()
+	if b == nil {
+		return q.getMany(vals, n)
+	}
+	allowed := b.take(n)
+	got := len(vals)
+	vals = q.getMany(vals, allowed)
+	b.give(allowed - (len(vals) - got))
+	return vals
+}
+
+// Drain removes and returns every value in Queue under a single lock
+// acquisition, in the order Get takes them. Blocked Put operators are moved
+// in afterwards, their values are not returned.
+func (q *Queue) Drain() []interface{} {
+	return q.DrainN(0)
+}
 
+// DrainN is Drain which returns up to n values, if n is greater than zero.
+func (q *Queue) DrainN(n int) []interface{} {
 	q.mutex.Lock()
-	if !q.notifyGetter(e, val) {
-		q.put(e)
+	defer q.unlock()
+	q.clearPending()
+	if n <= 0 || n > q.size() {
+		n = q.size()
+	}
+	if n == 0 {
+		return nil
 	}
-	q.mutex.Unlock()
-	return nil
+	vals := make([]interface{}, 0, n)
+	for ; n > 0; n-- {
+		vals = append(vals, q.get())
+	}
+	q.clearPending()
+	return vals
 }
 
 func (q *Queue) size() int {
 	return q.items.Len()
 }
 
-// Return size of Queue.
+// Return size of Queue, it doesn't take the lock so it never slows down
+// Get and Put.
 func (q *Queue) Size() int {
-	q.mutex.Lock()
-	defer q.mutex.Unlock()
-	return q.size()
+	return int(atomic.LoadInt64(&q.length))
 }
 
 func (q *Queue) isempty() bool {
@@ -215,9 +732,7 @@ func (q *Queue) isempty() bool {
 
 // Return true if Queue is empty.
 func (q *Queue) IsEmpty() bool {
-	q.mutex.Lock()
-	defer q.mutex.Unlock()
-	return q.isempty()
+	return q.Size() == 0
 }
 
 func (q *Queue) isfull() bool {
@@ -226,7 +741,119 @@ func (q *Queue) isfull() bool {
 
 // Return true if Queue is full.
 func (q *Queue) IsFull() bool {
+	limit := q.MaxSize()
+	return (limit > 0 && limit <= q.Size())
+}
+
+// peekAt returns the i-th value in the order Get takes them.
+func (q *Queue) peekAt(i int) interface{} {
+	if q.lifo {
+		return q.items.at(q.items.Len() - 1 - i)
+	}
+	return q.items.at(i)
+}
+
+// Peek returns the value at the head of Queue without removing it, or
+// (nil, ErrEmptyQueue).
+func (q *Queue) Peek() (interface{}, error) {
+	q.mutex.Lock()
+	defer q.unlock()
+	if q.items.Len() != 0 {
+		return q.peekAt(0), nil
+	}
+	return nil, ErrEmptyQueue
+}
+
+// PeekN returns up to n values from the head of Queue, in the order Get
+// returns them, without removing them.
+func (q *Queue) PeekN(n int) []interface{} {
 	q.mutex.Lock()
-	defer q.mutex.Unlock()
-	return q.isfull()
+	defer q.unlock()
+	if n > q.items.Len() {
+		n = q.items.Len()
+	}
+	if n <= 0 {
+		return nil
+	}
+	vals := make([]interface{}, 0, n)
+	for i := 0; i < n; i++ {
+		vals = append(vals, q.peekAt(i))
+	}
+	return vals
+}
+
+// ToSlice returns a copy of the values of Queue from front to back, which
+// is the order Get takes them unless WithLIFO is set, without removing
+// them. Values which are pointers still point to the queued data.
+func (q *Queue) ToSlice() []interface{} {
+	v := q.view()
+	vals := make([]interface{}, 0, v.n)
+	for i := 0; i < v.n; i++ {
+		vals = append(vals, v.at(i))
+	}
+	return vals
+}
+
+// Range calls fn for the values of Queue from front to back until it
+// returns false. It iterates a copy-on-write snapshot taken under the lock
+// and doesn't hold it, so Put and Get go on meanwhile and fn may use the
+// Queue; the values put or got since the snapshot are not seen.
+func (q *Queue) Range(fn func(val interface{}) bool) {
+	v := q.view()
+	for i := 0; i < v.n; i++ {
+		if !fn(v.at(i)) {
+			return
+		}
+	}
+}
+
+func (q *Queue) view() ringView {
+	q.mutex.Lock()
+	defer q.unlock()
+	return q.items.view()
+}
+
+// Find returns the first value from front to back for which pred returns
+// true, without removing it. pred is called with the lock held, it must
+// not use the Queue.
+func (q *Queue) Find(pred func(val interface{}) bool) (interface{}, bool) {
+	q.mutex.Lock()
+	defer q.unlock()
+	for i := 0; i < q.items.Len(); i++ {
+		if val := q.items.at(i); pred(val) {
+			return val, true
+		}
+	}
+	return nil, false
+}
+
+// Contains reports whether pred returns true for a value of Queue, see
+// Find.
+func (q *Queue) Contains(pred func(val interface{}) bool) bool {
+	_, ok := q.Find(pred)
+	return ok
+}
+
+// Sample returns up to n values chosen uniformly at random from the Queue,
+// in queue order, without removing them. The values are copied into a new
+// slice, but values which are pointers still point to the queued data.
+func (q *Queue) Sample(n int) []interface{} {
+	q.mutex.Lock()
+	defer q.unlock()
+	if n <= 0 {
+		return nil
+	}
+	if n > q.items.Len() {
+		n = q.items.Len()
+	}
+	// Selection sampling (Knuth's algorithm S) keeps the queue order.
+	sample := make([]interface{}, 0, n)
+	left := q.items.Len()
+	for i := 0; len(sample) < n; i++ {
+		if rand.Intn(left) < n-len(sample) {
+			sample = append(sample, q.items.at(i))
+		}
+		left--
+	}
+	return sample
 }
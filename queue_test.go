@@ -1,7 +1,9 @@
 package goqueue
 
 import (
+	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -254,3 +256,496 @@ func TestConcurrentPutGet(t *testing.T) {
 	}
 	fmt.Println("  ...PASSED")
 }
+
+func TestTimeoutKeepsItems(t *testing.T) {
+	fmt.Println("Test timed out Get doesn't swallow a later Put...")
+	queue := New(1)
+	if _, err := queue.Get(0.05); err != ErrEmptyQueue {
+		t.Fatalf("Expect %v, got %v\n", ErrEmptyQueue, err)
+	}
+	queue.PutNoWait(1)
+	if val, err := queue.GetNoWait(); err != nil || val.(int) != 1 {
+		t.Fatalf("Expect 1, got %v (%v)\n", val, err)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test timed out Put doesn't put its value later...")
+	queue.PutNoWait(1)
+	if err := queue.Put(2, 0.05); err != ErrFullQueue {
+		t.Fatalf("Expect %v, got %v\n", ErrFullQueue, err)
+	}
+	queue.GetNoWait()
+	if queue.Size() != 0 {
+		t.Fatalf("Expect empty Queue, got %d items\n", queue.Size())
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test blocked Put puts its own value...")
+	queue.PutNoWait(1)
+	done := make(chan error, 1)
+	go func() {
+		done <- queue.Put(2, 0)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	for _, expect := range []int{1, 2} {
+		if val, err := queue.Get(1); err != nil || val.(int) != expect {
+			t.Fatalf("Expect %v, got %v (%v)\n", expect, val, err)
+		}
+	}
+	<-done
+	fmt.Println("  ...PASSED")
+}
+
+func TestPeek(t *testing.T) {
+	queue := New(0)
+
+	fmt.Println("Test Peek on an empty Queue...")
+	if _, err := queue.Peek(); err != ErrEmptyQueue {
+		t.Fatalf("Expect %v, got %v\n", ErrEmptyQueue, err)
+	}
+	if queue.PeekN(3) != nil {
+		t.Fatalf("Expect no values\n")
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test Peek and PeekN don't remove values...")
+	for i := 0; i < 3; i++ {
+		queue.PutNoWait(i)
+	}
+	if val, err := queue.Peek(); err != nil || val.(int) != 0 {
+		t.Fatalf("Expect 0, got %v (%v)\n", val, err)
+	}
+	vals := queue.PeekN(5)
+	if len(vals) != 3 || vals[0].(int) != 0 || vals[2].(int) != 2 {
+		t.Fatalf("Expect [0 1 2], got %v\n", vals)
+	}
+	if len(queue.PeekN(2)) != 2 || queue.Size() != 3 {
+		t.Fatalf("Expect 2 values peeked and size 3, got size %d\n", queue.Size())
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestBatch(t *testing.T) {
+	queue := New(3)
+
+	fmt.Println("Test PutAll puts the values which fit...")
+	if n, err := queue.PutAll([]interface{}{1, 2, 3, 4}); n != 3 || err != ErrFullQueue {
+		t.Fatalf("Expect 3 put and %v, got %d (%v)\n", ErrFullQueue, n, err)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test GetN returns what is available...")
+	vals, err := queue.GetN(2, -1)
+	if err != nil || len(vals) != 2 || vals[0].(int) != 1 || vals[1].(int) != 2 {
+		t.Fatalf("Expect [1 2], got %v (%v)\n", vals, err)
+	}
+	vals, err = queue.GetN(5, -1)
+	if err != nil || len(vals) != 1 || vals[0].(int) != 3 {
+		t.Fatalf("Expect [3], got %v (%v)\n", vals, err)
+	}
+	if _, err := queue.GetN(5, 0.05); err != ErrEmptyQueue {
+		t.Fatalf("Expect %v, got %v\n", ErrEmptyQueue, err)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test GetN waits for the first value...")
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		queue.PutAll([]interface{}{4, 5})
+	}()
+	vals, err = queue.GetN(5, 2)
+	if err != nil || len(vals) == 0 || vals[0].(int) != 4 {
+		t.Fatalf("Expect values from 4, got %v (%v)\n", vals, err)
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestDeque(t *testing.T) {
+	queue := New(3)
+
+	fmt.Println("Test PutFront and GetBack use the other ends...")
+	queue.PutNoWait(2)
+	queue.PutFront(1, -1)
+	queue.PutNoWait(3)
+	if err := queue.PutFront(0, -1); err != ErrFullQueue {
+		t.Fatalf("Expect %v, got %v\n", ErrFullQueue, err)
+	}
+	if val, err := queue.GetBack(-1); err != nil || val.(int) != 3 {
+		t.Fatalf("Expect 3, got %v (%v)\n", val, err)
+	}
+	if val, err := queue.GetNoWait(); err != nil || val.(int) != 1 {
+		t.Fatalf("Expect 1, got %v (%v)\n", val, err)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test a blocked PutFront is moved to the front...")
+	queue.PutNoWait(3)
+	queue.PutNoWait(4)
+	done := make(chan error, 1)
+	go func() {
+		done <- queue.PutFront(0, 2)
+	}()
+	for {
+		queue.mutex.Lock()
+		n := queue.putters.Len()
+		queue.mutex.Unlock()
+		if n != 0 {
+			break
+		}
+	}
+	if val, _ := queue.GetBack(-1); val.(int) != 4 {
+		t.Fatalf("Expect 4, got %v\n", val)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	for _, expect := range []int{0, 2, 3} {
+		if val, _ := queue.GetNoWait(); val.(int) != expect {
+			t.Fatalf("Expect %v, got %v\n", expect, val)
+		}
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestLIFO(t *testing.T) {
+	queue := New(2, WithLIFO())
+
+	fmt.Println("Test Get takes the value put last...")
+	queue.PutNoWait(1)
+	queue.PutNoWait(2)
+	if vals := queue.PeekN(2); vals[0].(int) != 2 || vals[1].(int) != 1 {
+		t.Fatalf("Expect [2 1], got %v\n", vals)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- queue.Put(3, 2)
+	}()
+	if val, err := queue.Get(2); err != nil || val.(int) != 2 {
+		t.Fatalf("Expect 2, got %v (%v)\n", val, err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	for _, expect := range []int{3, 1} {
+		if val, _ := queue.GetNoWait(); val.(int) != expect {
+			t.Fatalf("Expect %v, got %v\n", expect, val)
+		}
+	}
+	if _, err := queue.Get(0.05); err != ErrEmptyQueue {
+		t.Fatalf("Expect %v, got %v\n", ErrEmptyQueue, err)
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestSample(t *testing.T) {
+	queue := New(0)
+	for i := 0; i < 10; i++ {
+		queue.PutNoWait(i)
+	}
+
+	fmt.Println("Test Sample returns distinct values in queue order...")
+	for i := 0; i < 100; i++ {
+		sample := queue.Sample(4)
+		if len(sample) != 4 {
+			t.Fatalf("Expect 4 values, got %d\n", len(sample))
+		}
+		for j := 1; j < len(sample); j++ {
+			if sample[j-1].(int) >= sample[j].(int) {
+				t.Fatalf("Expect values in queue order, got %v\n", sample)
+			}
+		}
+	}
+	if len(queue.Sample(20)) != 10 || queue.Sample(0) != nil {
+		t.Fatalf("Expect Sample bounded by Queue size\n")
+	}
+	if queue.Size() != 10 {
+		t.Fatalf("Expect Sample removes nothing, got size %d\n", queue.Size())
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestReconfigure(t *testing.T) {
+	queue := New(1)
+	queue.PutNoWait(1)
+
+	fmt.Println("Test Reconfigure keeps contents and moves blocked putters in...")
+	done := make(chan error, 1)
+	go func() {
+		done <- queue.Put(2, 2)
+	}()
+	for {
+		queue.mutex.Lock()
+		n := queue.putters.Len()
+		queue.mutex.Unlock()
+		if n != 0 {
+			break
+		}
+	}
+	queue.Reconfigure(WithMaxSize(3), WithSoftLimit(0.5, 0.1))
+	if err := <-done; err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	if queue.MaxSize() != 3 || queue.Size() != 2 {
+		t.Fatalf("Expect max size 3 and size 2, got %d and %d\n", queue.MaxSize(), queue.Size())
+	}
+	if queue.backoff() <= 0 {
+		t.Fatalf("Expect the soft limit applied\n")
+	}
+	queue.Reconfigure(WithSoftLimit(1, 0))
+	if queue.backoff() != 0 {
+		t.Fatalf("Expect the soft limit disabled\n")
+	}
+	for _, expect := range []int{1, 2} {
+		if val, _ := queue.GetNoWait(); val.(int) != expect {
+			t.Fatalf("Expect %v, got %v\n", expect, val)
+		}
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestDurationTimeout(t *testing.T) {
+	queue := New(1)
+
+	fmt.Println("Test GetTimeout and PutTimeout take a time.Duration...")
+	start := time.Now()
+	if _, err := queue.GetTimeout(50 * time.Millisecond); err != ErrEmptyQueue {
+		t.Fatalf("Expect %v, got %v\n", ErrEmptyQueue, err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond || elapsed > time.Second {
+		t.Fatalf("Expect GetTimeout waited 50ms, got %v\n", elapsed)
+	}
+	if err := queue.PutTimeout(1, -1); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	if err := queue.PutTimeout(2, 20*time.Millisecond); err != ErrFullQueue {
+		t.Fatalf("Expect %v, got %v\n", ErrFullQueue, err)
+	}
+	if val, err := queue.GetTimeout(0); err != nil || val.(int) != 1 {
+		t.Fatalf("Expect 1, got %v (%v)\n", val, err)
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestContext(t *testing.T) {
+	queue := New(1)
+
+	fmt.Println("Test GetContext and PutContext stop waiting on cancel...")
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := queue.GetContext(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Expect %v, got %v\n", context.DeadlineExceeded, err)
+	}
+	queue.PutNoWait(1)
+	ctx, cancel = context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- queue.PutContext(ctx, 2)
+	}()
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("Expect %v, got %v\n", context.Canceled, err)
+	}
+	queue.mutex.Lock()
+	if queue.putters.Len() != 0 || queue.getters.Len() != 0 {
+		t.Fatalf("Expect no pending operators, got %d putters %d getters\n",
+			queue.putters.Len(), queue.getters.Len())
+	}
+	queue.mutex.Unlock()
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test GetContext and PutContext succeed before cancel...")
+	ctx, cancel = context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	go func() {
+		done <- queue.PutContext(ctx, 3)
+	}()
+	if val, err := queue.GetContext(ctx); err != nil || val.(int) != 1 {
+		t.Fatalf("Expect 1, got %v (%v)\n", val, err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	if val, err := queue.GetContext(ctx); err != nil || val.(int) != 3 {
+		t.Fatalf("Expect 3, got %v (%v)\n", val, err)
+	}
+	fmt.Println("  ...PASSED")
+}
+
+// Observers used to take the mutex, compare with -cpu 1,4,8 to see they
+// don't contend with Get/Put.
+func BenchmarkObserversUnderLoad(b *testing.B) {
+	queue := New(1024)
+	stop := make(chan struct{})
+	defer close(stop)
+	for i := 0; i < 4; i++ {
+		go func() {
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				queue.PutNoWait(1)
+				queue.GetNoWait()
+			}
+		}()
+	}
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			queue.Size()
+			queue.IsEmpty()
+			queue.IsFull()
+		}
+	})
+}
+
+// Blocked getters reuse pooled waiters, compare allocs/op with -benchmem.
+func BenchmarkBlockingHandoff(b *testing.B) {
+	queue := New(1)
+	go func() {
+		for i := 0; i < b.N; i++ {
+			queue.Put(i, 0)
+		}
+	}()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		queue.Get(0)
+	}
+}
+
+func TestOverwrite(t *testing.T) {
+	var dropped []interface{}
+	queue := New(2, WithOverwrite(), WithHooks(Hooks{OnDrop: func(val interface{}) { dropped = append(dropped, val) }}))
+
+	fmt.Println("Test Put on a full Queue evicts the oldest value...")
+	for i := 0; i < 3; i++ {
+		if err := queue.PutNoWait(i); err != nil {
+			t.Fatalf("Unexpect error: %v\n", err)
+		}
+	}
+	if len(dropped) != 1 || dropped[0].(int) != 0 {
+		t.Fatalf("Expect 0 dropped, got %v\n", dropped)
+	}
+	if vals := queue.PeekN(2); vals[0].(int) != 1 || vals[1].(int) != 2 {
+		t.Fatalf("Expect [1 2], got %v\n", vals)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test PutAll keeps the newest values...")
+	if n, err := queue.PutAll([]interface{}{3, 4, 5}); n != 3 || err != nil {
+		t.Fatalf("Expect 3 values put, got %d (%v)\n", n, err)
+	}
+	if vals := queue.PeekN(2); vals[0].(int) != 4 || vals[1].(int) != 5 {
+		t.Fatalf("Expect [4 5], got %v\n", vals)
+	}
+	if len(dropped) != 4 {
+		t.Fatalf("Expect 4 values dropped, got %v\n", dropped)
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestDrainQueue(t *testing.T) {
+	queue := New(3)
+	queue.PutAll([]interface{}{1, 2, 3})
+	done := make(chan error, 1)
+	go func() { done <- queue.Put(4, 1) }()
+	for {
+		queue.mutex.Lock()
+		n := queue.putters.Len()
+		queue.mutex.Unlock()
+		if n == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	fmt.Println("Test DrainN takes the values at the head...")
+	if vals := queue.DrainN(2); len(vals) != 2 || vals[0].(int) != 1 || vals[1].(int) != 2 {
+		t.Fatalf("Expect [1 2], got %v\n", vals)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test Drain empties Queue...")
+	if vals := queue.Drain(); len(vals) != 2 || vals[0].(int) != 3 || vals[1].(int) != 4 {
+		t.Fatalf("Expect [3 4], got %v\n", vals)
+	}
+	if !queue.IsEmpty() || queue.Drain() != nil {
+		t.Fatalf("Expect Queue is empty\n")
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestToSlice(t *testing.T) {
+	queue := New(0)
+	queue.PutAll([]interface{}{1, 2, 3})
+
+	fmt.Println("Test ToSlice copies the values from front to back...")
+	vals := queue.ToSlice()
+	if len(vals) != 3 || vals[0].(int) != 1 || vals[2].(int) != 3 {
+		t.Fatalf("Expect [1 2 3], got %v\n", vals)
+	}
+	vals[0] = 0
+	if val, _ := queue.Peek(); val.(int) != 1 || queue.Size() != 3 {
+		t.Fatalf("Expect Queue untouched, got head %v and size %d\n", val, queue.Size())
+	}
+	if vals := New(0).ToSlice(); len(vals) != 0 {
+		t.Fatalf("Expect no value, got %v\n", vals)
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestFind(t *testing.T) {
+	queue := New(0)
+	queue.PutAll([]interface{}{"job-1", "job-2", "job-2"})
+
+	fmt.Println("Test Find returns the first matching value...")
+	val, ok := queue.Find(func(val interface{}) bool { return strings.HasSuffix(val.(string), "2") })
+	if !ok || val.(string) != "job-2" || queue.Size() != 3 {
+		t.Fatalf("Expect job-2 found and kept, got %v (%v)\n", val, ok)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test Contains...")
+	if !queue.Contains(func(val interface{}) bool { return val.(string) == "job-1" }) {
+		t.Fatalf("Expect job-1 is queued\n")
+	}
+	if queue.Contains(func(val interface{}) bool { return val.(string) == "job-3" }) {
+		t.Fatalf("Expect job-3 is not queued\n")
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestRange(t *testing.T) {
+	queue := New(0)
+	for i := 0; i < 3; i++ {
+		queue.PutNoWait(i)
+	}
+
+	fmt.Println("Test Range iterates a snapshot without holding the lock...")
+	var seen []interface{}
+	queue.Range(func(val interface{}) bool {
+		seen = append(seen, val)
+		queue.GetNoWait()
+		queue.PutNoWait(10 + val.(int))
+		return true
+	})
+	if len(seen) != 3 || seen[0] != 0 || seen[1] != 1 || seen[2] != 2 {
+		t.Fatalf("Expect [0 1 2], got %v\n", seen)
+	}
+	if vals := queue.ToSlice(); len(vals) != 3 || vals[0] != 10 || vals[2] != 12 {
+		t.Fatalf("Expect [10 11 12], got %v\n", vals)
+	}
+	n := 0
+	queue.Range(func(val interface{}) bool {
+		n++
+		return false
+	})
+	if n != 1 {
+		t.Fatalf("Expect Range stops after 1 value, got %d\n", n)
+	}
+	fmt.Println("  ...PASSED")
+}
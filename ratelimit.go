@@ -0,0 +1,154 @@
+package goqueue
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// Put went over the rate set by WithPutRate.
+var ErrRateLimited = errors.New("rate limited")
+
+// tokenBucket allows rate operations per second, in bursts of up to burst.
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	mutex  sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+}
+
+// take takes up to n tokens available right now, and returns how many.
+func (b *tokenBucket) take(n int) int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.refill(time.Now())
+	if avail := int(b.tokens); n > avail {
+		n = avail
+	}
+	b.tokens -= float64(n)
+	return n
+}
+
+// give puts back n tokens which were not used.
+func (b *tokenBucket) give(n int) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.tokens += float64(n)
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+}
+
+// next returns how long until a token is available.
+func (b *tokenBucket) next() time.Duration {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.refill(time.Now())
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+// acquire takes a token with the timeout semantics of Get, and returns
+// what is left of timeout; it returns false if no token was taken in time
+// or done was closed.
+func (b *tokenBucket) acquire(timeout float64, done <-chan struct{}) (float64, bool) {
+	var deadline time.Time
+	if timeout > 0.0 {
+		deadline = time.Now().Add(seconds(timeout))
+	}
+	for {
+		if b.take(1) == 1 {
+			if timeout > 0.0 {
+				// Keep a try without waiting once timeout is spent.
+				if timeout = time.Until(deadline).Seconds(); timeout <= 0.0 {
+					timeout = -1
+				}
+			}
+			return timeout, true
+		}
+		if timeout < 0.0 {
+			return timeout, false
+		}
+		d := b.next()
+		if timeout > 0.0 {
+			left := time.Until(deadline)
+			if left <= 0 {
+				return timeout, false
+			}
+			if d > left {
+				d = left
+			}
+		}
+		t := acquireTimer(d)
+		select {
+		case <-t.C:
+			releaseTimer(t)
+		case <-done:
+			releaseTimer(t)
+			return timeout, false
+		}
+	}
+}
+
+// WithGetRate limits Get to rate values per second, in bursts of up to
+// burst values. Get waits for its turn with its own timeout semantics, so
+// GetNoWait returns ErrEmptyQueue over the rate. GetN takes as many values
+// as the rate allows. Select, Move and Merge are not limited, and a rate
+// of zero disables it.
+func WithGetRate(rate float64, burst int) Option {
+	return func(q *Queue) {
+		if rate <= 0 {
+			q.getRate.Store((*tokenBucket)(nil))
+			return
+		}
+		q.getRate.Store(newTokenBucket(rate, burst))
+	}
+}
+
+func (q *Queue) getLimiter() *tokenBucket {
+	b, _ := q.getRate.Load().(*tokenBucket)
+	return b
+}
+
+// WithPutRate limits Put to rate values per second, in bursts of up to
+// burst values, regardless of maxSize. Put waits for its turn with its own
+// timeout semantics, and returns ErrRateLimited if it doesn't get it in
+// time, so PutNoWait fails right away over the rate. PutAll puts as many
+// values as the rate allows. A rate of zero disables it.
+func WithPutRate(rate float64, burst int) Option {
+	return func(q *Queue) {
+		if rate <= 0 {
+			q.putRate.Store((*tokenBucket)(nil))
+			return
+		}
+		q.putRate.Store(newTokenBucket(rate, burst))
+	}
+}
+
+func (q *Queue) putLimiter() *tokenBucket {
+	b, _ := q.putRate.Load().(*tokenBucket)
+	return b
+}
@@ -0,0 +1,110 @@
+package goqueue
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestGetRate(t *testing.T) {
+	queue := New(0, WithGetRate(20, 2))
+	for i := 0; i < 10; i++ {
+		queue.PutNoWait(i)
+	}
+
+	fmt.Println("Test GetNoWait is refused over the rate after the burst...")
+	for i := 0; i < 2; i++ {
+		if _, err := queue.GetNoWait(); err != nil {
+			t.Fatalf("Unexpect error: %v\n", err)
+		}
+	}
+	if _, err := queue.GetNoWait(); err != ErrEmptyQueue {
+		t.Fatalf("Expect %v, got %v\n", ErrEmptyQueue, err)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test Get waits for its turn...")
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if _, err := queue.Get(1); err != nil {
+			t.Fatalf("Unexpect error: %v\n", err)
+		}
+	}
+	if d := time.Since(start); d < 150*time.Millisecond {
+		t.Fatalf("Expect 4 values at 20/s take 150ms at least, took %v\n", d)
+	}
+	if _, err := queue.Get(0.01); err != ErrEmptyQueue {
+		t.Fatalf("Expect %v, got %v\n", ErrEmptyQueue, err)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test GetN takes as many values as the rate allows...")
+	time.Sleep(100 * time.Millisecond)
+	if vals, err := queue.GetN(4, -1); err != nil || len(vals) != 2 {
+		t.Fatalf("Expect 2 values, got %v (%v)\n", vals, err)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test an empty Queue gives the token back...")
+	queue.Reconfigure(WithGetRate(0, 0))
+	queue.GetN(10, -1)
+	queue.Reconfigure(WithGetRate(1, 1))
+	queue.GetNoWait()
+	queue.PutNoWait("x")
+	if val, err := queue.GetNoWait(); err != nil || val.(string) != "x" {
+		t.Fatalf("Expect x, got %v (%v)\n", val, err)
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestPutRate(t *testing.T) {
+	queue := New(0, WithPutRate(20, 2))
+
+	fmt.Println("Test PutNoWait is refused over the rate after the burst...")
+	for i := 0; i < 2; i++ {
+		if err := queue.PutNoWait(i); err != nil {
+			t.Fatalf("Unexpect error: %v\n", err)
+		}
+	}
+	if err := queue.PutNoWait(2); err != ErrRateLimited {
+		t.Fatalf("Expect %v, got %v\n", ErrRateLimited, err)
+	}
+	if err := queue.Put(2, 0.01); err != ErrRateLimited {
+		t.Fatalf("Expect %v, got %v\n", ErrRateLimited, err)
+	}
+	if stats := queue.Stats(); stats.Rejected != 2 {
+		t.Fatalf("Expect 2 rejected, got %d\n", stats.Rejected)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test Put waits for its turn...")
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if err := queue.Put(i, 0); err != nil {
+			t.Fatalf("Unexpect error: %v\n", err)
+		}
+	}
+	if d := time.Since(start); d < 150*time.Millisecond {
+		t.Fatalf("Expect 4 values at 20/s take 150ms at least, took %v\n", d)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test PutAll puts as many values as the rate allows...")
+	time.Sleep(100 * time.Millisecond)
+	if n, err := queue.PutAll([]interface{}{1, 2, 3}); n != 2 || err != ErrRateLimited {
+		t.Fatalf("Expect 2 values and %v, got %d (%v)\n", ErrRateLimited, n, err)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test a full Queue gives the token back...")
+	queue = New(1, WithPutRate(1, 2))
+	queue.PutNoWait(1)
+	if err := queue.PutNoWait(2); err != ErrFullQueue {
+		t.Fatalf("Expect %v, got %v\n", ErrFullQueue, err)
+	}
+	queue.GetNoWait()
+	if err := queue.PutNoWait(2); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	fmt.Println("  ...PASSED")
+}
@@ -0,0 +1,181 @@
+/*
+Package redisqueue implements the goqueue API on top of a Redis list, so a
+queue can be shared by many processes.
+
+Values are pushed with LPUSH and popped with RPOP/BRPOP, the Redis client
+itself is provided by the application through the Client interface. With
+redigo for example:
+
+	type pool struct{ p *redis.Pool }
+
+	func (c pool) Do(cmd string, args ...interface{}) (interface{}, error) {
+		conn := c.p.Get()
+		defer conn.Close()
+		return conn.Do(cmd, args...)
+	}
+*/
+
+package redisqueue
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/damnever/goqueue"
+)
+
+// Client runs a Redis command and returns the reply as redigo does: int64
+// for integers, []byte for bulk strings, []interface{} for arrays and nil
+// for a nil reply. It must be safe for concurrent use and run each command
+// on a connection of its own (a pool), since BRPOP blocks the connection.
+type Client interface {
+	Do(cmd string, args ...interface{}) (interface{}, error)
+}
+
+// pushScript pushes ARGV[2] unless the list already holds ARGV[1] items,
+// the check and the push are atomic.
+const pushScript = `
+local max = tonumber(ARGV[1])
+if max > 0 and redis.call('LLEN', KEYS[1]) >= max then
+	return -1
+end
+return redis.call('LPUSH', KEYS[1], ARGV[2])`
+
+type Queue struct {
+	client  Client
+	key     string
+	maxSize int
+
+	// Codec used to store values, default is goqueue.GobCodec.
+	Codec goqueue.Codec
+	// PollInterval is the seconds between two tries of a blocking Put,
+	// default is 0.1. A blocking Get waits in BRPOP.
+	PollInterval float64
+}
+
+// New create a Queue stored in the list at key. The maxSize variable sets
+// the max Queue size, if maxSize is zero, Queue will be infinite size.
+func New(client Client, key string, maxSize int) *Queue {
+	return &Queue{
+		client:       client,
+		key:          key,
+		maxSize:      maxSize,
+		Codec:        goqueue.GobCodec{},
+		PollInterval: 0.1,
+	}
+}
+
+// Same as Get(-1).
+func (q *Queue) GetNoWait() (interface{}, error) {
+	return q.Get(-1)
+}
+
+// Get has the same timeout semantics as goqueue.Queue.Get, fractional
+// timeouts need Redis 6 or later.
+func (q *Queue) Get(timeout float64) (interface{}, error) {
+	var reply interface{}
+	var err error
+	if timeout < 0.0 {
+		reply, err = q.client.Do("RPOP", q.key)
+	} else {
+		reply, err = q.client.Do("BRPOP", q.key, strconv.FormatFloat(timeout, 'f', -1, 64))
+		if items, ok := reply.([]interface{}); ok && len(items) == 2 {
+			reply = items[1]
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if reply == nil {
+		return nil, goqueue.ErrEmptyQueue
+	}
+	data, ok := reply.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("redisqueue: unexpected reply %T", reply)
+	}
+	return q.Codec.Unmarshal(data)
+}
+
+// Same as Put(val, -1).
+func (q *Queue) PutNoWait(val interface{}) error {
+	return q.Put(val, -1)
+}
+
+// Put has the same timeout semantics as goqueue.Queue.Put, a blocking Put
+// polls every PollInterval seconds while Queue is full.
+func (q *Queue) Put(val interface{}, timeout float64) error {
+	data, err := q.Codec.Marshal(val)
+	if err != nil {
+		return err
+	}
+	deadline := time.Now().Add(time.Duration(timeout * float64(time.Second)))
+	interval := time.Duration(q.PollInterval * float64(time.Second))
+	for {
+		ok, err := q.push(data)
+		if err != nil || ok {
+			return err
+		}
+		if timeout < 0.0 {
+			return goqueue.ErrFullQueue
+		}
+		wait := interval
+		if timeout > 0.0 {
+			left := deadline.Sub(time.Now())
+			if left <= 0 {
+				return goqueue.ErrFullQueue
+			}
+			if left < wait {
+				wait = left
+			}
+		}
+		time.Sleep(wait)
+	}
+}
+
+func (q *Queue) push(data []byte) (bool, error) {
+	if q.maxSize <= 0 {
+		_, err := q.client.Do("LPUSH", q.key, data)
+		return err == nil, err
+	}
+	reply, err := q.client.Do("EVAL", pushScript, 1, q.key, q.maxSize, data)
+	if err != nil {
+		return false, err
+	}
+	n, ok := reply.(int64)
+	if !ok {
+		return false, errors.New("redisqueue: unexpected reply to push")
+	}
+	return n >= 0, nil
+}
+
+// Count returns the number of items in the Queue.
+func (q *Queue) Count() (int, error) {
+	reply, err := q.client.Do("LLEN", q.key)
+	if err != nil {
+		return 0, err
+	}
+	n, ok := reply.(int64)
+	if !ok {
+		return 0, fmt.Errorf("redisqueue: unexpected reply %T", reply)
+	}
+	return int(n), nil
+}
+
+// Return size of Queue, or 0 if Redis can't be queried, use Count to get
+// the error.
+func (q *Queue) Size() int {
+	n, _ := q.Count()
+	return n
+}
+
+// Return true if Queue is empty.
+func (q *Queue) IsEmpty() bool {
+	return q.Size() == 0
+}
+
+// Return true if Queue is full.
+func (q *Queue) IsFull() bool {
+	return q.maxSize > 0 && q.maxSize <= q.Size()
+}
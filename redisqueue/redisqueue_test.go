@@ -0,0 +1,135 @@
+package redisqueue
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/damnever/goqueue"
+)
+
+// fakeRedis implements the few list commands used by Queue.
+type fakeRedis struct {
+	mutex sync.Mutex
+	cond  *sync.Cond
+	lists map[string][][]byte
+}
+
+var _ goqueue.Interface = (*Queue)(nil)
+
+func newFakeRedis() *fakeRedis {
+	r := &fakeRedis{lists: make(map[string][][]byte)}
+	r.cond = sync.NewCond(&r.mutex)
+	return r
+}
+
+func (r *fakeRedis) Do(cmd string, args ...interface{}) (interface{}, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	switch cmd {
+	case "LPUSH":
+		key := args[0].(string)
+		r.lists[key] = append([][]byte{args[1].([]byte)}, r.lists[key]...)
+		r.cond.Broadcast()
+		return int64(len(r.lists[key])), nil
+	case "EVAL":
+		key, max := args[2].(string), args[3].(int)
+		if len(r.lists[key]) >= max {
+			return int64(-1), nil
+		}
+		r.lists[key] = append([][]byte{args[4].([]byte)}, r.lists[key]...)
+		r.cond.Broadcast()
+		return int64(len(r.lists[key])), nil
+	case "RPOP":
+		return r.pop(args[0].(string)), nil
+	case "BRPOP":
+		key := args[0].(string)
+		timeout, _ := strconv.ParseFloat(args[1].(string), 64)
+		deadline := time.Now().Add(time.Duration(timeout * float64(time.Second)))
+		for len(r.lists[key]) == 0 {
+			if timeout > 0 && !time.Now().Before(deadline) {
+				return nil, nil
+			}
+			go func() {
+				time.Sleep(10 * time.Millisecond)
+				r.cond.Broadcast()
+			}()
+			r.cond.Wait()
+		}
+		return []interface{}{[]byte(key), r.pop(key)}, nil
+	case "LLEN":
+		return int64(len(r.lists[args[0].(string)])), nil
+	}
+	return nil, fmt.Errorf("unknown command %s", cmd)
+}
+
+func (r *fakeRedis) pop(key string) interface{} {
+	l := r.lists[key]
+	if len(l) == 0 {
+		return nil
+	}
+	r.lists[key] = l[:len(l)-1]
+	return l[len(l)-1]
+}
+
+func TestFIFO(t *testing.T) {
+	q := New(newFakeRedis(), "items", 0)
+
+	fmt.Println("Test values are got in FIFO order...")
+	for i := 0; i < 3; i++ {
+		if err := q.PutNoWait(i); err != nil {
+			t.Fatalf("Unexpect error: %v\n", err)
+		}
+	}
+	if q.Size() != 3 {
+		t.Fatalf("Expect Queue size %d, got %d\n", 3, q.Size())
+	}
+	for i := 0; i < 3; i++ {
+		val, err := q.GetNoWait()
+		if err != nil {
+			t.Fatalf("Unexpect error: %v\n", err)
+		} else if val.(int) != i {
+			t.Fatalf("Expect %v, got %v\n", i, val)
+		}
+	}
+	if _, err := q.GetNoWait(); err != goqueue.ErrEmptyQueue {
+		t.Fatalf("Expect %v, got %v\n", goqueue.ErrEmptyQueue, err)
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestBlockGetPut(t *testing.T) {
+	q := New(newFakeRedis(), "items", 1)
+	q.PollInterval = 0.01
+
+	fmt.Println("Test Put on a full Queue...")
+	q.PutNoWait("a")
+	if err := q.PutNoWait("b"); err != goqueue.ErrFullQueue {
+		t.Fatalf("Expect %v, got %v\n", goqueue.ErrFullQueue, err)
+	}
+	if err := q.Put("b", 0.05); err != goqueue.ErrFullQueue {
+		t.Fatalf("Expect %v, got %v\n", goqueue.ErrFullQueue, err)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test blocking Get and Put...")
+	done := make(chan error, 1)
+	go func() {
+		done <- q.Put("b", 2)
+	}()
+	if val, err := q.Get(2); err != nil || val.(string) != "a" {
+		t.Fatalf("Expect a, got %v (%v)\n", val, err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	if val, err := q.Get(0); err != nil || val.(string) != "b" {
+		t.Fatalf("Expect b, got %v (%v)\n", val, err)
+	}
+	if _, err := q.Get(0.05); err != goqueue.ErrEmptyQueue {
+		t.Fatalf("Expect %v, got %v\n", goqueue.ErrEmptyQueue, err)
+	}
+	fmt.Println("  ...PASSED")
+}
@@ -0,0 +1,180 @@
+package goqueue
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// The delivery was acked, nacked or timed out already.
+var ErrNotInFlight = errors.New("delivery is not in flight")
+
+type reliableItem struct {
+	value    interface{}
+	attempts int
+}
+
+// ReliableQueue gives at-least-once delivery: Get hands out a Delivery
+// which must be acked once the value is processed, a value which is not
+// acked within the visibility timeout, or which is nacked, is put back at
+// the front of the queue for the next Get.
+type ReliableQueue struct {
+	queue      *Queue
+	visibility time.Duration
+	mutex      sync.Mutex
+	seq        uint64
+	inflight   map[uint64]*Delivery
+}
+
+// Delivery is a value got from a ReliableQueue.
+type Delivery struct {
+	Value interface{}
+	// Attempts is the number of times the value was delivered, this
+	// delivery included.
+	Attempts int
+
+	q     *ReliableQueue
+	id    uint64
+	timer *time.Timer
+}
+
+// NewReliable create a ReliableQueue whose deliveries are visible again
+// after visibility seconds. The maxSize variable bounds the values waiting
+// to be got, values put back may go over it; in flight values don't count.
+func NewReliable(maxSize int, visibility float64) *ReliableQueue {
+	return &ReliableQueue{
+		queue:      New(maxSize),
+		visibility: seconds(visibility),
+		inflight:   make(map[uint64]*Delivery),
+	}
+}
+
+// Same as Get(-1).
+func (q *ReliableQueue) GetNoWait() (*Delivery, error) {
+	return q.Get(-1)
+}
+
+// Get has the same timeout semantics as Queue.Get.
+func (q *ReliableQueue) Get(timeout float64) (*Delivery, error) {
+	val, err := q.queue.Get(timeout)
+	if err != nil {
+		return nil, err
+	}
+	item := val.(*reliableItem)
+	item.attempts++
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.seq++
+	d := &Delivery{Value: item.value, Attempts: item.attempts, q: q, id: q.seq}
+	q.inflight[d.id] = d
+	d.timer = time.AfterFunc(q.visibility, func() {
+		if q.settle(d) {
+			q.queue.putBack(item)
+		}
+	})
+	return d, nil
+}
+
+// settle takes d off the in flight deliveries, it returns false if it was
+// settled already.
+func (q *ReliableQueue) settle(d *Delivery) bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if _, ok := q.inflight[d.id]; !ok {
+		return false
+	}
+	delete(q.inflight, d.id)
+	d.timer.Stop()
+	return true
+}
+
+// ID identifies the delivery, IDs grow with every Get, see AckUpTo.
+func (d *Delivery) ID() uint64 {
+	return d.id
+}
+
+// AckBatch acks the in flight deliveries of ids under a single lock
+// acquisition, and returns how many were in flight.
+func (q *ReliableQueue) AckBatch(ids []uint64) int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	n := 0
+	for _, id := range ids {
+		if d, ok := q.inflight[id]; ok {
+			delete(q.inflight, id)
+			d.timer.Stop()
+			n++
+		}
+	}
+	return n
+}
+
+// AckUpTo acks every in flight delivery whose ID is not greater than id,
+// and returns how many were acked.
+func (q *ReliableQueue) AckUpTo(id uint64) int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	n := 0
+	for did, d := range q.inflight {
+		if did <= id {
+			delete(q.inflight, did)
+			d.timer.Stop()
+			n++
+		}
+	}
+	return n
+}
+
+// Ack marks the value as processed, it won't be delivered again.
+func (d *Delivery) Ack() error {
+	if !d.q.settle(d) {
+		return ErrNotInFlight
+	}
+	return nil
+}
+
+// Nack puts the value back at the front of the queue right away.
+func (d *Delivery) Nack() error {
+	if !d.q.settle(d) {
+		return ErrNotInFlight
+	}
+	d.q.queue.putBack(&reliableItem{value: d.Value, attempts: d.Attempts})
+	return nil
+}
+
+// NackAfter is Nack which puts the value back after delay seconds, e.g. the
+// Retry-After of a downstream service. Meanwhile the value is neither in
+// flight nor waiting to be got.
+func (d *Delivery) NackAfter(delay float64) error {
+	if !d.q.settle(d) {
+		return ErrNotInFlight
+	}
+	item := &reliableItem{value: d.Value, attempts: d.Attempts}
+	time.AfterFunc(seconds(delay), func() {
+		d.q.queue.putBack(item)
+	})
+	return nil
+}
+
+// Same as Put(val, -1).
+func (q *ReliableQueue) PutNoWait(val interface{}) error {
+	return q.Put(val, -1)
+}
+
+// Put has the same timeout semantics as Queue.Put.
+func (q *ReliableQueue) Put(val interface{}, timeout float64) error {
+	return q.queue.Put(&reliableItem{value: val}, timeout)
+}
+
+// Return the number of values waiting to be got.
+func (q *ReliableQueue) Size() int {
+	return q.queue.Size()
+}
+
+// Return the number of values delivered but not settled yet.
+func (q *ReliableQueue) InFlight() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return len(q.inflight)
+}
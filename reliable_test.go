@@ -0,0 +1,122 @@
+package goqueue
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestReliableAckNack(t *testing.T) {
+	queue := NewReliable(0, 10)
+	queue.PutNoWait("a")
+	queue.PutNoWait("b")
+
+	fmt.Println("Test nacked values are delivered again first...")
+	d, err := queue.GetNoWait()
+	if err != nil || d.Value.(string) != "a" || d.Attempts != 1 {
+		t.Fatalf("Expect a, got %v (%v)\n", d, err)
+	}
+	if queue.InFlight() != 1 {
+		t.Fatalf("Expect 1 in flight, got %d\n", queue.InFlight())
+	}
+	if err := d.Nack(); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	if err := d.Ack(); err != ErrNotInFlight {
+		t.Fatalf("Expect %v, got %v\n", ErrNotInFlight, err)
+	}
+	d, _ = queue.GetNoWait()
+	if d.Value.(string) != "a" || d.Attempts != 2 {
+		t.Fatalf("Expect a on attempt 2, got %v on %d\n", d.Value, d.Attempts)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test acked values are gone...")
+	if err := d.Ack(); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	d, _ = queue.GetNoWait()
+	d.Ack()
+	if _, err := queue.GetNoWait(); err != ErrEmptyQueue || queue.InFlight() != 0 {
+		t.Fatalf("Expect %v, got %v\n", ErrEmptyQueue, err)
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestReliableVisibility(t *testing.T) {
+	queue := NewReliable(1, 0.05)
+	queue.PutNoWait("a")
+
+	fmt.Println("Test unacked values are visible again after the timeout...")
+	d, _ := queue.GetNoWait()
+	again, err := queue.Get(2)
+	if err != nil || again.Value.(string) != "a" || again.Attempts != 2 {
+		t.Fatalf("Expect a on attempt 2, got %v (%v)\n", again, err)
+	}
+	if err := d.Ack(); err != ErrNotInFlight {
+		t.Fatalf("Expect %v, got %v\n", ErrNotInFlight, err)
+	}
+	again.Ack()
+	queue.PutNoWait("b")
+	if d, _ := queue.GetNoWait(); d == nil || d.Value.(string) != "b" {
+		t.Fatalf("Expect b\n")
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestReliableAckBatch(t *testing.T) {
+	queue := NewReliable(0, 10)
+	for i := 0; i < 5; i++ {
+		queue.PutNoWait(i)
+	}
+	ds := make([]*Delivery, 5)
+	for i := range ds {
+		ds[i], _ = queue.GetNoWait()
+	}
+
+	fmt.Println("Test AckBatch and AckUpTo ack many deliveries at once...")
+	if n := queue.AckBatch([]uint64{ds[1].ID(), ds[3].ID(), ds[3].ID()}); n != 2 {
+		t.Fatalf("Expect %d acked, got %d\n", 2, n)
+	}
+	if err := ds[1].Ack(); err != ErrNotInFlight {
+		t.Fatalf("Expect %v, got %v\n", ErrNotInFlight, err)
+	}
+	if n := queue.AckUpTo(ds[3].ID()); n != 2 {
+		t.Fatalf("Expect %d acked, got %d\n", 2, n)
+	}
+	if queue.InFlight() != 1 {
+		t.Fatalf("Expect 1 in flight, got %d\n", queue.InFlight())
+	}
+	if err := ds[4].Ack(); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestReliableNackAfter(t *testing.T) {
+	queue := NewReliable(0, 10)
+	queue.PutNoWait("a")
+
+	fmt.Println("Test a value nacked with a delay is delivered again after it...")
+	d, _ := queue.GetNoWait()
+	start := time.Now()
+	if err := d.NackAfter(0.1); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	if err := d.NackAfter(0.1); err != ErrNotInFlight {
+		t.Fatalf("Expect %v, got %v\n", ErrNotInFlight, err)
+	}
+	if _, err := queue.GetNoWait(); err != ErrEmptyQueue {
+		t.Fatalf("Expect %v, got %v\n", ErrEmptyQueue, err)
+	}
+	d, err := queue.Get(2)
+	if err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	} else if d.Value.(string) != "a" || d.Attempts != 2 {
+		t.Fatalf("Expect a on attempt 2, got %v on %d\n", d.Value, d.Attempts)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Fatalf("Expect the value back after 100ms, got it after %v\n", elapsed)
+	}
+	fmt.Println("  ...PASSED")
+}
@@ -0,0 +1,200 @@
+package goqueue
+
+import (
+	"crypto/rand"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+func init() {
+	gob.Register(&Request{})
+	gob.Register(&Reply{})
+}
+
+// No reply arrived in time.
+var ErrNoReply = errors.New("no reply")
+
+// Resolver returns the queue named name, Request.Reply sends replies to it.
+type Resolver interface {
+	Resolve(name string) (Putter, error)
+}
+
+// ResolverFunc is a function used as Resolver.
+type ResolverFunc func(name string) (Putter, error)
+
+func (f ResolverFunc) Resolve(name string) (Putter, error) {
+	return f(name)
+}
+
+// Request is the envelope put by Requester.Request. ReplyTo is the name of
+// the reply queue rather than the queue itself, so the envelope can go
+// through durable and networked backends, the Body must be encodable by
+// their Codec too.
+type Request struct {
+	CorrelationID string
+	ReplyTo       string
+	Body          interface{}
+}
+
+// Reply sends the response of the request to the queue resolver returns for
+// its ReplyTo, a non nil err is delivered to the requester as its message.
+// The timeout semantics are the same as Queue.Put.
+func (r *Request) Reply(resolver Resolver, body interface{}, err error, timeout float64) error {
+	q, rerr := resolver.Resolve(r.ReplyTo)
+	if rerr != nil {
+		return rerr
+	}
+	reply := &Reply{CorrelationID: r.CorrelationID, Body: body}
+	if err != nil {
+		reply.Err = err.Error()
+	}
+	return q.Put(reply, timeout)
+}
+
+// Reply is the envelope put into the reply queue.
+type Reply struct {
+	CorrelationID string
+	Body          interface{}
+	Err           string
+}
+
+// Requester puts requests into queues and matches the replies that arrive on
+// its own reply queue by correlation ID.
+type Requester struct {
+	replies Getter
+	replyTo string
+	prefix  string
+	mutex   sync.Mutex
+	seq     uint64
+	pending map[string]chan *Reply
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+// NewRequester create a Requester whose replies are sent to the queue named
+// replyTo and read from replies, which is usually that queue. Replies are
+// read by a goroutine until Close is called, so nothing else should Get from
+// it.
+func NewRequester(replies Getter, replyTo string) *Requester {
+	b := make([]byte, 8)
+	rand.Read(b)
+	r := &Requester{
+		replies: replies,
+		replyTo: replyTo,
+		prefix:  hex.EncodeToString(b),
+		pending: make(map[string]chan *Reply),
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go r.dispatch()
+	return r
+}
+
+func (r *Requester) dispatch() {
+	defer close(r.stopped)
+	for {
+		select {
+		case <-r.stop:
+			return
+		default:
+		}
+		val, err := r.replies.Get(0.1)
+		if err != nil {
+			continue
+		}
+		reply, ok := val.(*Reply)
+		if !ok {
+			continue
+		}
+		r.mutex.Lock()
+		ch, ok := r.pending[reply.CorrelationID]
+		r.mutex.Unlock()
+		// The entry stays until AwaitReply takes the reply, replies nobody
+		// waits for any more and duplicates are dropped.
+		if ok {
+			select {
+			case ch <- reply:
+			default:
+			}
+		}
+	}
+}
+
+// Request puts a *Request wrapping body into q and returns its correlation
+// ID for AwaitReply. The timeout semantics are the same as Queue.Put.
+func (r *Requester) Request(q Putter, body interface{}, timeout float64) (string, error) {
+	r.mutex.Lock()
+	r.seq++
+	id := fmt.Sprintf("%s-%d", r.prefix, r.seq)
+	r.pending[id] = make(chan *Reply, 1)
+	r.mutex.Unlock()
+
+	req := &Request{CorrelationID: id, ReplyTo: r.replyTo, Body: body}
+	if err := q.Put(req, timeout); err != nil {
+		r.forget(id)
+		return "", err
+	}
+	return id, nil
+}
+
+func (r *Requester) forget(id string) {
+	r.mutex.Lock()
+	delete(r.pending, id)
+	r.mutex.Unlock()
+}
+
+// AwaitReply waits for the reply of the request id, the timeout semantics
+// are the same as Queue.Get but ErrNoReply is returned on timeout. It
+// returns the error sent by Request.Reply if any. Each id can be awaited
+// only once.
+func (r *Requester) AwaitReply(id string, timeout float64) (interface{}, error) {
+	r.mutex.Lock()
+	ch, ok := r.pending[id]
+	r.mutex.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown correlation id %q", id)
+	}
+
+	var reply *Reply
+	if timeout < 0.0 {
+		select {
+		case reply = <-ch:
+		default:
+			return nil, ErrNoReply
+		}
+	} else if timeout == 0.0 {
+		reply = <-ch
+	} else {
+		t := acquireTimer(seconds(timeout))
+		defer releaseTimer(t)
+		select {
+		case reply = <-ch:
+		case <-t.C:
+			r.forget(id)
+			return nil, ErrNoReply
+		}
+	}
+	r.forget(id)
+	if reply.Err != "" {
+		return reply.Body, errors.New(reply.Err)
+	}
+	return reply.Body, nil
+}
+
+// Call is Request followed by AwaitReply with the same timeout.
+func (r *Requester) Call(q Putter, body interface{}, timeout float64) (interface{}, error) {
+	id, err := r.Request(q, body, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return r.AwaitReply(id, timeout)
+}
+
+// Close stops reading the reply queue.
+func (r *Requester) Close() {
+	close(r.stop)
+	<-r.stopped
+}
@@ -0,0 +1,125 @@
+package goqueue
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func serve(requests *Queue, replies Resolver, n int) {
+	for i := 0; i < n; i++ {
+		val, _ := requests.Get(0)
+		req := val.(*Request)
+		if req.Body.(int) < 0 {
+			req.Reply(replies, nil, errors.New("negative"), -1)
+		} else {
+			req.Reply(replies, req.Body.(int)*2, nil, -1)
+		}
+	}
+}
+
+var _ Resolver = (*Manager)(nil)
+
+func TestRequestReply(t *testing.T) {
+	m := NewManager(Config{})
+	requests := m.Open("requests")
+	replies := m.Open("replies")
+	requester := NewRequester(replies, "replies")
+	defer requester.Close()
+
+	fmt.Println("Test the envelopes are encodable by GobCodec...")
+	codec := GobCodec{}
+	data, err := codec.Marshal(&Request{CorrelationID: "1", ReplyTo: "replies", Body: 1})
+	if err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	val, err := codec.Unmarshal(data)
+	if err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	if req := val.(*Request); req.ReplyTo != "replies" || req.Body.(int) != 1 {
+		t.Fatalf("Expect the request back, got %+v\n", req)
+	}
+	if data, err = codec.Marshal(&Reply{CorrelationID: "1", Err: "failed"}); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	if val, err = codec.Unmarshal(data); err != nil || val.(*Reply).Err != "failed" {
+		t.Fatalf("Expect the reply back, got %v (%v)\n", val, err)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test Reply to an unknown queue...")
+	req := &Request{CorrelationID: "1", ReplyTo: "missing"}
+	if err := req.Reply(m, 1, nil, -1); err != ErrNoQueue {
+		t.Fatalf("Expect %v, got %v\n", ErrNoQueue, err)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test replies are matched to concurrent requests...")
+	go serve(requests, m, 20)
+	wg := &sync.WaitGroup{}
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			val, err := requester.Call(requests, i, 2)
+			if err != nil {
+				errs <- err
+			} else if val.(int) != i*2 {
+				errs <- fmt.Errorf("expect %d, got %v", i*2, val)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test error reply...")
+	go serve(requests, m, 1)
+	if _, err := requester.Call(requests, -1, 2); err == nil || err.Error() != "negative" {
+		t.Fatalf("Expect error %q, got %v\n", "negative", err)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test AwaitReply times out without a reply...")
+	id, err := requester.Request(requests, 1, -1)
+	if err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	if _, err := requester.AwaitReply(id, -1); err != ErrNoReply {
+		t.Fatalf("Expect %v, got %v\n", ErrNoReply, err)
+	}
+	if _, err := requester.AwaitReply(id, 0.05); err != ErrNoReply {
+		t.Fatalf("Expect %v, got %v\n", ErrNoReply, err)
+	}
+	if _, err := requester.AwaitReply(id, 0.05); err == nil || err == ErrNoReply {
+		t.Fatalf("Expect unknown id error, got %v\n", err)
+	}
+	// A late reply is dropped.
+	serve(requests, m, 1)
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test a reply which arrives before AwaitReply...")
+	id, err = requester.Request(requests, 3, -1)
+	if err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	serve(requests, m, 1)
+	for replies.Size() != 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if val, err := requester.AwaitReply(id, 1); err != nil || val.(int) != 6 {
+		t.Fatalf("Expect 6, got %v (%v)\n", val, err)
+	}
+	if _, err := requester.AwaitReply(id, -1); err == nil || err == ErrNoReply {
+		t.Fatalf("Expect unknown id error, got %v\n", err)
+	}
+	fmt.Println("  ...PASSED")
+}
@@ -0,0 +1,113 @@
+package goqueue
+
+import (
+	"errors"
+	"time"
+)
+
+// The value ran out of attempts.
+var ErrMaxAttempts = errors.New("max attempts reached")
+
+// DefaultMaxAttempts is used when RetryQueue.MaxAttempts is zero.
+const DefaultMaxAttempts = 3
+
+// ExponentialBackoff returns 1s, 2s, 4s, ... capped at one minute.
+func ExponentialBackoff(attempts int) time.Duration {
+	d := time.Second
+	for i := 1; i < attempts && d < time.Minute; i++ {
+		d *= 2
+	}
+	if d > time.Minute {
+		d = time.Minute
+	}
+	return d
+}
+
+// Attempt is a value got from a RetryQueue.
+type Attempt struct {
+	Value interface{}
+	// Attempts is the number of times the value was got, this one included.
+	Attempts int
+}
+
+// RetryQueue is a ScheduledQueue which puts failed values back with a
+// backoff, until they run out of attempts.
+type RetryQueue struct {
+	queue *ScheduledQueue
+
+	// MaxAttempts is the max number of times a value is got, zero means
+	// DefaultMaxAttempts.
+	MaxAttempts int
+	// Backoff returns the delay before a value which failed attempts times
+	// is got again, default is ExponentialBackoff.
+	Backoff func(attempts int) time.Duration
+	// Jitter randomizes the Backoff delays, default is EqualJitter, nil
+	// disables it.
+	Jitter Jitter
+	// DeadLetter receives the values which ran out of attempts, they are
+	// dropped if it is nil or full.
+	DeadLetter *Queue
+}
+
+// NewRetry create a RetryQueue with a new ScheduledQueue of maxSize, values
+// waiting for their retry included.
+func NewRetry(maxSize int) *RetryQueue {
+	return &RetryQueue{
+		queue:   NewScheduled(maxSize),
+		Backoff: ExponentialBackoff,
+		Jitter:  EqualJitter,
+	}
+}
+
+// Same as Get(-1).
+func (q *RetryQueue) GetNoWait() (*Attempt, error) {
+	return q.Get(-1)
+}
+
+// Get has the same timeout semantics as Queue.Get, values waiting for
+// their retry are not visible.
+func (q *RetryQueue) Get(timeout float64) (*Attempt, error) {
+	val, err := q.queue.Get(timeout)
+	if err != nil {
+		return nil, err
+	}
+	a := val.(*Attempt)
+	a.Attempts++
+	return a, nil
+}
+
+// Same as Put(val, -1).
+func (q *RetryQueue) PutNoWait(val interface{}) error {
+	return q.Put(val, -1)
+}
+
+// Put has the same timeout semantics as Queue.Put.
+func (q *RetryQueue) Put(val interface{}, timeout float64) error {
+	return q.queue.Put(&Attempt{Value: val}, timeout)
+}
+
+// Retry puts a failed attempt back after the backoff. Once the value ran
+// out of attempts, it is moved to DeadLetter and ErrMaxAttempts returned.
+// The timeout semantics are the same as Queue.Put.
+func (q *RetryQueue) Retry(a *Attempt, timeout float64) error {
+	max := q.MaxAttempts
+	if max <= 0 {
+		max = DefaultMaxAttempts
+	}
+	if a.Attempts >= max {
+		if q.DeadLetter != nil {
+			q.DeadLetter.PutNoWait(a.Value)
+		}
+		return ErrMaxAttempts
+	}
+	delay := q.Backoff(a.Attempts)
+	if q.Jitter != nil {
+		delay = q.Jitter(delay)
+	}
+	return q.queue.PutScheduled(a, time.Now().Add(delay), 0, timeout)
+}
+
+// Return size of RetryQueue, values waiting for their retry included.
+func (q *RetryQueue) Size() int {
+	return q.queue.Size()
+}
@@ -0,0 +1,57 @@
+package goqueue
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRetryQueue(t *testing.T) {
+	queue := NewRetry(0)
+	queue.Backoff = func(attempts int) time.Duration { return time.Duration(attempts) * 50 * time.Millisecond }
+	queue.Jitter = nil
+	queue.DeadLetter = New(0)
+	queue.PutNoWait("job")
+
+	fmt.Println("Test a failed value is got again after the backoff...")
+	a, err := queue.GetNoWait()
+	if err != nil || a.Value.(string) != "job" || a.Attempts != 1 {
+		t.Fatalf("Expect job on attempt 1, got %v (%v)\n", a, err)
+	}
+	if err := queue.Retry(a, -1); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	if _, err := queue.GetNoWait(); err != ErrEmptyQueue {
+		t.Fatalf("Expect %v, got %v\n", ErrEmptyQueue, err)
+	}
+	if a, err = queue.Get(2); err != nil || a.Attempts != 2 {
+		t.Fatalf("Expect attempt 2, got %v (%v)\n", a, err)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test the value is dead lettered after max attempts...")
+	queue.Retry(a, -1)
+	a, _ = queue.Get(2)
+	if err := queue.Retry(a, -1); err != ErrMaxAttempts {
+		t.Fatalf("Expect %v, got %v\n", ErrMaxAttempts, err)
+	}
+	if val, err := queue.DeadLetter.GetNoWait(); err != nil || val.(string) != "job" {
+		t.Fatalf("Expect job dead lettered, got %v (%v)\n", val, err)
+	}
+	if queue.Size() != 0 {
+		t.Fatalf("Expect RetryQueue is empty, got size %d\n", queue.Size())
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	fmt.Println("Test backoff doubles up to one minute...")
+	for attempts, expect := range map[int]time.Duration{
+		1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 10: time.Minute,
+	} {
+		if d := ExponentialBackoff(attempts); d != expect {
+			t.Fatalf("Expect %v for %d attempts, got %v\n", expect, attempts, d)
+		}
+	}
+	fmt.Println("  ...PASSED")
+}
@@ -0,0 +1,100 @@
+package goqueue
+
+// ringPrealloc bounds the slots allocated up front for a bounded Queue.
+const ringPrealloc = 1 << 16
+
+// ring is a double-ended queue on a circular slice, it grows by doubling
+// and never allocates per value.
+type ring struct {
+	buf    []interface{}
+	head   int // index of the first value
+	n      int
+	shared bool // buf is seen by a view, copy it before clearing a slot
+}
+
+// ringView is a read-only copy-on-write snapshot of a ring.
+type ringView struct {
+	buf  []interface{}
+	head int
+	n    int
+}
+
+// view returns a snapshot of r. Pushes only write the slots which were
+// free, they don't disturb it; the first pop copies buf.
+func (r *ring) view() ringView {
+	r.shared = true
+	return ringView{buf: r.buf, head: r.head, n: r.n}
+}
+
+func (v ringView) at(i int) interface{} {
+	return v.buf[(v.head+i)%len(v.buf)]
+}
+
+// own copies buf if a view sees it.
+func (r *ring) own() {
+	if r.shared {
+		r.buf = append([]interface{}(nil), r.buf...)
+		r.shared = false
+	}
+}
+
+func newRing(capacity int) *ring {
+	if capacity > ringPrealloc {
+		capacity = ringPrealloc
+	}
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &ring{buf: make([]interface{}, capacity)}
+}
+
+func (r *ring) Len() int {
+	return r.n
+}
+
+func (r *ring) grow() {
+	if r.n < len(r.buf) {
+		return
+	}
+	buf := make([]interface{}, 2*len(r.buf))
+	for i := 0; i < r.n; i++ {
+		buf[i] = r.at(i)
+	}
+	r.buf, r.head, r.shared = buf, 0, false
+}
+
+// at returns the i-th value from the front.
+func (r *ring) at(i int) interface{} {
+	return r.buf[(r.head+i)%len(r.buf)]
+}
+
+func (r *ring) pushBack(val interface{}) {
+	r.grow()
+	r.buf[(r.head+r.n)%len(r.buf)] = val
+	r.n++
+}
+
+func (r *ring) pushFront(val interface{}) {
+	r.grow()
+	r.head = (r.head + len(r.buf) - 1) % len(r.buf)
+	r.buf[r.head] = val
+	r.n++
+}
+
+func (r *ring) popFront() interface{} {
+	r.own()
+	val := r.buf[r.head]
+	r.buf[r.head] = nil
+	r.head = (r.head + 1) % len(r.buf)
+	r.n--
+	return val
+}
+
+func (r *ring) popBack() interface{} {
+	r.own()
+	i := (r.head + r.n - 1) % len(r.buf)
+	val := r.buf[i]
+	r.buf[i] = nil
+	r.n--
+	return val
+}
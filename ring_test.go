@@ -0,0 +1,67 @@
+package goqueue
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRing(t *testing.T) {
+	r := newRing(2)
+
+	fmt.Println("Test ring wraps around and grows...")
+	r.pushBack(1)
+	r.pushBack(2)
+	if r.popFront().(int) != 1 {
+		t.Fatalf("Expect 1\n")
+	}
+	r.pushBack(3)
+	r.pushFront(0)
+	r.pushBack(4)
+	if len(r.buf) != 4 || r.Len() != 4 {
+		t.Fatalf("Expect 4 values in 4 slots, got %d in %d\n", r.Len(), len(r.buf))
+	}
+	for i, expect := range []int{0, 2, 3, 4} {
+		if val := r.at(i); val.(int) != expect {
+			t.Fatalf("Expect %v at %d, got %v\n", expect, i, val)
+		}
+	}
+	if r.popBack().(int) != 4 || r.popFront().(int) != 0 || r.Len() != 2 {
+		t.Fatalf("Expect both ends popped\n")
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestRingView(t *testing.T) {
+	r := newRing(4)
+	r.pushBack(1)
+	r.pushBack(2)
+
+	fmt.Println("Test a ring view is not changed by pushes and pops...")
+	v := r.view()
+	r.pushFront(0)
+	r.pushBack(3)
+	r.popFront()
+	r.popBack()
+	r.popFront()
+	if v.n != 2 || v.at(0).(int) != 1 || v.at(1).(int) != 2 {
+		t.Fatalf("Expect the view [1 2], got %v %v\n", v.at(0), v.at(1))
+	}
+	if r.Len() != 1 || r.at(0).(int) != 2 || r.shared {
+		t.Fatalf("Expect the ring [2] on its own slots\n")
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestRingNoAllocs(t *testing.T) {
+	queue := New(16)
+
+	fmt.Println("Test a bounded Queue doesn't allocate per value...")
+	allocs := testing.AllocsPerRun(100, func() {
+		queue.PutNoWait(nil)
+		queue.GetNoWait()
+	})
+	if allocs != 0 {
+		t.Fatalf("Expect no allocations, got %v\n", allocs)
+	}
+	fmt.Println("  ...PASSED")
+}
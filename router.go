@@ -0,0 +1,44 @@
+package goqueue
+
+// Router dispatches the values of one queue to several output queues by
+// key. Values with the same key go to the same output in the order they
+// were got, so each output can be consumed in parallel with the others.
+type Router struct {
+	key  func(val interface{}) string
+	outs []*Queue
+
+	// Partition returns the index in outs of key, default is HashPartition.
+	// It must be set before Run.
+	Partition func(key string, n int) int
+}
+
+// NewRouter create a Router dispatching to outs by the key of each value.
+func NewRouter(key func(val interface{}) string, outs ...*Queue) *Router {
+	return &Router{key: key, outs: outs, Partition: HashPartition}
+}
+
+// Route returns the output queue of val.
+func (r *Router) Route(val interface{}) *Queue {
+	return r.outs[r.Partition(r.key(val), len(r.outs))]
+}
+
+// Run moves the values of src to their output until stop is closed, and
+// returns the number of values routed. It waits while the output of a value
+// is full, which holds back the values behind it, so a single Run keeps the
+// per key order. A value got when stop is closed is put back at the front
+// of src.
+func (r *Router) Run(src *Queue, stop <-chan struct{}) int {
+	n := 0
+	for {
+		val, err := src.getUntil(0, stop, src.lifo)
+		if err != nil {
+			return n
+		}
+		if r.Route(val).putUntil(val, 0, stop, false) != nil {
+			src.putBack(val)
+			return n
+		}
+		src.TaskDone()
+		n++
+	}
+}
@@ -0,0 +1,67 @@
+package goqueue
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRouter(t *testing.T) {
+	src := New(0)
+	outs := []*Queue{New(0), New(0), New(0)}
+	router := NewRouter(func(val interface{}) string {
+		return strings.SplitN(val.(string), "-", 2)[0]
+	}, outs...)
+	for i := 0; i < 10; i++ {
+		for _, key := range []string{"a", "b", "c", "d"} {
+			src.PutNoWait(fmt.Sprintf("%s-%d", key, i))
+		}
+	}
+	stop := make(chan struct{})
+	routed := make(chan int)
+	go func() { routed <- router.Run(src, stop) }()
+
+	fmt.Println("Test Router keeps the order of each key in its output...")
+	for src.Size() != 0 {
+		time.Sleep(time.Millisecond)
+	}
+	close(stop)
+	if n := <-routed; n != 40 {
+		t.Fatalf("Expect 40 values routed, got %d\n", n)
+	}
+	for _, out := range outs {
+		next := make(map[string]int)
+		for !out.IsEmpty() {
+			val, _ := out.GetNoWait()
+			var key string
+			var i int
+			fmt.Sscanf(strings.Replace(val.(string), "-", " ", 1), "%s %d", &key, &i)
+			if router.Route(val) != out {
+				t.Fatalf("Expect %v in the output of its key\n", val)
+			}
+			if i != next[key] {
+				t.Fatalf("Expect %s-%d, got %v\n", key, next[key], val)
+			}
+			next[key]++
+		}
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestRouterPartition(t *testing.T) {
+	outs := []*Queue{New(0), New(0)}
+	router := NewRouter(func(val interface{}) string { return val.(string) }, outs...)
+	router.Partition = func(key string, n int) int {
+		if key == "vip" {
+			return 0
+		}
+		return 1
+	}
+
+	fmt.Println("Test an explicit partition...")
+	if router.Route("vip") != outs[0] || router.Route("other") != outs[1] {
+		t.Fatalf("Expect the values routed by Partition\n")
+	}
+	fmt.Println("  ...PASSED")
+}
@@ -0,0 +1,160 @@
+/*
+Package rpc implements remote procedure calls over goqueue.
+
+Calls are sent as goqueue.Request envelopes through any queue satisfying
+goqueue.Putter/goqueue.Getter, a Server handles them by method name and the
+Client matches the replies with a goqueue.Requester. The reply queue travels
+by name, so calls work over the durable and networked backends as long as
+the arguments and results are registered by gob.Register. A call which is
+not answered in time is retried, so handlers may run more than once and
+should be idempotent.
+*/
+
+package rpc
+
+import (
+	"encoding/gob"
+	"fmt"
+	"sync"
+
+	"github.com/damnever/goqueue"
+)
+
+func init() {
+	gob.Register(&Call{})
+}
+
+// Call is the body of the request sent by Client.
+type Call struct {
+	Method string
+	Args   interface{}
+}
+
+// Handler handles the arguments of a call.
+type Handler func(args interface{}) (interface{}, error)
+
+// Server handles calls read from a queue.
+type Server struct {
+	requests goqueue.Getter
+	replies  goqueue.Resolver
+	mutex    sync.RWMutex
+	handlers map[string]Handler
+}
+
+// NewServer create a Server reading calls from requests, the replies are
+// sent to the queues replies resolves for their ReplyTo, a goqueue.Manager
+// for example.
+func NewServer(requests goqueue.Getter, replies goqueue.Resolver) *Server {
+	return &Server{
+		requests: requests,
+		replies:  replies,
+		handlers: make(map[string]Handler),
+	}
+}
+
+// Register sets the handler of method.
+func (s *Server) Register(method string, h Handler) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.handlers[method] = h
+}
+
+func (s *Server) handler(method string) (Handler, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	h, ok := s.handlers[method]
+	return h, ok
+}
+
+// Serve handles calls with workers goroutines until stop is closed.
+func (s *Server) Serve(workers int, stop <-chan struct{}) {
+	wg := &sync.WaitGroup{}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				val, err := s.requests.Get(0.1)
+				if err != nil {
+					continue
+				}
+				if req, ok := val.(*goqueue.Request); ok {
+					s.handle(req)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func (s *Server) handle(req *goqueue.Request) {
+	call, ok := req.Body.(*Call)
+	if !ok {
+		req.Reply(s.replies, nil, fmt.Errorf("rpc: bad request body %T", req.Body), -1)
+		return
+	}
+	h, ok := s.handler(call.Method)
+	if !ok {
+		req.Reply(s.replies, nil, fmt.Errorf("rpc: unknown method %q", call.Method), -1)
+		return
+	}
+	result, err := s.call(h, call.Args)
+	req.Reply(s.replies, result, err, -1)
+}
+
+func (s *Server) call(h Handler, args interface{}) (result interface{}, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("rpc: panic: %v", p)
+		}
+	}()
+	return h(args)
+}
+
+// Client sends calls to a Server.
+type Client struct {
+	requests  goqueue.Putter
+	requester *goqueue.Requester
+
+	// Timeout is the seconds to wait for each try of a call, default is 5.
+	Timeout float64
+	// Retries is the number of extra tries when the request queue is full
+	// or no reply arrives in time, default is 2.
+	Retries int
+}
+
+// NewClient create a Client which puts calls into requests and reads the
+// replies sent to the queue named replyTo from replies, which is usually
+// that queue and must not be shared with other readers.
+func NewClient(requests goqueue.Putter, replies goqueue.Getter, replyTo string) *Client {
+	return &Client{
+		requests:  requests,
+		requester: goqueue.NewRequester(replies, replyTo),
+		Timeout:   5,
+		Retries:   2,
+	}
+}
+
+// Call calls method with args and returns its result, ErrNoReply is
+// returned when every try timed out.
+func (c *Client) Call(method string, args interface{}) (interface{}, error) {
+	var err error
+	for i := 0; i <= c.Retries; i++ {
+		var result interface{}
+		result, err = c.requester.Call(c.requests, &Call{Method: method, Args: args}, c.Timeout)
+		if err != goqueue.ErrNoReply && err != goqueue.ErrFullQueue {
+			return result, err
+		}
+	}
+	return nil, err
+}
+
+// Close stops reading replies.
+func (c *Client) Close() {
+	c.requester.Close()
+}
@@ -0,0 +1,151 @@
+package rpc
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/damnever/goqueue"
+	"github.com/damnever/goqueue/walqueue"
+)
+
+func setup() (*Server, *Client, func()) {
+	return setupWith(goqueue.New(0), goqueue.New(0))
+}
+
+func setupWith(requests, replies goqueue.Interface) (*Server, *Client, func()) {
+	resolver := goqueue.ResolverFunc(func(name string) (goqueue.Putter, error) {
+		if name != "replies" {
+			return nil, goqueue.ErrNoQueue
+		}
+		return replies, nil
+	})
+	server := NewServer(requests, resolver)
+	client := NewClient(requests, replies, "replies")
+	client.Timeout = 0.2
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		server.Serve(2, stop)
+		close(done)
+	}()
+	return server, client, func() {
+		close(stop)
+		<-done
+		client.Close()
+	}
+}
+
+func TestCall(t *testing.T) {
+	server, client, stop := setup()
+	defer stop()
+	server.Register("add", func(args interface{}) (interface{}, error) {
+		nums := args.([]int)
+		return nums[0] + nums[1], nil
+	})
+	server.Register("fail", func(args interface{}) (interface{}, error) {
+		return nil, errors.New("failed")
+	})
+	server.Register("panic", func(args interface{}) (interface{}, error) {
+		panic("boom")
+	})
+
+	fmt.Println("Test call returns the handler result...")
+	result, err := client.Call("add", []int{1, 2})
+	if err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	} else if result.(int) != 3 {
+		t.Fatalf("Expect %v, got %v\n", 3, result)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test call returns remote errors without retry...")
+	for method, expect := range map[string]string{
+		"fail":    "failed",
+		"panic":   "rpc: panic: boom",
+		"missing": `rpc: unknown method "missing"`,
+	} {
+		if _, err := client.Call(method, nil); err == nil || err.Error() != expect {
+			t.Fatalf("Expect error %q, got %v\n", expect, err)
+		}
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestCallRetry(t *testing.T) {
+	server, client, stop := setup()
+	defer stop()
+	release := make(chan struct{})
+	defer close(release)
+	var calls int32
+	server.Register("flaky", func(args interface{}) (interface{}, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			<-release // Doesn't answer the first call in time.
+		}
+		return "ok", nil
+	})
+
+	fmt.Println("Test unanswered call is retried...")
+	result, err := client.Call("flaky", nil)
+	if err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	} else if result.(string) != "ok" {
+		t.Fatalf("Expect %v, got %v\n", "ok", result)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test ErrNoReply after all retries...")
+	client.Retries = 1
+	server.Register("slow", func(args interface{}) (interface{}, error) {
+		<-release
+		return nil, nil
+	})
+	if _, err := client.Call("slow", nil); err != goqueue.ErrNoReply {
+		t.Fatalf("Expect %v, got %v\n", goqueue.ErrNoReply, err)
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestCallWAL(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rpc")
+	if err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	defer os.RemoveAll(dir)
+	requests, err := walqueue.Open(filepath.Join(dir, "requests.wal"), 0, walqueue.Options{})
+	if err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	defer requests.Close()
+	replies, err := walqueue.Open(filepath.Join(dir, "replies.wal"), 0, walqueue.Options{})
+	if err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	defer replies.Close()
+
+	server, client, stop := setupWith(requests, replies)
+	defer stop()
+	server.Register("add", func(args interface{}) (interface{}, error) {
+		nums := args.([]int)
+		return nums[0] + nums[1], nil
+	})
+	server.Register("fail", func(args interface{}) (interface{}, error) {
+		return nil, errors.New("failed")
+	})
+
+	fmt.Println("Test call over walqueue...")
+	result, err := client.Call("add", []int{1, 2})
+	if err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	} else if result.(int) != 3 {
+		t.Fatalf("Expect %v, got %v\n", 3, result)
+	}
+	if _, err := client.Call("fail", nil); err == nil || err.Error() != "failed" {
+		t.Fatalf("Expect error %q, got %v\n", "failed", err)
+	}
+	fmt.Println("  ...PASSED")
+}
@@ -0,0 +1,217 @@
+/*
+Package saga runs multi-step workflows over goqueue queues.
+
+Each step of a Workflow has its own queue, an action and an optional
+compensation. An Execution envelope carries the workflow state from one step
+queue to the next: when an action succeeds the Execution is put into the
+next step's queue, when it fails the Execution travels back through the
+queues of the completed steps, in reverse order, running their
+compensations.
+
+All the state lives in the Execution, so putting the step queues in a
+durable backend (e.g. sqlqueue, which stores values with gob) lets a
+restarted process carry on with the executions in flight. The backend
+removes an Execution when a worker gets it, so a crash in the middle of a
+step loses that Execution unless the backend redelivers it.
+*/
+
+package saga
+
+import (
+	"encoding/gob"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/damnever/goqueue"
+)
+
+func init() {
+	gob.Register(&Execution{})
+}
+
+// pollInterval is the longest a worker waits before it checks stop.
+const pollInterval = 100 * time.Millisecond
+
+// Queue is a step queue.
+type Queue interface {
+	goqueue.Putter
+	goqueue.Getter
+}
+
+// Execution is one run of a Workflow, actions keep their results in Data
+// for the next steps and the compensations, values stored in a durable
+// backend must be registered by gob.Register.
+type Execution struct {
+	ID       string
+	Workflow string
+	Data     map[string]interface{}
+
+	// Step is the index of the step to run next.
+	Step int
+	// Compensating is true once a step failed, Err is the failure and
+	// CompensationErrs collects failed compensations.
+	Compensating     bool
+	Err              string
+	CompensationErrs []string
+}
+
+// StepFunc is an action or a compensation.
+type StepFunc func(exec *Execution) error
+
+type step struct {
+	name       string
+	queue      Queue
+	action     StepFunc
+	compensate StepFunc
+}
+
+// Workflow is a list of steps.
+type Workflow struct {
+	name  string
+	steps []*step
+
+	// OnComplete is called, if not nil, when every step succeeded.
+	OnComplete func(exec *Execution)
+	// OnFailed is called, if not nil, when a step failed and all the
+	// compensations have run.
+	OnFailed func(exec *Execution)
+}
+
+// New create an empty Workflow.
+func New(name string) *Workflow {
+	return &Workflow{name: name}
+}
+
+// Step appends a step which reads executions from q, compensate may be nil.
+func (w *Workflow) Step(name string, q Queue, action, compensate StepFunc) *Workflow {
+	w.steps = append(w.steps, &step{name: name, queue: q, action: action, compensate: compensate})
+	return w
+}
+
+// Start puts a new Execution into the first step queue, the timeout
+// semantics are the same as goqueue.Queue.Put.
+func (w *Workflow) Start(id string, data map[string]interface{}, timeout float64) error {
+	if len(w.steps) == 0 {
+		return fmt.Errorf("saga: workflow %q has no steps", w.name)
+	}
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+	exec := &Execution{ID: id, Workflow: w.name, Data: data}
+	return w.steps[0].queue.Put(exec, timeout)
+}
+
+// Run consumes every step queue with workers goroutines per step until stop
+// is closed. A step queue may be shared by several workflows as long as all
+// of them run: an Execution of another workflow is put back into the queue,
+// and the worker waits a poll interval before it gets again.
+func (w *Workflow) Run(workers int, stop <-chan struct{}) {
+	wg := &sync.WaitGroup{}
+	for _, s := range w.steps {
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func(s *step) {
+				defer wg.Done()
+				// One timer per worker for the waits after a forward.
+				t := time.NewTimer(pollInterval)
+				t.Stop()
+				defer t.Stop()
+				for {
+					select {
+					case <-stop:
+						return
+					default:
+					}
+					val, err := s.queue.Get(pollInterval.Seconds())
+					if err != nil {
+						continue
+					}
+					exec, ok := val.(*Execution)
+					if !ok {
+						continue
+					}
+					if exec.Workflow == w.name {
+						w.process(exec)
+						continue
+					}
+					w.forward(exec, s.queue)
+					t.Reset(pollInterval)
+					select {
+					case <-stop:
+						return
+					case <-t.C:
+					}
+				}
+			}(s)
+		}
+	}
+	wg.Wait()
+}
+
+func (w *Workflow) process(exec *Execution) {
+	if exec.Step < 0 || exec.Step >= len(w.steps) {
+		// Persisted before steps were removed, the compensations may not
+		// match any more, so none runs.
+		exec.Compensating = true
+		exec.Err = fmt.Sprintf("saga: step %d out of range, workflow %q has %d steps", exec.Step, w.name, len(w.steps))
+		if w.OnFailed != nil {
+			w.OnFailed(exec)
+		}
+		return
+	}
+	s := w.steps[exec.Step]
+	if exec.Compensating {
+		if s.compensate != nil {
+			if err := call(s.compensate, exec); err != nil {
+				exec.CompensationErrs = append(exec.CompensationErrs,
+					fmt.Sprintf("%s: %v", s.name, err))
+			}
+		}
+		w.back(exec)
+		return
+	}
+
+	if err := call(s.action, exec); err != nil {
+		exec.Compensating = true
+		exec.Err = fmt.Sprintf("%s: %v", s.name, err)
+		w.back(exec)
+		return
+	}
+	if exec.Step == len(w.steps)-1 {
+		if w.OnComplete != nil {
+			w.OnComplete(exec)
+		}
+		return
+	}
+	exec.Step++
+	w.forward(exec, w.steps[exec.Step].queue)
+}
+
+// back moves a compensating execution to the previous step.
+func (w *Workflow) back(exec *Execution) {
+	exec.Step--
+	if exec.Step < 0 {
+		if w.OnFailed != nil {
+			w.OnFailed(exec)
+		}
+		return
+	}
+	w.forward(exec, w.steps[exec.Step].queue)
+}
+
+func (w *Workflow) forward(exec *Execution, q Queue) {
+	if err := q.Put(exec, 0); err != nil && w.OnFailed != nil {
+		exec.Err = fmt.Sprintf("saga: put: %v", err)
+		w.OnFailed(exec)
+	}
+}
+
+func call(f StepFunc, exec *Execution) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return f(exec)
+}
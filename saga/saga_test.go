@@ -0,0 +1,159 @@
+package saga
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/damnever/goqueue"
+)
+
+type recorder struct {
+	mutex sync.Mutex
+	calls []string
+}
+
+func (r *recorder) step(name string, err error) StepFunc {
+	return func(exec *Execution) error {
+		r.mutex.Lock()
+		r.calls = append(r.calls, name)
+		r.mutex.Unlock()
+		exec.Data[name] = true
+		return err
+	}
+}
+
+func run(t *testing.T, w *Workflow) *Execution {
+	finished := make(chan *Execution, 1)
+	w.OnComplete = func(exec *Execution) { finished <- exec }
+	w.OnFailed = func(exec *Execution) { finished <- exec }
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		w.Run(1, stop)
+		close(done)
+	}()
+	defer func() {
+		close(stop)
+		<-done
+	}()
+
+	if err := w.Start("order-1", nil, -1); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	select {
+	case exec := <-finished:
+		return exec
+	case <-time.After(3 * time.Second):
+		t.Fatalf("Workflow didn't finish\n")
+	}
+	return nil
+}
+
+func TestWorkflowComplete(t *testing.T) {
+	r := &recorder{}
+	w := New("order").
+		Step("reserve", goqueue.New(0), r.step("reserve", nil), r.step("release", nil)).
+		Step("charge", goqueue.New(0), r.step("charge", nil), r.step("refund", nil)).
+		Step("ship", goqueue.New(0), r.step("ship", nil), nil)
+
+	fmt.Println("Test every step runs in order...")
+	exec := run(t, w)
+	if exec.Compensating || exec.Err != "" {
+		t.Fatalf("Expect completed execution, got %+v\n", exec)
+	}
+	if expect := []string{"reserve", "charge", "ship"}; !reflect.DeepEqual(r.calls, expect) {
+		t.Fatalf("Expect calls %v, got %v\n", expect, r.calls)
+	}
+	if len(exec.Data) != 3 {
+		t.Fatalf("Expect data of 3 steps, got %v\n", exec.Data)
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestWorkflowCompensate(t *testing.T) {
+	r := &recorder{}
+	w := New("order").
+		Step("reserve", goqueue.New(0), r.step("reserve", nil), r.step("release", nil)).
+		Step("charge", goqueue.New(0), r.step("charge", nil), r.step("refund", errors.New("bank down"))).
+		Step("ship", goqueue.New(0), r.step("ship", errors.New("no stock")), r.step("never", nil))
+
+	fmt.Println("Test failure runs compensations in reverse order...")
+	exec := run(t, w)
+	if !exec.Compensating || exec.Err != "ship: no stock" {
+		t.Fatalf("Expect failed execution, got %+v\n", exec)
+	}
+	if expect := []string{"reserve", "charge", "ship", "refund", "release"}; !reflect.DeepEqual(r.calls, expect) {
+		t.Fatalf("Expect calls %v, got %v\n", expect, r.calls)
+	}
+	if expect := []string{"charge: bank down"}; !reflect.DeepEqual(exec.CompensationErrs, expect) {
+		t.Fatalf("Expect compensation errors %v, got %v\n", expect, exec.CompensationErrs)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test panic is a failure...")
+	w = New("panic").Step("boom", goqueue.New(0), func(*Execution) error { panic("boom") }, nil)
+	if exec := run(t, w); exec.Err != "boom: panic: boom" {
+		t.Fatalf("Expect panic error, got %q\n", exec.Err)
+	}
+	if err := New("empty").Start("x", nil, -1); err == nil {
+		t.Fatalf("Expect error for a workflow without steps\n")
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestWorkflowShared(t *testing.T) {
+	r := &recorder{}
+	shared := goqueue.New(0)
+	a := New("a").Step("a", shared, r.step("a", nil), nil)
+	b := New("b").Step("b", shared, r.step("b", nil), nil)
+
+	fmt.Println("Test workflows sharing a step queue get their own executions...")
+	finished := make(chan string, 2)
+	a.OnComplete = func(exec *Execution) { finished <- exec.Workflow }
+	b.OnComplete = func(exec *Execution) { finished <- exec.Workflow }
+	stop := make(chan struct{})
+	wg := &sync.WaitGroup{}
+	for _, w := range []*Workflow{a, b} {
+		wg.Add(1)
+		go func(w *Workflow) {
+			defer wg.Done()
+			w.Run(1, stop)
+		}(w)
+	}
+	a.Start("1", nil, -1)
+	b.Start("2", nil, -1)
+	got := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		select {
+		case name := <-finished:
+			got[name] = true
+		case <-time.After(3 * time.Second):
+			t.Fatalf("Workflows didn't finish, got %v\n", got)
+		}
+	}
+	close(stop)
+	wg.Wait()
+	if !got["a"] || !got["b"] {
+		t.Fatalf("Expect both workflows complete, got %v\n", got)
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestWorkflowStepOutOfRange(t *testing.T) {
+	r := &recorder{}
+	q := goqueue.New(0)
+	w := New("order").Step("reserve", q, r.step("reserve", nil), r.step("release", nil))
+
+	fmt.Println("Test an execution past the last step fails...")
+	var failed *Execution
+	w.OnFailed = func(exec *Execution) { failed = exec }
+	w.process(&Execution{ID: "old", Workflow: "order", Data: map[string]interface{}{}, Step: 3})
+	if failed == nil || failed.Err == "" || len(r.calls) != 0 {
+		t.Fatalf("Expect a failed execution without calls, got %+v and %v\n", failed, r.calls)
+	}
+	fmt.Println("  ...PASSED")
+}
@@ -0,0 +1,205 @@
+package goqueue
+
+import (
+	"container/heap"
+	"container/list"
+	"sync"
+	"time"
+)
+
+type scheduledItem struct {
+	value    interface{}
+	at       time.Time
+	priority int
+	seq      uint64
+}
+
+// delayedBefore orders items by release time, then by insertion order.
+func delayedBefore(x, y interface{}) bool {
+	a, b := x.(*scheduledItem), y.(*scheduledItem)
+	if a.at.Equal(b.at) {
+		return a.seq < b.seq
+	}
+	return a.at.Before(b.at)
+}
+
+// readyBefore orders items by priority (higher first), then by insertion
+// order.
+func readyBefore(x, y interface{}) bool {
+	a, b := x.(*scheduledItem), y.(*scheduledItem)
+	if a.priority == b.priority {
+		return a.seq < b.seq
+	}
+	return a.priority > b.priority
+}
+
+// ScheduledQueue is a GoRoutine safe queue whose items carry a release time
+// and a priority. An item is invisible to Get until its release time, then
+// it competes with the other released items by priority, items with the
+// same priority are returned in FIFO order.
+type ScheduledQueue struct {
+	maxSize int
+	mutex   sync.Mutex
+	seq     uint64
+	delayed itemHeap    // items waiting for their release time
+	ready   itemHeap    // released items
+	timer   *time.Timer // fires at the earliest release time
+	putters *list.List  // store blocked Put operators
+	getters *list.List  // store blocked Get operators
+
+	// Jitter, if not nil, randomizes the delay of items put with a future
+	// release time, it must be set before the ScheduledQueue is used.
+	Jitter Jitter
+}
+
+// NewScheduled create a new ScheduledQueue, the maxSize variable sets the
+// max size, delayed items included. If maxSize is zero, ScheduledQueue will
+// be infinite size, and Put always no wait.
+func NewScheduled(maxSize int) *ScheduledQueue {
+	q := new(ScheduledQueue)
+	q.maxSize = maxSize
+	q.delayed.less = delayedBefore
+	q.ready.less = readyBefore
+	q.putters = list.New()
+	q.getters = list.New()
+	return q
+}
+
+func (q *ScheduledQueue) onTimer() {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.promote(time.Now())
+	wake(q.getters, q.ready.Len())
+}
+
+// promote moves the items whose release time passed into ready, and arms
+// the timer for the next one.
+func (q *ScheduledQueue) promote(now time.Time) {
+	for q.delayed.Len() > 0 && !q.delayed.items[0].(*scheduledItem).at.After(now) {
+		heap.Push(&q.ready, heap.Pop(&q.delayed))
+	}
+	if q.delayed.Len() == 0 {
+		if q.timer != nil {
+			q.timer.Stop()
+		}
+		return
+	}
+	d := q.delayed.items[0].(*scheduledItem).at.Sub(now)
+	if q.timer == nil {
+		q.timer = time.AfterFunc(d, q.onTimer)
+	} else {
+		q.timer.Reset(d)
+	}
+}
+
+func (q *ScheduledQueue) size() int {
+	return q.delayed.Len() + q.ready.Len()
+}
+
+func (q *ScheduledQueue) isfull() bool {
+	return (q.maxSize > 0 && q.maxSize <= q.size())
+}
+
+// Same as Get(-1).
+func (q *ScheduledQueue) GetNoWait() (interface{}, error) {
+	return q.Get(-1)
+}
+
+// Get returns the released item with the highest priority, the timeout
+// semantics are the same as Queue.Get.
+func (q *ScheduledQueue) Get(timeout float64) (interface{}, error) {
+	deadline, stop := deadlineOf(timeout)
+	defer stop()
+
+	q.mutex.Lock()
+	for {
+		q.promote(time.Now())
+		if q.ready.Len() > 0 {
+			item := heap.Pop(&q.ready).(*scheduledItem)
+			wake(q.getters, q.ready.Len())
+			wake(q.putters, 1)
+			q.mutex.Unlock()
+			return item.value, nil
+		}
+		if !await(&q.mutex, q.getters, timeout, deadline) {
+			q.mutex.Unlock()
+			return nil, ErrEmptyQueue
+		}
+	}
+}
+
+// Same as Put(val, -1).
+func (q *ScheduledQueue) PutNoWait(val interface{}) error {
+	return q.Put(val, -1)
+}
+
+// Put an item which is released immediately with priority 0.
+func (q *ScheduledQueue) Put(val interface{}, timeout float64) error {
+	return q.PutScheduled(val, time.Time{}, 0, timeout)
+}
+
+// PutDelayed puts an item which is released after delay with priority 0,
+// the timeout semantics are the same as Queue.Put.
+func (q *ScheduledQueue) PutDelayed(val interface{}, delay time.Duration, timeout float64) error {
+	return q.PutScheduled(val, time.Now().Add(delay), 0, timeout)
+}
+
+// PutScheduled puts an item which is released at time at, then served by
+// priority, a zero at releases it immediately. The timeout semantics are the
+// same as Queue.Put.
+func (q *ScheduledQueue) PutScheduled(val interface{}, at time.Time, priority int, timeout float64) error {
+	deadline, stop := deadlineOf(timeout)
+	defer stop()
+
+	q.mutex.Lock()
+	for {
+		if !q.isfull() {
+			q.seq++
+			now := time.Now()
+			if q.Jitter != nil && at.After(now) {
+				at = now.Add(q.Jitter(at.Sub(now)))
+			}
+			item := &scheduledItem{value: val, at: at, priority: priority, seq: q.seq}
+			if at.After(now) {
+				heap.Push(&q.delayed, item)
+				q.promote(now)
+			} else {
+				heap.Push(&q.ready, item)
+				wake(q.getters, 1)
+			}
+			q.mutex.Unlock()
+			return nil
+		}
+		if !await(&q.mutex, q.putters, timeout, deadline) {
+			q.mutex.Unlock()
+			return ErrFullQueue
+		}
+	}
+}
+
+// Return size of ScheduledQueue, delayed items included.
+func (q *ScheduledQueue) Size() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.size()
+}
+
+// Return the number of items which can be got right now.
+func (q *ScheduledQueue) ReadySize() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.promote(time.Now())
+	return q.ready.Len()
+}
+
+// Return true if ScheduledQueue is empty, delayed items included.
+func (q *ScheduledQueue) IsEmpty() bool {
+	return q.Size() == 0
+}
+
+// Return true if ScheduledQueue is full.
+func (q *ScheduledQueue) IsFull() bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.isfull()
+}
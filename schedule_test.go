@@ -0,0 +1,120 @@
+package goqueue
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestScheduledPriority(t *testing.T) {
+	queue := NewScheduled(0)
+
+	fmt.Println("Test released items are served by priority, then FIFO...")
+	now := time.Now()
+	queue.PutScheduled("low", now, 1, -1)
+	queue.PutScheduled("high-1", now, 5, -1)
+	queue.PutScheduled("high-2", now, 5, -1)
+	queue.PutNoWait("zero")
+	for _, expect := range []string{"high-1", "high-2", "low", "zero"} {
+		val, err := queue.GetNoWait()
+		if err != nil {
+			t.Fatalf("Unexpect error: %v\n", err)
+		} else if val.(string) != expect {
+			t.Fatalf("Expect %v, got %v\n", expect, val)
+		}
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestScheduledDelay(t *testing.T) {
+	queue := NewScheduled(0)
+
+	fmt.Println("Test delayed items are invisible until released...")
+	queue.PutScheduled("later", time.Now().Add(200*time.Millisecond), 0, -1)
+	if _, err := queue.GetNoWait(); err != ErrEmptyQueue {
+		t.Fatalf("Expect %v, got %v\n", ErrEmptyQueue, err)
+	}
+	if queue.Size() != 1 || queue.ReadySize() != 0 {
+		t.Fatalf("Expect 1 delayed item, got size %d ready %d\n", queue.Size(), queue.ReadySize())
+	}
+	if _, err := queue.Get(0.05); err != ErrEmptyQueue {
+		t.Fatalf("Expect %v, got %v\n", ErrEmptyQueue, err)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test blocking Get returns when an item is released...")
+	start := time.Now()
+	val, err := queue.Get(0)
+	if err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	} else if val.(string) != "later" {
+		t.Fatalf("Expect %v, got %v\n", "later", val)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Get returned after %v\n", elapsed)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test PutDelayed hides the item for the delay...")
+	queue.PutDelayed("delayed", 100*time.Millisecond, -1)
+	if _, err := queue.GetNoWait(); err != ErrEmptyQueue {
+		t.Fatalf("Expect %v, got %v\n", ErrEmptyQueue, err)
+	}
+	if val, err := queue.Get(2); err != nil || val.(string) != "delayed" {
+		t.Fatalf("Expect delayed, got %v (%v)\n", val, err)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test released items compete by priority...")
+	at := time.Now().Add(100 * time.Millisecond)
+	queue.PutScheduled("early-low", at.Add(-50*time.Millisecond), 1, -1)
+	queue.PutScheduled("late-high", at, 9, -1)
+	queue.PutScheduled("far", at.Add(time.Hour), 100, -1)
+	time.Sleep(150 * time.Millisecond)
+	for _, expect := range []string{"late-high", "early-low"} {
+		val, err := queue.GetNoWait()
+		if err != nil {
+			t.Fatalf("Unexpect error: %v\n", err)
+		} else if val.(string) != expect {
+			t.Fatalf("Expect %v, got %v\n", expect, val)
+		}
+	}
+	if _, err := queue.GetNoWait(); err != ErrEmptyQueue {
+		t.Fatalf("Expect %v, got %v\n", ErrEmptyQueue, err)
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestScheduledFull(t *testing.T) {
+	queue := NewScheduled(1)
+
+	fmt.Println("Test delayed items take a slot...")
+	queue.PutScheduled(1, time.Now().Add(100*time.Millisecond), 0, -1)
+	if !queue.IsFull() {
+		t.Fatalf("Expect ScheduledQueue is full\n")
+	}
+	if err := queue.PutNoWait(2); err != ErrFullQueue {
+		t.Fatalf("Expect %v, got %v\n", ErrFullQueue, err)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test blocking Put waits for a free slot...")
+	done := make(chan error, 1)
+	go func() {
+		done <- queue.Put(2, 2)
+	}()
+	if val, err := queue.Get(2); err != nil || val.(int) != 1 {
+		t.Fatalf("Expect 1, got %v (%v)\n", val, err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	if err := queue.Put(3, 0.05); err != ErrFullQueue {
+		t.Fatalf("Expect %v, got %v\n", ErrFullQueue, err)
+	}
+	if queue.putters.Len() != 0 || queue.getters.Len() != 0 {
+		t.Fatalf("Expect no pending operators, got %d putters %d getters\n",
+			queue.putters.Len(), queue.getters.Len())
+	}
+	fmt.Println("  ...PASSED")
+}
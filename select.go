@@ -0,0 +1,107 @@
+package goqueue
+
+import (
+	"container/list"
+	"sync/atomic"
+)
+
+// selectSeq rotates the first queue tried by Select.
+var selectSeq uint32
+
+// notifyWatchers tells the waiting Select operators that a value is in
+// the Queue, they compete for it with Get.
+func (q *Queue) notifyWatchers() {
+	for e := q.watchers.Front(); e != nil; e = e.Next() {
+		select {
+		case e.Value.(chan struct{}) <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Select gets a value from the first of queues which has one, and returns
+// its index in queues with the value. Queues are tried in turn from a
+// rotating start, so a busy queue can't starve the others of one Select.
+//
+// * If timeout less than 0, If all the queues are empty, return
+// (-1, nil, ErrEmptyQueue).
+//
+// * If timeout equals to 0, block until get a value from one of queues.
+//
+// * If timeout greater than 0, wait timeout seconds until get a value from
+// one of queues, if timeout passed, return (-1, nil, ErrEmptyQueue).
+func Select(timeout float64, queues ...*Queue) (int, interface{}, error) {
+	return selectUntil(timeout, nil, queues)
+}
+
+// selectUntil is Select which also gives up waiting once done is closed.
+func selectUntil(timeout float64, done <-chan struct{}, queues []*Queue) (int, interface{}, error) {
+	if len(queues) == 0 {
+		return -1, nil, ErrEmptyQueue
+	}
+	deadline, stop := deadlineOf(timeout)
+	defer stop()
+
+	notify := make(chan struct{}, 1)
+	elems := make([]*list.Element, len(queues))
+	defer func() {
+		for i, q := range queues {
+			if elems[i] != nil {
+				q.mutex.Lock()
+				q.watchers.Remove(elems[i])
+				q.mutex.Unlock()
+			}
+		}
+	}()
+
+	start := int(atomic.AddUint32(&selectSeq, 1) % uint32(len(queues)))
+	for {
+		for n := 0; n < len(queues); n++ {
+			i := (start + n) % len(queues)
+			if val, err := queues[i].getWait(-1, nil, queues[i].lifo); err == nil {
+				return i, val, nil
+			}
+		}
+		if timeout < 0.0 {
+			return -1, nil, ErrEmptyQueue
+		}
+		if elems[0] == nil {
+			// Watch, then try again so a value put meanwhile is seen.
+			for i, q := range queues {
+				q.mutex.Lock()
+				elems[i] = q.watchers.PushBack(notify)
+				q.mutex.Unlock()
+			}
+			continue
+		}
+		select {
+		case <-notify:
+		case <-deadline:
+			return -1, nil, ErrEmptyQueue
+		case <-done:
+			return -1, nil, ErrEmptyQueue
+		}
+		start++
+	}
+}
+
+// Merge moves the values of srcs into dst until stop is closed, and
+// returns the number of values moved. Sources are taken in turn as Select
+// does, and Merge waits while dst is full, so srcs fill up in turn and
+// their producers are slowed down. A value got when stop is closed is put
+// back at the front of its source.
+func Merge(dst *Queue, stop <-chan struct{}, srcs ...*Queue) int {
+	n := 0
+	for {
+		i, val, err := selectUntil(0, stop, srcs)
+		if err != nil {
+			return n
+		}
+		if dst.putUntil(val, 0, stop, false) != nil {
+			srcs[i].putBack(val)
+			return n
+		}
+		srcs[i].TaskDone()
+		n++
+	}
+}
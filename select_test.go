@@ -0,0 +1,98 @@
+package goqueue
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestSelect(t *testing.T) {
+	a, b := New(0), New(0)
+
+	fmt.Println("Test Select gets from the queue which has a value...")
+	if _, _, err := Select(-1, a, b); err != ErrEmptyQueue {
+		t.Fatalf("Expect %v, got %v\n", ErrEmptyQueue, err)
+	}
+	b.PutNoWait("b")
+	if i, val, err := Select(-1, a, b); err != nil || i != 1 || val.(string) != "b" {
+		t.Fatalf("Expect b from queue 1, got %v from %d (%v)\n", val, i, err)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test Select blocks until a value is put...")
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		a.PutNoWait("a")
+	}()
+	if i, val, err := Select(1, a, b); err != nil || i != 0 || val.(string) != "a" {
+		t.Fatalf("Expect a from queue 0, got %v from %d (%v)\n", val, i, err)
+	}
+	if a.watchers.Len() != 0 || b.watchers.Len() != 0 {
+		t.Fatalf("Expect Select stops watching the queues\n")
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test Select with timeout...")
+	start := time.Now()
+	if _, _, err := Select(0.05, a, b); err != ErrEmptyQueue {
+		t.Fatalf("Expect %v, got %v\n", ErrEmptyQueue, err)
+	}
+	if d := time.Since(start); d < 50*time.Millisecond {
+		t.Fatalf("Expect Select waits 50ms, returned after %v\n", d)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test Select takes from every busy queue...")
+	seen := make(map[int]bool)
+	for i := 0; i < 100; i++ {
+		a.PutNoWait(i)
+		b.PutNoWait(i)
+	}
+	for i := 0; i < 100; i++ {
+		n, _, _ := Select(-1, a, b)
+		seen[n] = true
+	}
+	if !seen[0] || !seen[1] {
+		t.Fatalf("Expect values from both queues, got %v\n", seen)
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestMerge(t *testing.T) {
+	a, b, dst := New(0), New(0), New(2)
+	stop := make(chan struct{})
+	merged := make(chan int)
+	for i := 0; i < 3; i++ {
+		a.PutNoWait("a")
+		b.PutNoWait("b")
+	}
+	go func() { merged <- Merge(dst, stop, a, b) }()
+
+	fmt.Println("Test Merge interleaves sources and waits while dst is full...")
+	counts := make(map[string]int)
+	for i := 0; i < 4; i++ {
+		val, err := dst.Get(1)
+		if err != nil {
+			t.Fatalf("Unexpect error: %v\n", err)
+		}
+		counts[val.(string)]++
+	}
+	if counts["a"] == 0 || counts["b"] == 0 {
+		t.Fatalf("Expect values from both sources, got %v\n", counts)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test Merge returns once stopped, putting the value back...")
+	for dst.Size() != 2 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(stop)
+	if n := <-merged; n != 6-a.Size()-b.Size() {
+		t.Fatalf("Expect %d values moved, got %d\n", 6-a.Size()-b.Size(), n)
+	}
+	if a.Size()+b.Size()+dst.Size() != 2 {
+		t.Fatalf("Expect no value lost, got %d left\n", a.Size()+b.Size()+dst.Size())
+	}
+	fmt.Println("  ...PASSED")
+}
@@ -0,0 +1,100 @@
+package goqueue
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Sequenced is the envelope put by SequenceChecker, backends which encode
+// values (e.g. sqlqueue with gob) must register it.
+type Sequenced struct {
+	Key   string
+	Seq   uint64
+	Value interface{}
+}
+
+// OrderError describes a value got out of order: Got is not the next
+// sequence number after the last one got for Key.
+type OrderError struct {
+	Key    string
+	Expect uint64
+	Got    uint64
+}
+
+func (e *OrderError) Error() string {
+	return fmt.Sprintf("out of order on key %q: expect seq %d, got %d", e.Key, e.Expect, e.Got)
+}
+
+// SequenceChecker is a debug wrapper around a queue which stamps every value
+// with a sequence number on Put and verifies on Get that values come out in
+// FIFO order, per key if Key is set. Lost, duplicated and reordered values
+// are all reported. Put and Get are serialized so the check itself doesn't
+// reorder anything, don't use it in production.
+type SequenceChecker struct {
+	putter   Putter
+	getter   Getter
+	putMutex sync.Mutex
+	getMutex sync.Mutex
+	next     map[string]uint64 // last seq put per key
+	last     map[string]uint64 // last seq got per key
+
+	// Key returns the ordering key of a value, nil checks the whole queue
+	// as a single key.
+	Key func(val interface{}) string
+	// OnViolation is called for every value out of order, default panics.
+	OnViolation func(err *OrderError)
+}
+
+// NewSequenceChecker create a SequenceChecker over the two sides of a queue,
+// usually the same value.
+func NewSequenceChecker(p Putter, g Getter) *SequenceChecker {
+	return &SequenceChecker{
+		putter: p,
+		getter: g,
+		next:   make(map[string]uint64),
+		last:   make(map[string]uint64),
+	}
+}
+
+// Put has the same timeout semantics as the wrapped Putter.
+func (c *SequenceChecker) Put(val interface{}, timeout float64) error {
+	key := ""
+	if c.Key != nil {
+		key = c.Key(val)
+	}
+	c.putMutex.Lock()
+	defer c.putMutex.Unlock()
+	seq := c.next[key] + 1
+	if err := c.putter.Put(&Sequenced{Key: key, Seq: seq, Value: val}, timeout); err != nil {
+		return err
+	}
+	c.next[key] = seq
+	return nil
+}
+
+// Get has the same timeout semantics as the wrapped Getter, values which
+// were not put through a SequenceChecker are returned unchecked.
+func (c *SequenceChecker) Get(timeout float64) (interface{}, error) {
+	c.getMutex.Lock()
+	defer c.getMutex.Unlock()
+	val, err := c.getter.Get(timeout)
+	if err != nil {
+		return nil, err
+	}
+	s, ok := val.(*Sequenced)
+	if !ok {
+		return val, nil
+	}
+	if expect := c.last[s.Key] + 1; s.Seq != expect {
+		c.violate(&OrderError{Key: s.Key, Expect: expect, Got: s.Seq})
+	}
+	c.last[s.Key] = s.Seq
+	return s.Value, nil
+}
+
+func (c *SequenceChecker) violate(err *OrderError) {
+	if c.OnViolation == nil {
+		panic(err)
+	}
+	c.OnViolation(err)
+}
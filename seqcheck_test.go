@@ -0,0 +1,68 @@
+package goqueue
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSequenceChecker(t *testing.T) {
+	queue := New(0)
+	checker := NewSequenceChecker(queue, queue)
+	var violations []*OrderError
+	checker.OnViolation = func(err *OrderError) {
+		violations = append(violations, err)
+	}
+
+	fmt.Println("Test values in FIFO order pass...")
+	for i := 0; i < 3; i++ {
+		checker.Put(i, -1)
+	}
+	for i := 0; i < 3; i++ {
+		if val, err := checker.Get(-1); err != nil || val.(int) != i {
+			t.Fatalf("Expect %v, got %v (%v)\n", i, val, err)
+		}
+	}
+	if len(violations) != 0 {
+		t.Fatalf("Unexpect violations: %v\n", violations)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test lost and reordered values are reported...")
+	checker.Put("a", -1)
+	checker.Put("b", -1)
+	checker.Put("c", -1)
+	queue.GetNoWait()
+	checker.Get(-1)
+	if len(violations) != 1 || violations[0].Expect != 4 || violations[0].Got != 5 {
+		t.Fatalf("Expect a gap reported, got %v\n", violations)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test ordering is checked per key...")
+	keyed := NewSequenceChecker(queue, queue)
+	keyed.Key = func(val interface{}) string { return val.(string)[:1] }
+	keyed.OnViolation = checker.OnViolation
+	violations = nil
+	queue.GetNoWait()
+	for _, v := range []string{"x1", "y1", "x2", "y2"} {
+		keyed.Put(v, -1)
+	}
+	for i := 0; i < 4; i++ {
+		keyed.Get(-1)
+	}
+	if len(violations) != 0 {
+		t.Fatalf("Unexpect violations: %v\n", violations)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test a violation panics by default...")
+	strict := NewSequenceChecker(queue, queue)
+	queue.PutNoWait(&Sequenced{Seq: 2, Value: "late"})
+	defer func() {
+		if _, ok := recover().(*OrderError); !ok {
+			t.Fatalf("Expect panic with OrderError\n")
+		}
+		fmt.Println("  ...PASSED")
+	}()
+	strict.Get(-1)
+}
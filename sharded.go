@@ -0,0 +1,261 @@
+package goqueue
+
+import (
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ShardedQueue spreads values over several Queues so concurrent Put and
+// Get operators rarely fight for the same lock. Values with the same key
+// go to the same shard and keep their FIFO order, the order across shards
+// is approximate.
+type ShardedQueue struct {
+	shards  []*Queue
+	loads   []shardLoad
+	active  int32 // shards Put starts from, see SetActive
+	putNext uint32
+	getNext uint32
+	hints   sync.Pool     // *shardHint, the home shard of each P
+	notify  chan struct{} // wakes a blocked Get operator
+}
+
+// shardLoad tells how often Put found another Put in its home shard.
+type shardLoad struct {
+	inside    int32
+	contended int64
+}
+
+// shardHint is where Put starts from. sync.Pool keeps a private item per
+// P, so the producers running on the same P mostly get the same hint back
+// and stick to one shard instead of bouncing its lock between cores.
+type shardHint struct {
+	seq int
+}
+
+// NewSharded create a ShardedQueue of n shards, maxSize is split among them,
+// the first maxSize%n shards take one more slot, and opts are applied to
+// every shard. If maxSize is zero, ShardedQueue will be infinite size,
+// otherwise n is lowered to maxSize so every shard gets a slot.
+func NewSharded(n, maxSize int, opts ...Option) *ShardedQueue {
+	if n < 1 {
+		n = 1
+	}
+	if maxSize > 0 && n > maxSize {
+		n = maxSize
+	}
+	q := &ShardedQueue{
+		shards: make([]*Queue, n),
+		loads:  make([]shardLoad, n),
+		active: int32(n),
+		notify: make(chan struct{}, 1),
+	}
+	for i := range q.shards {
+		size := maxSize / n
+		if i < maxSize%n {
+			size++
+		}
+		q.shards[i] = New(size, opts...)
+	}
+	q.hints.New = func() interface{} {
+		return &shardHint{seq: int(atomic.AddUint32(&q.putNext, 1))}
+	}
+	return q
+}
+
+// home returns the shard of the P running the caller, among the active
+// ones.
+func (q *ShardedQueue) home() int {
+	h := q.hints.Get().(*shardHint)
+	shard := h.seq % int(atomic.LoadInt32(&q.active))
+	q.hints.Put(h)
+	return shard
+}
+
+// Return the number of shards Put starts from.
+func (q *ShardedQueue) Active() int {
+	return int(atomic.LoadInt32(&q.active))
+}
+
+// SetActive changes the number of shards Put starts from, clamped between 1
+// and the shards of ShardedQueue. Put still moves on to the other shards
+// if those are full, and Get and PutKey use every shard, so the capacity
+// and the order of keys are kept.
+func (q *ShardedQueue) SetActive(n int) {
+	if n < 1 {
+		n = 1
+	}
+	if n > len(q.shards) {
+		n = len(q.shards)
+	}
+	atomic.StoreInt32(&q.active, int32(n))
+}
+
+// RunTuner adapts the active shards from the contention until stop is
+// closed. Every interval seconds, they are doubled if more than a tenth of
+// the Puts found another Put in their home shard, or halved down to min if
+// none did.
+func (q *ShardedQueue) RunTuner(min int, interval float64, stop <-chan struct{}) {
+	ticker := time.NewTicker(seconds(interval))
+	defer ticker.Stop()
+	var puts, contended int64
+	for {
+		select {
+		case <-ticker.C:
+			puts, contended = q.tune(min, puts, contended)
+		case <-stop:
+			return
+		}
+	}
+}
+
+// tune resizes the active shards from the totals since the last window,
+// and returns the new totals.
+func (q *ShardedQueue) tune(min int, puts, contended int64) (int64, int64) {
+	var p, c int64
+	for i, shard := range q.shards {
+		p += atomic.LoadInt64(&shard.stats.puts)
+		c += atomic.LoadInt64(&q.loads[i].contended)
+	}
+	n := q.Active()
+	switch {
+	case (c-contended)*10 > p-puts && c > contended:
+		n *= 2
+	case c == contended && n > min:
+		if n /= 2; n < min {
+			n = min
+		}
+	}
+	q.SetActive(n)
+	return p, c
+}
+
+func (q *ShardedQueue) signal() {
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Same as Get(-1).
+func (q *ShardedQueue) GetNoWait() (interface{}, error) {
+	return q.Get(-1)
+}
+
+// Get takes a value from the first non-empty shard, starting from a
+// different shard every time. The timeout semantics are the same as
+// Queue.Get.
+func (q *ShardedQueue) Get(timeout float64) (interface{}, error) {
+	deadline, stop := deadlineOf(timeout)
+	defer stop()
+	for {
+		start := atomic.AddUint32(&q.getNext, 1)
+		for i := range q.shards {
+			shard := q.shards[(int(start)+i)%len(q.shards)]
+			if v, err := shard.GetNoWait(); err == nil {
+				// Pass the wake-up on, more values may be waiting.
+				if !q.IsEmpty() {
+					q.signal()
+				}
+				return v, nil
+			}
+		}
+		if timeout < 0.0 {
+			return nil, ErrEmptyQueue
+		}
+		select {
+		case <-q.notify:
+		case <-deadline:
+			return nil, ErrEmptyQueue
+		}
+	}
+}
+
+// Same as Put(val, -1).
+func (q *ShardedQueue) PutNoWait(val interface{}) error {
+	return q.Put(val, -1)
+}
+
+// Put a value into the shard of the P running the caller, or the next one
+// with a free slot, or wait on the first if they are all full. The timeout
+// semantics are the same as Queue.Put.
+func (q *ShardedQueue) Put(val interface{}, timeout float64) error {
+	start := q.home()
+	if q.putHome(start, val) {
+		q.signal()
+		return nil
+	}
+	for i := 1; i < len(q.shards); i++ {
+		if q.shards[(start+i)%len(q.shards)].PutNoWait(val) == nil {
+			q.signal()
+			return nil
+		}
+	}
+	if timeout < 0.0 {
+		return ErrFullQueue
+	}
+	return q.putShard(q.shards[start%len(q.shards)], val, timeout)
+}
+
+// putHome tries the home shard, counting the Puts which find another one
+// in there.
+func (q *ShardedQueue) putHome(i int, val interface{}) bool {
+	l := &q.loads[i]
+	if atomic.AddInt32(&l.inside, 1) > 1 {
+		atomic.AddInt64(&l.contended, 1)
+	}
+	err := q.shards[i].PutNoWait(val)
+	atomic.AddInt32(&l.inside, -1)
+	return err == nil
+}
+
+// PutKey puts a value into the shard of key, the timeout semantics are the
+// same as Queue.Put.
+func (q *ShardedQueue) PutKey(key string, val interface{}, timeout float64) error {
+	return q.putShard(q.shards[HashPartition(key, len(q.shards))], val, timeout)
+}
+
+// HashPartition returns the partition of key among n, by FNV-1a hash.
+func HashPartition(key string, n int) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % uint32(n))
+}
+
+func (q *ShardedQueue) putShard(shard *Queue, val interface{}, timeout float64) error {
+	if err := shard.Put(val, timeout); err != nil {
+		return err
+	}
+	q.signal()
+	return nil
+}
+
+// Return size of ShardedQueue, the sum of every shard.
+func (q *ShardedQueue) Size() int {
+	n := 0
+	for _, shard := range q.shards {
+		n += shard.Size()
+	}
+	return n
+}
+
+// Return true if every shard is empty.
+func (q *ShardedQueue) IsEmpty() bool {
+	for _, shard := range q.shards {
+		if !shard.IsEmpty() {
+			return false
+		}
+	}
+	return true
+}
+
+// Return true if every shard is full.
+func (q *ShardedQueue) IsFull() bool {
+	for _, shard := range q.shards {
+		if !shard.IsFull() {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,146 @@
+package goqueue
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+var _ Interface = (*ShardedQueue)(nil)
+
+func TestShardedKeyOrder(t *testing.T) {
+	queue := NewSharded(4, 0)
+
+	fmt.Println("Test values with the same key keep their order...")
+	for i := 0; i < 20; i++ {
+		queue.PutKey("a", i, -1)
+		queue.PutNoWait(-1)
+	}
+	last := -1
+	for !queue.IsEmpty() {
+		val, err := queue.GetNoWait()
+		if err != nil {
+			t.Fatalf("Unexpect error: %v\n", err)
+		}
+		if v := val.(int); v >= 0 {
+			if v != last+1 {
+				t.Fatalf("Expect %d after %d, got %d\n", last+1, last, v)
+			}
+			last = v
+		}
+	}
+	if last != 19 {
+		t.Fatalf("Expect every keyed value, got up to %d\n", last)
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestShardedBlocking(t *testing.T) {
+	queue := NewSharded(3, 3)
+
+	fmt.Println("Test maxSize is split among shards...")
+	for i := 0; i < 3; i++ {
+		if err := queue.PutNoWait(i); err != nil {
+			t.Fatalf("Unexpect error: %v\n", err)
+		}
+	}
+	if err := queue.PutNoWait(3); err != ErrFullQueue || !queue.IsFull() {
+		t.Fatalf("Expect %v, got %v\n", ErrFullQueue, err)
+	}
+	uneven := NewSharded(3, 10)
+	for uneven.PutNoWait(0) == nil {
+	}
+	if uneven.Size() != 10 {
+		t.Fatalf("Expect %d values, got %d\n", 10, uneven.Size())
+	}
+	clamped := NewSharded(4, 2)
+	if n := len(clamped.shards); n != 2 {
+		t.Fatalf("Expect shards clamped to 2, got %d\n", n)
+	}
+	for clamped.PutNoWait(0) == nil {
+	}
+	if clamped.Size() != 2 {
+		t.Fatalf("Expect %d values, got %d\n", 2, clamped.Size())
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test blocked getters are all woken up...")
+	for i := 0; i < 3; i++ {
+		queue.GetNoWait()
+	}
+	if _, err := queue.Get(0.05); err != ErrEmptyQueue {
+		t.Fatalf("Expect %v, got %v\n", ErrEmptyQueue, err)
+	}
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := queue.Get(5); err != nil {
+				t.Errorf("Unexpect error: %v\n", err)
+			}
+		}()
+	}
+	for i := 0; i < 10; i++ {
+		if err := queue.Put(i, 5); err != nil {
+			t.Fatalf("Unexpect error: %v\n", err)
+		}
+	}
+	wg.Wait()
+	fmt.Println("  ...PASSED")
+}
+
+func TestShardedTuner(t *testing.T) {
+	queue := NewSharded(8, 0)
+
+	fmt.Println("Test the tuner halves idle shards and doubles contended ones...")
+	puts, contended := queue.tune(2, 0, 0)
+	if queue.Active() != 4 {
+		t.Fatalf("Expect %d active shards, got %d\n", 4, queue.Active())
+	}
+	puts, contended = queue.tune(2, puts, contended)
+	puts, contended = queue.tune(2, puts, contended)
+	if queue.Active() != 2 {
+		t.Fatalf("Expect %d active shards, got %d\n", 2, queue.Active())
+	}
+	for i := 0; i < 10; i++ {
+		queue.PutNoWait(i)
+	}
+	atomic.AddInt64(&queue.loads[0].contended, 2)
+	queue.tune(2, puts, contended)
+	if queue.Active() != 4 {
+		t.Fatalf("Expect %d active shards, got %d\n", 4, queue.Active())
+	}
+	if queue.Size() != 10 {
+		t.Fatalf("Expect size %d, got %d\n", 10, queue.Size())
+	}
+	queue.SetActive(100)
+	if queue.Active() != 8 {
+		t.Fatalf("Expect %d active shards, got %d\n", 8, queue.Active())
+	}
+	fmt.Println("  ...PASSED")
+}
+
+// Put starts from the shard of the P, compare with -cpu 1,4,8 against
+// BenchmarkContendedQueue to see the lock contention.
+func BenchmarkContendedSharded(b *testing.B) {
+	queue := NewSharded(runtime.GOMAXPROCS(0), 0)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			queue.PutNoWait(1)
+			queue.GetNoWait()
+		}
+	})
+}
+
+func BenchmarkContendedQueue(b *testing.B) {
+	queue := New(0)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			queue.PutNoWait(1)
+			queue.GetNoWait()
+		}
+	})
+}
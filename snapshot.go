@@ -0,0 +1,25 @@
+package goqueue
+
+import (
+	"encoding/gob"
+	"io"
+)
+
+// Snapshot writes the values of Queue to w with encoding/gob, custom types
+// must be registered by gob.Register. The values are taken by ToSlice, so
+// the snapshot is a consistent point in time, and Queue is not blocked
+// while they are encoded.
+func (q *Queue) Snapshot(w io.Writer) error {
+	return gob.NewEncoder(w).Encode(q.ToSlice())
+}
+
+// Restore reads values written by Snapshot from r and puts them at the back
+// of Queue in their order, as PutAll does. It returns the number of values
+// put, and ErrFullQueue if some didn't fit.
+func (q *Queue) Restore(r io.Reader) (int, error) {
+	var vals []interface{}
+	if err := gob.NewDecoder(r).Decode(&vals); err != nil {
+		return 0, err
+	}
+	return q.PutAll(vals)
+}
@@ -0,0 +1,47 @@
+package goqueue
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"testing"
+)
+
+type snapshotItem struct {
+	ID   int
+	Name string
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	gob.Register(snapshotItem{})
+	queue := New(0)
+	queue.PutNoWait(1)
+	queue.PutNoWait("two")
+	queue.PutNoWait(snapshotItem{3, "three"})
+
+	fmt.Println("Test Restore reloads a Snapshot in order...")
+	buf := &bytes.Buffer{}
+	if err := queue.Snapshot(buf); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	if queue.Size() != 3 {
+		t.Fatalf("Expect Snapshot removes nothing, got size %d\n", queue.Size())
+	}
+	restored := New(0)
+	if n, err := restored.Restore(bytes.NewReader(buf.Bytes())); err != nil || n != 3 {
+		t.Fatalf("Expect 3 restored, got %d (%v)\n", n, err)
+	}
+	for _, expect := range []interface{}{1, "two", snapshotItem{3, "three"}} {
+		if val, _ := restored.GetNoWait(); val != expect {
+			t.Fatalf("Expect %v, got %v\n", expect, val)
+		}
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test Restore respects maxSize...")
+	small := New(2)
+	if n, err := small.Restore(bytes.NewReader(buf.Bytes())); err != ErrFullQueue || n != 2 {
+		t.Fatalf("Expect 2 restored and %v, got %d (%v)\n", ErrFullQueue, n, err)
+	}
+	fmt.Println("  ...PASSED")
+}
@@ -0,0 +1,80 @@
+package sqlqueue
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Dialect describes the SQL differences between databases.
+type Dialect struct {
+	Name string
+	// Placeholder returns the bind variable for the n-th (1-based) argument.
+	Placeholder func(n int) string
+	// Migrations are the DDL statements which build the items table, one
+	// statement per schema version, {table} is replaced by the table name.
+	// The table must have an auto increment "id" column and a "payload"
+	// binary column.
+	Migrations []string
+	// LockClause is appended to the query which selects the head item, e.g.
+	// " FOR UPDATE SKIP LOCKED", so concurrent consumers skip each other's
+	// rows instead of racing for the same one.
+	LockClause string
+	// Notify is executed in the Put transaction, so other processes can be
+	// woken up by a Notifier as soon as the item is committed.
+	Notify string
+	// PutLock is executed first in the transaction of a Put into a bounded
+	// Queue, so concurrent Puts count the items one at a time and don't
+	// overshoot maxSize.
+	PutLock string
+}
+
+// MySQL dialect, also works for MariaDB.
+var MySQL = Dialect{
+	Name:        "mysql",
+	Placeholder: func(n int) string { return "?" },
+	Migrations: []string{
+		"CREATE TABLE IF NOT EXISTS {table} (id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY, payload LONGBLOB NOT NULL)",
+	},
+	// Locks every row and the gap after the last one, so a concurrent Put
+	// waits until the transaction commits.
+	PutLock: "SELECT COUNT(*) FROM {table} FOR UPDATE",
+}
+
+// PostgreSQL dialect, items are taken with SKIP LOCKED and every Put sends a
+// notification on the channel named after the table, see Notifier.
+var PostgreSQL = Dialect{
+	Name:        "postgres",
+	Placeholder: func(n int) string { return fmt.Sprintf("$%d", n) },
+	Migrations: []string{
+		"CREATE TABLE IF NOT EXISTS {table} (id BIGSERIAL PRIMARY KEY, payload BYTEA NOT NULL)",
+	},
+	LockClause: " FOR UPDATE SKIP LOCKED",
+	Notify:     "SELECT pg_notify('{table}', '')",
+	PutLock:    "SELECT pg_advisory_xact_lock(hashtext('{table}'))",
+}
+
+// SQLite dialect, works with any database/sql SQLite driver, e.g. the pure
+// Go modernc.org/sqlite. SQLite allows one writer at a time, so open the
+// database with a busy timeout and immediate transactions (for
+// modernc.org/sqlite: "file.db?_pragma=busy_timeout(5000)&_txlock=immediate"),
+// or call db.SetMaxOpenConns(1), otherwise concurrent Get/Put may fail with
+// SQLITE_BUSY. Immediate transactions also serialize bounded Puts.
+var SQLite = Dialect{
+	Name:        "sqlite",
+	Placeholder: func(n int) string { return "?" },
+	Migrations: []string{
+		"CREATE TABLE IF NOT EXISTS {table} (id INTEGER PRIMARY KEY AUTOINCREMENT, payload BLOB NOT NULL)",
+	},
+}
+
+// format replaces {table} in query, and the verbs with placeholders.
+func (d Dialect) format(table, query string, nargs int) string {
+	marks := make([]interface{}, nargs)
+	for i := range marks {
+		marks[i] = d.Placeholder(i + 1)
+	}
+	if nargs > 0 {
+		query = fmt.Sprintf(query, marks...)
+	}
+	return strings.Replace(query, "{table}", table, -1)
+}
@@ -0,0 +1,257 @@
+package sqlqueue
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"regexp"
+	"sync"
+	"testing"
+)
+
+// A tiny in-memory driver which only understands the statements issued by
+// this package. A transaction holds the database lock until it finishes,
+// so transactions are serialized like in SQLite.
+
+type fakeItem struct {
+	id      int64
+	payload []byte
+}
+
+type fakeTable struct {
+	version *int64
+	items   []fakeItem
+	nextID  int64
+}
+
+type fakeDB struct {
+	mutex  sync.Mutex
+	tables map[string]*fakeTable
+	ddl    []string // executed CREATE statements
+	locks  int      // executed PutLock statements
+	notify chan string
+}
+
+func (db *fakeDB) clone() map[string]*fakeTable {
+	tables := make(map[string]*fakeTable, len(db.tables))
+	for name, t := range db.tables {
+		c := *t
+		c.items = append([]fakeItem(nil), t.items...)
+		if t.version != nil {
+			v := *t.version
+			c.version = &v
+		}
+		tables[name] = &c
+	}
+	return tables
+}
+
+var (
+	fakeDBsMu sync.Mutex
+	fakeDBs   = map[string]*fakeDB{}
+)
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	fakeDBsMu.Lock()
+	defer fakeDBsMu.Unlock()
+	db, ok := fakeDBs[name]
+	if !ok {
+		db = &fakeDB{tables: map[string]*fakeTable{}, notify: make(chan string, 16)}
+		fakeDBs[name] = db
+	}
+	return &fakeConn{db: db}, nil
+}
+
+type fakeConn struct {
+	db     *fakeDB
+	intx   bool
+	backup map[string]*fakeTable
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{conn: c, query: query}, nil
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	c.db.mutex.Lock()
+	c.intx = true
+	c.backup = c.db.clone()
+	return c, nil
+}
+
+func (c *fakeConn) Commit() error {
+	c.intx = false
+	c.backup = nil
+	c.db.mutex.Unlock()
+	return nil
+}
+
+func (c *fakeConn) Rollback() error {
+	c.intx = false
+	c.db.tables = c.backup
+	c.backup = nil
+	c.db.mutex.Unlock()
+	return nil
+}
+
+var (
+	reCreate  = regexp.MustCompile(`^CREATE TABLE IF NOT EXISTS (\w+)`)
+	reVersion = regexp.MustCompile(`^SELECT version FROM (\w+)`)
+	reSetVer  = regexp.MustCompile(`^(?:INSERT INTO (\w+) \(version\)|UPDATE (\w+) SET version)`)
+	reInsert  = regexp.MustCompile(`^INSERT INTO (\w+) \(payload\)`)
+	reCount   = regexp.MustCompile(`^SELECT COUNT\(\*\) FROM (\w+)`)
+	reHead    = regexp.MustCompile(`^SELECT id, payload FROM (\w+) ORDER BY id LIMIT 1`)
+	reDelete  = regexp.MustCompile(`^DELETE FROM (\w+) WHERE id = `)
+	reNotify  = regexp.MustCompile(`^SELECT pg_notify\('(\w+)'`)
+	rePutLock = regexp.MustCompile(`^SELECT (?:COUNT\(\*\) FROM \w+ FOR UPDATE|pg_advisory_xact_lock)`)
+)
+
+type fakeStmt struct {
+	conn  *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) table(name string) (*fakeTable, error) {
+	t, ok := s.conn.db.tables[name]
+	if !ok {
+		return nil, fmt.Errorf("no such table: %s", name)
+	}
+	return t, nil
+}
+
+func (s *fakeStmt) lock() func() {
+	if s.conn.intx {
+		return func() {}
+	}
+	s.conn.db.mutex.Lock()
+	return s.conn.db.mutex.Unlock
+}
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	defer s.lock()()
+	db := s.conn.db
+
+	if m := reCreate.FindStringSubmatch(s.query); m != nil {
+		if _, ok := db.tables[m[1]]; !ok {
+			db.tables[m[1]] = &fakeTable{}
+		}
+		db.ddl = append(db.ddl, s.query)
+		return driver.RowsAffected(0), nil
+	}
+	if m := reSetVer.FindStringSubmatch(s.query); m != nil {
+		t, err := s.table(m[1] + m[2])
+		if err != nil {
+			return nil, err
+		}
+		v := args[0].(int64)
+		t.version = &v
+		return driver.RowsAffected(1), nil
+	}
+	if m := reInsert.FindStringSubmatch(s.query); m != nil {
+		t, err := s.table(m[1])
+		if err != nil {
+			return nil, err
+		}
+		t.nextID++
+		t.items = append(t.items, fakeItem{id: t.nextID, payload: args[0].([]byte)})
+		return driver.RowsAffected(1), nil
+	}
+	if m := reDelete.FindStringSubmatch(s.query); m != nil {
+		t, err := s.table(m[1])
+		if err != nil {
+			return nil, err
+		}
+		for i, item := range t.items {
+			if item.id == args[0].(int64) {
+				t.items = append(t.items[:i], t.items[i+1:]...)
+				return driver.RowsAffected(1), nil
+			}
+		}
+		return driver.RowsAffected(0), nil
+	}
+	if m := reNotify.FindStringSubmatch(s.query); m != nil {
+		db.notify <- m[1]
+		return driver.RowsAffected(0), nil
+	}
+	if rePutLock.MatchString(s.query) {
+		// Transactions are serialized already.
+		db.locks++
+		return driver.RowsAffected(0), nil
+	}
+	return nil, fmt.Errorf("unsupported exec: %s", s.query)
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	defer s.lock()()
+
+	if m := reVersion.FindStringSubmatch(s.query); m != nil {
+		t, err := s.table(m[1])
+		if err != nil {
+			return nil, err
+		}
+		rows := &fakeRows{columns: []string{"version"}}
+		if t.version != nil {
+			rows.values = [][]driver.Value{{*t.version}}
+		}
+		return rows, nil
+	}
+	if m := reCount.FindStringSubmatch(s.query); m != nil {
+		t, err := s.table(m[1])
+		if err != nil {
+			return nil, err
+		}
+		return &fakeRows{columns: []string{"count"}, values: [][]driver.Value{{int64(len(t.items))}}}, nil
+	}
+	if m := reHead.FindStringSubmatch(s.query); m != nil {
+		t, err := s.table(m[1])
+		if err != nil {
+			return nil, err
+		}
+		rows := &fakeRows{columns: []string{"id", "payload"}}
+		if len(t.items) > 0 {
+			rows.values = [][]driver.Value{{t.items[0].id, t.items[0].payload}}
+		}
+		return rows, nil
+	}
+	return nil, fmt.Errorf("unsupported query: %s", s.query)
+}
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+func init() {
+	sql.Register("sqlqueuefake", fakeDriver{})
+}
+
+func openFakeDB(t *testing.T, name string) (*sql.DB, *fakeDB) {
+	db, err := sql.Open("sqlqueuefake", name)
+	if err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	if err := db.Ping(); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	return db, fakeDBs[name]
+}
@@ -0,0 +1,44 @@
+package sqlqueue
+
+import (
+	"database/sql"
+)
+
+// Migrate brings table up to the latest schema version of the dialect.
+// The applied version is kept in the "<table>_schema" table, so Migrate
+// is safe to call every time the application starts.
+func Migrate(db *sql.DB, d Dialect, table string) error {
+	_, err := db.Exec(d.format(table, "CREATE TABLE IF NOT EXISTS {table}_schema (version INTEGER NOT NULL)", 0))
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	version := 0
+	err = tx.QueryRow(d.format(table, "SELECT version FROM {table}_schema", 0)).Scan(&version)
+	if err == sql.ErrNoRows {
+		_, err = tx.Exec(d.format(table, "INSERT INTO {table}_schema (version) VALUES (%s)", 1), 0)
+	}
+	if err != nil {
+		return err
+	}
+
+	if version >= len(d.Migrations) {
+		return tx.Commit()
+	}
+	for _, stmt := range d.Migrations[version:] {
+		if _, err := tx.Exec(d.format(table, stmt, 0)); err != nil {
+			return err
+		}
+	}
+	_, err = tx.Exec(d.format(table, "UPDATE {table}_schema SET version = %s", 1), len(d.Migrations))
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
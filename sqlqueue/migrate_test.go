@@ -0,0 +1,41 @@
+package sqlqueue
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestMigrate(t *testing.T) {
+	db, fake := openFakeDB(t, "migrate")
+	dialect := MySQL
+	dialect.Migrations = []string{
+		"CREATE TABLE IF NOT EXISTS {table} (id BIGINT)",
+	}
+
+	fmt.Println("Test Migrate creates the schema once...")
+	for i := 0; i < 2; i++ {
+		if err := Migrate(db, dialect, "jobs"); err != nil {
+			t.Fatalf("Unexpect error: %v\n", err)
+		}
+	}
+	if len(fake.ddl) != 3 {
+		t.Fatalf("Expect %d CREATE statements, got %d: %v\n", 3, len(fake.ddl), fake.ddl)
+	}
+	if v := *fake.tables["jobs_schema"].version; v != 1 {
+		t.Fatalf("Expect schema version %d, got %d\n", 1, v)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test Migrate applies new versions only...")
+	dialect.Migrations = append(dialect.Migrations, "CREATE TABLE IF NOT EXISTS {table}_extra (id BIGINT)")
+	if err := Migrate(db, dialect, "jobs"); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	if last := fake.ddl[len(fake.ddl)-1]; last != "CREATE TABLE IF NOT EXISTS jobs_extra (id BIGINT)" {
+		t.Fatalf("Unexpect statement: %v\n", last)
+	}
+	if v := *fake.tables["jobs_schema"].version; v != 2 {
+		t.Fatalf("Expect schema version %d, got %d\n", 2, v)
+	}
+	fmt.Println("  ...PASSED")
+}
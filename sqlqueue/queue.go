@@ -0,0 +1,234 @@
+/*
+Package sqlqueue implements a durable queue on top of database/sql.
+
+Items are stored in a single table and removed by a transactional Get, so a
+queue survives restarts and can be shared by many processes. Blocking Get
+and Put poll the database every PollInterval seconds.
+
+Dialects for MySQL, PostgreSQL and SQLite are provided, the driver itself is
+imported by the application; for SQLite a pure Go driver keeps the whole
+queue in a single file without cgo or external services.
+*/
+
+package sqlqueue
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/damnever/goqueue"
+)
+
+// Codec converts values to and from the bytes stored in the database.
+type Codec = goqueue.Codec
+
+// Notifier wakes up a blocking Get when another process puts an item.
+//
+// For PostgreSQL, wrap the driver's LISTEN support, e.g. with lib/pq:
+//
+//	type listener struct{ l *pq.Listener }
+//
+//	func (n listener) Wait(d time.Duration) {
+//		select {
+//		case <-n.l.Notify:
+//		case <-time.After(d):
+//		}
+//	}
+//
+// where the pq.Listener has called Listen with the table name.
+type Notifier interface {
+	// Wait blocks until a notification arrives or d passed.
+	Wait(d time.Duration)
+}
+
+// GobCodec encodes values with encoding/gob, custom types must be
+// registered by gob.Register.
+type GobCodec = goqueue.GobCodec
+
+type Queue struct {
+	db      *sql.DB
+	dialect Dialect
+	table   string
+	maxSize int
+
+	// Codec used to store values, default is GobCodec.
+	Codec Codec
+	// PollInterval is the seconds between two tries of a blocking Get/Put,
+	// default is 0.1.
+	PollInterval float64
+	// Notifier is optional, if set a blocking Get waits on it between tries
+	// rather than sleeping, so it returns as soon as an item is committed.
+	Notifier Notifier
+}
+
+// New create a Queue stored in table, the table is created or upgraded by
+// Migrate. The maxSize variable sets the max Queue size, if maxSize is zero,
+// Queue will be infinite size.
+func New(db *sql.DB, dialect Dialect, table string, maxSize int) (*Queue, error) {
+	if err := Migrate(db, dialect, table); err != nil {
+		return nil, err
+	}
+	q := &Queue{
+		db:           db,
+		dialect:      dialect,
+		table:        table,
+		maxSize:      maxSize,
+		Codec:        GobCodec{},
+		PollInterval: 0.1,
+	}
+	return q, nil
+}
+
+// Same as Get(-1).
+func (q *Queue) GetNoWait() (interface{}, error) {
+	return q.Get(-1)
+}
+
+// Get has the same timeout semantics as goqueue.Queue.Get.
+func (q *Queue) Get(timeout float64) (interface{}, error) {
+	var val interface{}
+	sleep := time.Sleep
+	if q.Notifier != nil {
+		sleep = q.Notifier.Wait
+	}
+	err := q.poll(timeout, goqueue.ErrEmptyQueue, sleep, func() (bool, error) {
+		data, ok, err := q.get()
+		if err != nil || !ok {
+			return false, err
+		}
+		val, err = q.Codec.Unmarshal(data)
+		return true, err
+	})
+	return val, err
+}
+
+// Same as Put(val, -1).
+func (q *Queue) PutNoWait(val interface{}) error {
+	return q.Put(val, -1)
+}
+
+// Put has the same timeout semantics as goqueue.Queue.Put.
+func (q *Queue) Put(val interface{}, timeout float64) error {
+	data, err := q.Codec.Marshal(val)
+	if err != nil {
+		return err
+	}
+	return q.poll(timeout, goqueue.ErrFullQueue, time.Sleep, func() (bool, error) {
+		return q.put(data)
+	})
+}
+
+// Count returns the number of items in the Queue.
+func (q *Queue) Count() (int, error) {
+	n := 0
+	err := q.db.QueryRow(q.dialect.format(q.table, "SELECT COUNT(*) FROM {table}", 0)).Scan(&n)
+	return n, err
+}
+
+// Return size of Queue, or 0 if the database can't be queried, use Count
+// to get the error.
+func (q *Queue) Size() int {
+	n, _ := q.Count()
+	return n
+}
+
+// Return true if Queue is empty.
+func (q *Queue) IsEmpty() bool {
+	return q.Size() == 0
+}
+
+// Return true if Queue is full.
+func (q *Queue) IsFull() bool {
+	return q.maxSize > 0 && q.maxSize <= q.Size()
+}
+
+// poll calls try until it succeeds or timeout passed, sleep is called
+// between two tries.
+func (q *Queue) poll(timeout float64, errTimeout error, sleep func(time.Duration), try func() (bool, error)) error {
+	deadline := time.Now().Add(time.Duration(timeout * float64(time.Second)))
+	interval := time.Duration(q.PollInterval * float64(time.Second))
+	for {
+		ok, err := try()
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+		if timeout < 0.0 {
+			return errTimeout
+		}
+		wait := interval
+		if timeout > 0.0 {
+			left := deadline.Sub(time.Now())
+			if left <= 0 {
+				return errTimeout
+			}
+			if left < wait {
+				wait = left
+			}
+		}
+		sleep(wait)
+	}
+}
+
+func (q *Queue) get() ([]byte, bool, error) {
+	tx, err := q.db.Begin()
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback()
+
+	var id int64
+	var data []byte
+	err = tx.QueryRow(q.dialect.format(q.table, "SELECT id, payload FROM {table} ORDER BY id LIMIT 1"+q.dialect.LockClause, 0)).Scan(&id, &data)
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+
+	res, err := tx.Exec(q.dialect.format(q.table, "DELETE FROM {table} WHERE id = %s", 1), id)
+	if err != nil {
+		return nil, false, err
+	}
+	// Taken by another consumer, try again later.
+	if n, err := res.RowsAffected(); err != nil || n != 1 {
+		return nil, false, err
+	}
+	return data, true, tx.Commit()
+}
+
+func (q *Queue) put(data []byte) (bool, error) {
+	tx, err := q.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	if q.maxSize > 0 {
+		if q.dialect.PutLock != "" {
+			if _, err := tx.Exec(q.dialect.format(q.table, q.dialect.PutLock, 0)); err != nil {
+				return false, err
+			}
+		}
+		n := 0
+		err := tx.QueryRow(q.dialect.format(q.table, "SELECT COUNT(*) FROM {table}", 0)).Scan(&n)
+		if err != nil {
+			return false, err
+		}
+		if n >= q.maxSize {
+			return false, nil
+		}
+	}
+	_, err = tx.Exec(q.dialect.format(q.table, "INSERT INTO {table} (payload) VALUES (%s)", 1), data)
+	if err != nil {
+		return false, err
+	}
+	if q.dialect.Notify != "" {
+		if _, err := tx.Exec(q.dialect.format(q.table, q.dialect.Notify, 0)); err != nil {
+			return false, err
+		}
+	}
+	return true, tx.Commit()
+}
@@ -0,0 +1,202 @@
+package sqlqueue
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/damnever/goqueue"
+)
+
+var _ goqueue.Interface = (*Queue)(nil)
+
+func newQueue(t *testing.T, name string, maxSize int) *Queue {
+	return newDialectQueue(t, name, MySQL, maxSize)
+}
+
+func newDialectQueue(t *testing.T, name string, dialect Dialect, maxSize int) *Queue {
+	db, _ := openFakeDB(t, name)
+	q, err := New(db, dialect, "items", maxSize)
+	if err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	q.PollInterval = 0.01
+	return q
+}
+
+func TestFIFO(t *testing.T) {
+	for _, dialect := range []Dialect{MySQL, PostgreSQL, SQLite} {
+		fmt.Printf("Test FIFO with %s dialect...\n", dialect.Name)
+		testFIFO(t, newDialectQueue(t, "fifo-"+dialect.Name, dialect, 0))
+		fmt.Println("  ...PASSED")
+	}
+}
+
+func testFIFO(t *testing.T, q *Queue) {
+	for i := 0; i < 3; i++ {
+		if err := q.PutNoWait(i); err != nil {
+			t.Fatalf("Unexpect error: %v\n", err)
+		}
+	}
+	if q.Size() != 3 {
+		t.Fatalf("Expect Queue size %d, got %d\n", 3, q.Size())
+	}
+	for i := 0; i < 3; i++ {
+		val, err := q.GetNoWait()
+		if err != nil {
+			t.Fatalf("Unexpect error: %v\n", err)
+		} else if val.(int) != i {
+			t.Fatalf("Queue is not FIFO")
+		}
+	}
+	if _, err := q.GetNoWait(); err != goqueue.ErrEmptyQueue {
+		t.Fatalf("Expect %v, got %v\n", goqueue.ErrEmptyQueue, err)
+	}
+}
+
+func TestBlockGetPut(t *testing.T) {
+	q := newQueue(t, "block", 1)
+
+	fmt.Println("Test Put on a full Queue...")
+	q.PutNoWait("a")
+	if !q.IsFull() {
+		t.Fatalf("Expect Queue is full\n")
+	}
+	if err := q.PutNoWait("b"); err != goqueue.ErrFullQueue {
+		t.Fatalf("Expect %v, got %v\n", goqueue.ErrFullQueue, err)
+	}
+	if err := q.Put("b", 0.05); err != goqueue.ErrFullQueue {
+		t.Fatalf("Expect %v, got %v\n", goqueue.ErrFullQueue, err)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test blocking Put waits for a free slot...")
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		q.GetNoWait()
+	}()
+	if err := q.Put("b", 1); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test blocking Get waits for an item...")
+	q.GetNoWait()
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		q.PutNoWait("c")
+	}()
+	val, err := q.Get(0)
+	if err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	} else if val.(string) != "c" {
+		t.Fatalf("Expect %v, got %v\n", "c", val)
+	}
+	if _, err := q.Get(0.05); err != goqueue.ErrEmptyQueue {
+		t.Fatalf("Expect %v, got %v\n", goqueue.ErrEmptyQueue, err)
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestConcurrentGet(t *testing.T) {
+	q := newQueue(t, "concurrent", 0)
+	for i := 0; i < 100; i++ {
+		q.PutNoWait(i)
+	}
+
+	fmt.Println("Test concurrent Get delivers every item once...")
+	wg := &sync.WaitGroup{}
+	mutex := sync.Mutex{}
+	seen := map[int]bool{}
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				val, err := q.GetNoWait()
+				if err != nil {
+					return
+				}
+				mutex.Lock()
+				seen[val.(int)] = true
+				mutex.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if len(seen) != 100 {
+		t.Fatalf("Expect %d items, got %d\n", 100, len(seen))
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestBoundedPut(t *testing.T) {
+	for _, dialect := range []Dialect{MySQL, PostgreSQL, SQLite} {
+		fmt.Printf("Test concurrent Put with %s dialect stops at maxSize...\n", dialect.Name)
+		db, fake := openFakeDB(t, "bounded-"+dialect.Name)
+		q, err := New(db, dialect, "items", 10)
+		if err != nil {
+			t.Fatalf("Unexpect error: %v\n", err)
+		}
+		wg := &sync.WaitGroup{}
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 10; j++ {
+					q.PutNoWait(j)
+				}
+			}()
+		}
+		wg.Wait()
+		if q.Size() != 10 {
+			t.Fatalf("Expect Queue size %d, got %d\n", 10, q.Size())
+		}
+		fake.mutex.Lock()
+		locks := fake.locks
+		fake.mutex.Unlock()
+		if dialect.PutLock != "" && locks != 40 {
+			t.Fatalf("Expect %d PutLock statements, got %d\n", 40, locks)
+		}
+		fmt.Println("  ...PASSED")
+	}
+}
+
+type fakeNotifier struct {
+	notify chan string
+}
+
+func (n fakeNotifier) Wait(d time.Duration) {
+	select {
+	case <-n.notify:
+	case <-time.After(d):
+	}
+}
+
+func TestPostgreSQLNotify(t *testing.T) {
+	db, fake := openFakeDB(t, "postgres")
+	q, err := New(db, PostgreSQL, "items", 0)
+	if err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	q.PollInterval = 10
+	q.Notifier = fakeNotifier{notify: fake.notify}
+
+	fmt.Println("Test blocking Get is woken up by a notification...")
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		q.PutNoWait("a")
+	}()
+	start := time.Now()
+	val, err := q.Get(5)
+	if err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	} else if val.(string) != "a" {
+		t.Fatalf("Expect %v, got %v\n", "a", val)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("Get returned after %v, notification is ignored\n", elapsed)
+	}
+	fmt.Println("  ...PASSED")
+}
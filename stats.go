@@ -0,0 +1,177 @@
+package goqueue
+
+import (
+	"expvar"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// counters are updated atomically, so Stats doesn't take the lock.
+type counters struct {
+	puts        int64
+	gets        int64
+	putTimeouts int64
+	getTimeouts int64
+	rejected    int64
+	dropped     int64
+	peak        int64 // max size, stored by publish
+	putters     int64 // blocked Put operators, stored by unlock
+	getters     int64 // blocked Get operators, stored by unlock
+}
+
+func (c *counters) block(putters, getters int) {
+	atomic.StoreInt64(&c.putters, int64(putters))
+	atomic.StoreInt64(&c.getters, int64(getters))
+}
+
+func (c *counters) add(puts, gets int) {
+	if puts != 0 {
+		atomic.AddInt64(&c.puts, int64(puts))
+	}
+	if gets != 0 {
+		atomic.AddInt64(&c.gets, int64(gets))
+	}
+}
+
+// Stats are the counters of a Queue since it was created, or since
+// ResetStats was called.
+type Stats struct {
+	Size int
+	// HighWater is the max size reached.
+	HighWater int
+	// Putters and Getters are the Put and Get operators blocked right now.
+	Putters int
+	Getters int
+	// Puts and Gets count the values put into and got from Queue.
+	Puts int64
+	Gets int64
+	// PutTimeouts and GetTimeouts count the Put and Get operators which
+	// gave up waiting, because of their timeout or their context.
+	PutTimeouts int64
+	GetTimeouts int64
+	// Rejected counts the Put operators which didn't wait and got
+	// ErrFullQueue.
+	Rejected int64
+	// Dropped counts the values discarded by the FullPolicy, RemoveFunc,
+	// Clear or Reset, they don't count as Gets.
+	Dropped int64
+}
+
+// Stats returns the current counters, it doesn't take the lock.
+func (q *Queue) Stats() Stats {
+	return Stats{
+		Size:        q.Size(),
+		HighWater:   int(atomic.LoadInt64(&q.stats.peak)),
+		Putters:     int(atomic.LoadInt64(&q.stats.putters)),
+		Getters:     int(atomic.LoadInt64(&q.stats.getters)),
+		Puts:        atomic.LoadInt64(&q.stats.puts),
+		Gets:        atomic.LoadInt64(&q.stats.gets),
+		PutTimeouts: atomic.LoadInt64(&q.stats.putTimeouts),
+		GetTimeouts: atomic.LoadInt64(&q.stats.getTimeouts),
+		Rejected:    atomic.LoadInt64(&q.stats.rejected),
+		Dropped:     atomic.LoadInt64(&q.stats.dropped),
+	}
+}
+
+// ResetStats zeroes the counters, the high water mark starts again from
+// the current size.
+func (q *Queue) ResetStats() {
+	q.mutex.Lock()
+	defer q.unlock()
+	for _, c := range []*int64{&q.stats.puts, &q.stats.gets, &q.stats.putTimeouts, &q.stats.getTimeouts, &q.stats.rejected, &q.stats.dropped} {
+		atomic.StoreInt64(c, 0)
+	}
+	atomic.StoreInt64(&q.stats.peak, int64(q.size()))
+}
+
+// counter returns the increase of a counter from last to cur, a counter
+// lower than last was reset by ResetStats meanwhile.
+func counter(cur, last int64) int64 {
+	if cur < last {
+		return cur
+	}
+	return cur - last
+}
+
+// since returns the counters of s increased from last, the gauges and the
+// high water mark are the ones of s.
+func (s Stats) since(last Stats) Stats {
+	s.Puts = counter(s.Puts, last.Puts)
+	s.Gets = counter(s.Gets, last.Gets)
+	s.PutTimeouts = counter(s.PutTimeouts, last.PutTimeouts)
+	s.GetTimeouts = counter(s.GetTimeouts, last.GetTimeouts)
+	s.Rejected = counter(s.Rejected, last.Rejected)
+	s.Dropped = counter(s.Dropped, last.Dropped)
+	return s
+}
+
+// StatsWindows keeps the Stats of a Queue in buckets of a fixed interval,
+// e.g. per-minute ones, so dashboards can show rates over windows rather
+// than the lifetime counters. The counters of a bucket are the increase
+// within it, the other fields are taken when it is closed.
+type StatsWindows struct {
+	queue   *Queue
+	mutex   sync.Mutex
+	last    Stats
+	buckets []Stats // closed buckets, the oldest first
+	keep    int
+}
+
+// NewStatsWindows create a StatsWindows keeping the last keep buckets.
+func (q *Queue) NewStatsWindows(keep int) *StatsWindows {
+	if keep < 1 {
+		keep = 1
+	}
+	return &StatsWindows{queue: q, last: q.Stats(), keep: keep}
+}
+
+// Rotate closes the current bucket and starts a new one.
+func (w *StatsWindows) Rotate() {
+	stats := w.queue.Stats()
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.buckets = append(w.buckets, stats.since(w.last))
+	if len(w.buckets) > w.keep {
+		w.buckets = w.buckets[len(w.buckets)-w.keep:]
+	}
+	w.last = stats
+}
+
+// Run calls Rotate every interval seconds until stop is closed.
+func (w *StatsWindows) Run(interval float64, stop <-chan struct{}) {
+	ticker := time.NewTicker(seconds(interval))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			w.Rotate()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Return the closed buckets, the oldest first.
+func (w *StatsWindows) Buckets() []Stats {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return append([]Stats(nil), w.buckets...)
+}
+
+// Current returns the bucket which is not closed yet.
+func (w *StatsWindows) Current() Stats {
+	stats := w.queue.Stats()
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return stats.since(w.last)
+}
+
+// PublishExpvar publishes the Stats of Queue under name with expvar, so
+// they are served at /debug/vars. As expvar.Publish, it panics if name is
+// already published.
+func (q *Queue) PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return q.Stats()
+	}))
+}
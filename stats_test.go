@@ -0,0 +1,88 @@
+package goqueue
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestStats(t *testing.T) {
+	queue := New(1)
+
+	fmt.Println("Test Stats count puts, gets, timeouts and rejections...")
+	queue.PutNoWait(1)
+	queue.PutNoWait(2)
+	queue.Put(2, 0.01)
+	queue.GetNoWait()
+	queue.Get(0.01)
+	expect := Stats{Size: 0, HighWater: 1, Puts: 1, Gets: 1, PutTimeouts: 1, GetTimeouts: 1, Rejected: 1}
+	if stats := queue.Stats(); stats != expect {
+		t.Fatalf("Expect %+v, got %+v\n", expect, stats)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test Stats count blocked operators...")
+	done := make(chan struct{})
+	go func() {
+		queue.Get(0)
+		close(done)
+	}()
+	for queue.Stats().Getters != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	queue.PutNoWait(3)
+	<-done
+	if stats := queue.Stats(); stats.Getters != 0 || stats.Gets != 2 {
+		t.Fatalf("Expect no blocked getter and 2 gets, got %+v\n", stats)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test ResetStats...")
+	queue.PutNoWait(4)
+	queue.ResetStats()
+	expect = Stats{Size: 1, HighWater: 1}
+	if stats := queue.Stats(); stats != expect {
+		t.Fatalf("Expect %+v, got %+v\n", expect, stats)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test PublishExpvar serves Stats...")
+	queue.PublishExpvar("goqueue-test")
+	var published Stats
+	if err := json.Unmarshal([]byte(expvar.Get("goqueue-test").String()), &published); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	if published != expect {
+		t.Fatalf("Expect %+v, got %+v\n", expect, published)
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestStatsWindows(t *testing.T) {
+	queue := New(0)
+	queue.PutNoWait(1)
+	w := queue.NewStatsWindows(2)
+
+	fmt.Println("Test StatsWindows count the increase within each bucket...")
+	queue.PutNoWait(2)
+	queue.GetNoWait()
+	if stats := w.Current(); stats.Puts != 1 || stats.Gets != 1 || stats.Size != 1 {
+		t.Fatalf("Expect 1 put and 1 get, got %+v\n", stats)
+	}
+	w.Rotate()
+	queue.PutNoWait(3)
+	queue.ResetStats()
+	queue.PutNoWait(4)
+	w.Rotate()
+	w.Rotate()
+	buckets := w.Buckets()
+	if len(buckets) != 2 {
+		t.Fatalf("Expect 2 buckets kept, got %d\n", len(buckets))
+	}
+	if buckets[0].Puts != 1 || buckets[1].Puts != 0 || buckets[1].Size != 3 {
+		t.Fatalf("Expect 1 then 0 puts, got %+v\n", buckets)
+	}
+	fmt.Println("  ...PASSED")
+}
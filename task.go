@@ -0,0 +1,80 @@
+package goqueue
+
+import (
+	"errors"
+)
+
+var (
+	// TaskDone was called more times than values were put.
+	ErrTaskDone = errors.New("task done called too many times")
+	// Join timed out with unfinished values.
+	ErrUnfinished = errors.New("unfinished tasks")
+)
+
+// finish marks n values done, it must be called with the mutex held.
+func (q *Queue) finish(n int) {
+	q.unfinished -= n
+	if q.unfinished <= 0 {
+		q.unfinished = 0
+		if q.idle != nil {
+			close(q.idle)
+			q.idle = nil
+		}
+	}
+}
+
+// TaskDone tells that a value got from Queue is processed, like
+// task_done of Python's queue.Queue. Values removed without Get, by
+// RemoveFunc, Clear, Move or a FullPolicy which drops them, are done
+// already. Router, Merge and Pipeline call it for the values they handle.
+func (q *Queue) TaskDone() error {
+	q.mutex.Lock()
+	defer q.unlock()
+	if q.unfinished == 0 {
+		return ErrTaskDone
+	}
+	q.finish(1)
+	return nil
+}
+
+// Join waits until every value put into Queue is marked done by TaskDone.
+//
+// * If timeout less than 0, return ErrUnfinished if some values are not
+// done.
+//
+// * If timeout equals to 0, block until every value is done.
+//
+// * If timeout greater than 0, wait timeout seconds until every value is
+// done, if timeout passed, return ErrUnfinished.
+func (q *Queue) Join(timeout float64) error {
+	q.mutex.Lock()
+	if q.unfinished == 0 {
+		q.unlock()
+		return nil
+	}
+	if timeout < 0.0 {
+		q.unlock()
+		return ErrUnfinished
+	}
+	if q.idle == nil {
+		q.idle = make(chan struct{})
+	}
+	idle := q.idle
+	q.unlock()
+
+	deadline, stop := deadlineOf(timeout)
+	defer stop()
+	select {
+	case <-idle:
+		return nil
+	case <-deadline:
+		return ErrUnfinished
+	}
+}
+
+// Return the number of values put into Queue and not marked done yet.
+func (q *Queue) Unfinished() int {
+	q.mutex.Lock()
+	defer q.unlock()
+	return q.unfinished
+}
@@ -0,0 +1,67 @@
+package goqueue
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestJoin(t *testing.T) {
+	queue := New(0)
+
+	fmt.Println("Test Join returns at once without unfinished values...")
+	if err := queue.Join(-1); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	if err := queue.TaskDone(); err != ErrTaskDone {
+		t.Fatalf("Expect %v, got %v\n", ErrTaskDone, err)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test Join waits until every value is done...")
+	for i := 0; i < 3; i++ {
+		queue.PutNoWait(i)
+	}
+	go func() {
+		for i := 0; i < 3; i++ {
+			queue.Get(0)
+			time.Sleep(5 * time.Millisecond)
+			queue.TaskDone()
+		}
+	}()
+	if err := queue.Join(0.01); err != ErrUnfinished {
+		t.Fatalf("Expect %v, got %v\n", ErrUnfinished, err)
+	}
+	if err := queue.Join(1); err != nil || queue.Unfinished() != 0 {
+		t.Fatalf("Expect every value done, got %d unfinished (%v)\n", queue.Unfinished(), err)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test values removed without Get are done...")
+	queue.PutAll([]interface{}{1, 2, 3})
+	queue.RemoveFunc(func(val interface{}) bool { return val.(int) != 2 })
+	queue.GetNoWait()
+	queue.PutNoWait(4)
+	queue.Clear(nil)
+	queue.TaskDone()
+	if err := queue.Join(-1); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestJoinPutBack(t *testing.T) {
+	queue := NewReliable(0, 60)
+	queue.PutNoWait(1)
+
+	fmt.Println("Test a value put back is counted once...")
+	d, _ := queue.GetNoWait()
+	d.Nack()
+	d, _ = queue.GetNoWait()
+	d.Ack()
+	queue.queue.TaskDone()
+	if err := queue.queue.Join(-1); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	fmt.Println("  ...PASSED")
+}
@@ -0,0 +1,32 @@
+package goqueue
+
+import (
+	"sync"
+	"time"
+)
+
+// Timers of the timeout paths are pooled, unlike time.After a timer is
+// stopped as soon as the operation completes, and reused by the next one.
+var timerPool sync.Pool
+
+func acquireTimer(d time.Duration) *time.Timer {
+	if v := timerPool.Get(); v != nil {
+		t := v.(*time.Timer)
+		t.Reset(d)
+		return t
+	}
+	return time.NewTimer(d)
+}
+
+// releaseTimer stops t and puts it back to the pool, its channel must not
+// be used any more.
+func releaseTimer(t *time.Timer) {
+	if !t.Stop() {
+		// Fired, drain the channel unless the value was received.
+		select {
+		case <-t.C:
+		default:
+		}
+	}
+	timerPool.Put(t)
+}
@@ -0,0 +1,30 @@
+package goqueue
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestTimerPool(t *testing.T) {
+	fmt.Println("Test released timer doesn't fire for its next user...")
+	timer := acquireTimer(time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	releaseTimer(timer) // fired but never received
+	timer = acquireTimer(time.Hour)
+	select {
+	case <-timer.C:
+		t.Fatalf("Reused timer fired with a stale value\n")
+	case <-time.After(20 * time.Millisecond):
+	}
+	releaseTimer(timer)
+
+	timer = acquireTimer(10 * time.Millisecond)
+	select {
+	case <-timer.C:
+	case <-time.After(time.Second):
+		t.Fatalf("Reused timer didn't fire\n")
+	}
+	releaseTimer(timer) // fired and received
+	fmt.Println("  ...PASSED")
+}
@@ -0,0 +1,134 @@
+/*
+Package tracing records spans for the Put and Get of a goqueue queue, and
+carries the trace context of the producer through the queue, so the span
+of the consumer is linked to the one of the producer. The trace context
+goes in the headers of a *goqueue.Message, other values are wrapped in an
+Envelope.
+
+The tracer is provided by the application through the Tracer interface.
+With OpenTelemetry for example:
+
+	type otelTracer struct{ t trace.Tracer }
+
+	func (o otelTracer) Start(ctx context.Context, name string, link context.Context) (context.Context, func(error)) {
+		var opts []trace.SpanStartOption
+		if link != nil {
+			opts = append(opts, trace.WithLinks(trace.LinkFromContext(link)))
+		}
+		ctx, span := o.t.Start(ctx, name, opts...)
+		return ctx, func(err error) {
+			if err != nil {
+				span.RecordError(err)
+			}
+			span.End()
+		}
+	}
+
+	func (otelTracer) Inject(ctx context.Context, c tracing.Carrier) {
+		otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(c))
+	}
+
+	func (otelTracer) Extract(ctx context.Context, c tracing.Carrier) context.Context {
+		return otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(c))
+	}
+*/
+
+package tracing
+
+import (
+	"context"
+	"encoding/gob"
+
+	"github.com/damnever/goqueue"
+)
+
+func init() {
+	gob.Register(&Envelope{})
+}
+
+// Carrier holds the trace context of a producer, as text keys and values.
+type Carrier map[string]string
+
+// Tracer starts spans and moves trace contexts in and out of a Carrier,
+// it must be safe for concurrent use.
+type Tracer interface {
+	// Start starts a span named name as a child of ctx, linked to the span
+	// of link if it is not nil. It returns the context of the span, and
+	// a function which ends it with the result of the operation.
+	Start(ctx context.Context, name string, link context.Context) (context.Context, func(err error))
+	// Inject writes the trace context of ctx into c.
+	Inject(ctx context.Context, c Carrier)
+	// Extract returns ctx with the trace context read from c.
+	Extract(ctx context.Context, c Carrier) context.Context
+}
+
+// Envelope is what Queue puts into the underlying queue, it must be
+// encodable by the Codec of a durable backend, so the values need to be
+// registered with gob.Register for the default one.
+type Envelope struct {
+	Carrier Carrier
+	Value   interface{}
+}
+
+// Queue records the spans of the Put and Get of the queue it wraps.
+type Queue struct {
+	queue  goqueue.Interface
+	tracer Tracer
+	name   string
+}
+
+// New create a Queue wrapping q, spans are named "put name" and
+// "get name".
+func New(q goqueue.Interface, tracer Tracer, name string) *Queue {
+	return &Queue{queue: q, tracer: tracer, name: name}
+}
+
+// Put a value with the trace context of the span of the Put, which is a
+// child of ctx. The timeout semantics are the same as goqueue.Queue.Put.
+func (q *Queue) Put(ctx context.Context, val interface{}, timeout float64) error {
+	ctx, end := q.tracer.Start(ctx, "put "+q.name, nil)
+	if m, ok := val.(*goqueue.Message); ok {
+		if m.Headers == nil {
+			m.Headers = make(map[string]string)
+		}
+		q.tracer.Inject(ctx, Carrier(m.Headers))
+	} else {
+		env := &Envelope{Carrier: make(Carrier), Value: val}
+		q.tracer.Inject(ctx, env.Carrier)
+		val = env
+	}
+	err := q.queue.Put(val, timeout)
+	end(err)
+	return err
+}
+
+// Get a value and returns the context of the span of the Get, a child of
+// ctx linked to the span of the Put, for the spans of its processing. A
+// value put without Queue is returned as is, without link. The timeout
+// semantics are the same as goqueue.Queue.Get.
+func (q *Queue) Get(ctx context.Context, timeout float64) (context.Context, interface{}, error) {
+	val, err := q.queue.Get(timeout)
+	if err != nil {
+		_, end := q.tracer.Start(ctx, "get "+q.name, nil)
+		end(err)
+		return ctx, nil, err
+	}
+	var link context.Context
+	switch v := val.(type) {
+	case *Envelope:
+		link = q.tracer.Extract(context.Background(), v.Carrier)
+		val = v.Value
+	case *goqueue.Message:
+		if len(v.Headers) != 0 {
+			link = q.tracer.Extract(context.Background(), Carrier(v.Headers))
+		}
+	}
+	ctx, end := q.tracer.Start(ctx, "get "+q.name, link)
+	end(nil)
+	return ctx, val, nil
+}
+
+// Return size of the queue.
+func (q *Queue) Size() int {
+	return q.queue.Size()
+}
@@ -0,0 +1,97 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/damnever/goqueue"
+)
+
+type spanKey struct{}
+
+type span struct {
+	id     string
+	name   string
+	parent string
+	link   string
+	err    error
+	ended  bool
+}
+
+// fakeTracer keeps the spans, the trace context is the ID of the current
+// span.
+type fakeTracer struct {
+	mutex sync.Mutex
+	spans []*span
+}
+
+func spanID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(spanKey{}).(string)
+	return id
+}
+
+func (f *fakeTracer) Start(ctx context.Context, name string, link context.Context) (context.Context, func(error)) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	s := &span{id: strconv.Itoa(len(f.spans) + 1), name: name, parent: spanID(ctx), link: spanID(link)}
+	f.spans = append(f.spans, s)
+	return context.WithValue(ctx, spanKey{}, s.id), func(err error) {
+		s.err, s.ended = err, true
+	}
+}
+
+func (f *fakeTracer) Inject(ctx context.Context, c Carrier) {
+	c["span"] = spanID(ctx)
+}
+
+func (f *fakeTracer) Extract(ctx context.Context, c Carrier) context.Context {
+	return context.WithValue(ctx, spanKey{}, c["span"])
+}
+
+func TestTracing(t *testing.T) {
+	tracer := &fakeTracer{}
+	inner := goqueue.New(0)
+	queue := New(inner, tracer, "jobs")
+	root := context.WithValue(context.Background(), spanKey{}, "root")
+
+	fmt.Println("Test the span of Get is linked to the span of Put...")
+	if err := queue.Put(root, "job", -1); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	ctx, val, err := queue.Get(context.Background(), -1)
+	if err != nil || val.(string) != "job" {
+		t.Fatalf("Expect job, got %v (%v)\n", val, err)
+	}
+	put, get := tracer.spans[0], tracer.spans[1]
+	if put.name != "put jobs" || put.parent != "root" || !put.ended {
+		t.Fatalf("Expect an ended put span under root, got %+v\n", put)
+	}
+	if get.name != "get jobs" || get.link != put.id || !get.ended || spanID(ctx) != get.id {
+		t.Fatalf("Expect a get span linked to %s, got %+v\n", put.id, get)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test the trace context goes in the headers of a Message...")
+	msg := goqueue.NewMessage("job")
+	queue.Put(root, msg, -1)
+	if _, val, _ := queue.Get(context.Background(), -1); val != msg || msg.Header("span") != "3" || tracer.spans[3].link != "3" {
+		t.Fatalf("Expect the message linked to span 3, got %v\n", val)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test values without Envelope and errors...")
+	inner.PutNoWait("raw")
+	if _, val, _ := queue.Get(context.Background(), -1); val.(string) != "raw" || tracer.spans[4].link != "" {
+		t.Fatalf("Expect raw without link, got %v\n", val)
+	}
+	if _, _, err := queue.Get(context.Background(), -1); err != goqueue.ErrEmptyQueue || tracer.spans[5].err != err {
+		t.Fatalf("Expect %v recorded, got %v\n", goqueue.ErrEmptyQueue, err)
+	}
+	fmt.Println("  ...PASSED")
+}
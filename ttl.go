@@ -0,0 +1,126 @@
+package goqueue
+
+import (
+	"time"
+)
+
+type ttlItem struct {
+	value   interface{}
+	expires time.Time // zero if the value never expires
+}
+
+func (item *ttlItem) expired(now time.Time) bool {
+	return !item.expires.IsZero() && !now.Before(item.expires)
+}
+
+// TTLQueue is a Queue whose values may expire, expired values are skipped
+// by Get and removed by Sweep.
+type TTLQueue struct {
+	queue *Queue
+
+	// OnExpire, if not nil, is called for every expired value which is
+	// skipped or swept, it must be set before the TTLQueue is used.
+	OnExpire func(val interface{})
+}
+
+// NewTTL create a TTLQueue, the maxSize variable sets the max size, expired
+// values which are not swept yet included.
+func NewTTL(maxSize int) *TTLQueue {
+	return &TTLQueue{queue: New(maxSize)}
+}
+
+func (q *TTLQueue) expire(val interface{}) {
+	if q.OnExpire != nil {
+		q.OnExpire(val)
+	}
+	settled(val, Expired)
+}
+
+// Same as Get(-1).
+func (q *TTLQueue) GetNoWait() (interface{}, error) {
+	return q.Get(-1)
+}
+
+// Get returns the first value which is not expired, the timeout semantics
+// are the same as Queue.Get.
+func (q *TTLQueue) Get(timeout float64) (interface{}, error) {
+	deadline := time.Now().Add(seconds(timeout))
+	for {
+		val, err := q.queue.Get(timeout)
+		if err != nil {
+			return nil, err
+		}
+		item := val.(*ttlItem)
+		if !item.expired(time.Now()) {
+			settled(item.value, Delivered)
+			return item.value, nil
+		}
+		q.expire(item.value)
+		if timeout > 0.0 {
+			// Keep waiting for what is left of the timeout, but still
+			// look at the values which are there once it passed.
+			if timeout = time.Until(deadline).Seconds(); timeout <= 0.0 {
+				timeout = -1
+			}
+		}
+	}
+}
+
+// Same as Put(val, -1).
+func (q *TTLQueue) PutNoWait(val interface{}) error {
+	return q.Put(val, -1)
+}
+
+// Put a value which never expires, the timeout semantics are the same as
+// Queue.Put.
+func (q *TTLQueue) Put(val interface{}, timeout float64) error {
+	return q.queue.Put(&ttlItem{value: val}, timeout)
+}
+
+// PutWithTTL puts a value which expires after ttl, the timeout semantics
+// are the same as Queue.Put.
+func (q *TTLQueue) PutWithTTL(val interface{}, ttl time.Duration, timeout float64) error {
+	return q.queue.Put(&ttlItem{value: val, expires: time.Now().Add(ttl)}, timeout)
+}
+
+// Sweep removes the expired values wherever they are in the queue, and
+// returns how many were removed.
+func (q *TTLQueue) Sweep() int {
+	now := time.Now()
+	expired := q.queue.removeIf(func(val interface{}) bool {
+		return val.(*ttlItem).expired(now)
+	})
+	for _, val := range expired {
+		q.expire(val.(*ttlItem).value)
+	}
+	return len(expired)
+}
+
+// RunJanitor calls Sweep every interval seconds until stop is closed.
+func (q *TTLQueue) RunJanitor(interval float64, stop <-chan struct{}) {
+	ticker := time.NewTicker(seconds(interval))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			q.Sweep()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Return size of TTLQueue, expired values which are not swept yet included.
+func (q *TTLQueue) Size() int {
+	return q.queue.Size()
+}
+
+// Return true if TTLQueue is empty.
+func (q *TTLQueue) IsEmpty() bool {
+	return q.queue.IsEmpty()
+}
+
+// Return true if TTLQueue is full.
+func (q *TTLQueue) IsFull() bool {
+	return q.queue.IsFull()
+}
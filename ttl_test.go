@@ -0,0 +1,90 @@
+package goqueue
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+var _ Interface = (*TTLQueue)(nil)
+
+func TestTTLQueue(t *testing.T) {
+	queue := NewTTL(0)
+	var expired []interface{}
+	queue.OnExpire = func(val interface{}) { expired = append(expired, val) }
+
+	fmt.Println("Test Get skips expired values...")
+	queue.PutWithTTL("stale", 10*time.Millisecond, -1)
+	queue.PutNoWait("forever")
+	queue.PutWithTTL("fresh", time.Minute, -1)
+	time.Sleep(20 * time.Millisecond)
+	if val, err := queue.GetNoWait(); err != nil || val.(string) != "forever" {
+		t.Fatalf("Expect forever, got %v (%v)\n", val, err)
+	}
+	if len(expired) != 1 || expired[0].(string) != "stale" {
+		t.Fatalf("Expect stale expired, got %v\n", expired)
+	}
+	if val, err := queue.GetNoWait(); err != nil || val.(string) != "fresh" {
+		t.Fatalf("Expect fresh, got %v (%v)\n", val, err)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test Get with timeout gives up if only expired values arrive...")
+	expired = nil
+	queue.PutWithTTL("stale", 0, -1)
+	start := time.Now()
+	if _, err := queue.Get(0.05); err != ErrEmptyQueue {
+		t.Fatalf("Expect %v, got %v\n", ErrEmptyQueue, err)
+	}
+	if d := time.Since(start); d < 50*time.Millisecond {
+		t.Fatalf("Expect Get waits 50ms, returned after %v\n", d)
+	}
+	if len(expired) != 1 {
+		t.Fatalf("Expect one expired value, got %v\n", expired)
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestTTLSweep(t *testing.T) {
+	queue := NewTTL(3)
+	expired := make(chan interface{}, 3)
+	queue.OnExpire = func(val interface{}) { expired <- val }
+
+	fmt.Println("Test Sweep removes expired values and frees slots...")
+	queue.PutWithTTL(1, time.Minute, -1)
+	queue.PutWithTTL(2, 0, -1)
+	queue.PutWithTTL(3, time.Minute, -1)
+	if n := queue.Sweep(); n != 1 {
+		t.Fatalf("Expect 1 value swept, got %d\n", n)
+	}
+	if val := <-expired; val.(int) != 2 {
+		t.Fatalf("Expect 2 expired, got %v\n", val)
+	}
+	if err := queue.PutNoWait(4); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	for _, expect := range []int{1, 3, 4} {
+		if val, err := queue.GetNoWait(); err != nil || val.(int) != expect {
+			t.Fatalf("Expect %d, got %v (%v)\n", expect, val, err)
+		}
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test the janitor sweeps in background...")
+	stop := make(chan struct{})
+	defer close(stop)
+	go queue.RunJanitor(0.01, stop)
+	queue.PutWithTTL(5, 10*time.Millisecond, -1)
+	select {
+	case val := <-expired:
+		if val.(int) != 5 {
+			t.Fatalf("Expect 5 expired, got %v\n", val)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expect the janitor sweeps 5\n")
+	}
+	if queue.Size() != 0 {
+		t.Fatalf("Expect TTLQueue is empty, got size %d\n", queue.Size())
+	}
+	fmt.Println("  ...PASSED")
+}
@@ -0,0 +1,90 @@
+//go:build go1.18
+// +build go1.18
+
+/*
+Package typed wraps goqueue.Queue with a type parameter, so values come out
+of Get as T and don't need a type assertion.
+
+The generic Queue is a thin layer over the untyped one rather than its core,
+so the goqueue package and its backends keep building with Go releases
+before 1.18, only this package needs 1.18. Untyped gives the underlying
+goqueue.Queue for the APIs which take one.
+*/
+
+package typed
+
+import (
+	"context"
+
+	"github.com/damnever/goqueue"
+)
+
+// Queue is a goqueue.Queue which only holds values of type T.
+type Queue[T any] struct {
+	q *goqueue.Queue
+}
+
+// New create a new Queue, the arguments are the same as goqueue.New.
+func New[T any](maxSize int, opts ...goqueue.Option) *Queue[T] {
+	return &Queue[T]{q: goqueue.New(maxSize, opts...)}
+}
+
+// Untyped returns the underlying goqueue.Queue, values put into it must be
+// of type T.
+func (q *Queue[T]) Untyped() *goqueue.Queue {
+	return q.q
+}
+
+func cast[T any](val interface{}, err error) (T, error) {
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return val.(T), nil
+}
+
+// Same as Get(-1).
+func (q *Queue[T]) GetNoWait() (T, error) {
+	return q.Get(-1)
+}
+
+// Get has the same timeout semantics as goqueue.Queue.Get, the zero T is
+// returned with an error.
+func (q *Queue[T]) Get(timeout float64) (T, error) {
+	return cast[T](q.q.Get(timeout))
+}
+
+// GetContext is the same as goqueue.Queue.GetContext.
+func (q *Queue[T]) GetContext(ctx context.Context) (T, error) {
+	return cast[T](q.q.GetContext(ctx))
+}
+
+// Same as Put(val, -1).
+func (q *Queue[T]) PutNoWait(val T) error {
+	return q.q.Put(val, -1)
+}
+
+// Put has the same timeout semantics as goqueue.Queue.Put.
+func (q *Queue[T]) Put(val T, timeout float64) error {
+	return q.q.Put(val, timeout)
+}
+
+// PutContext is the same as goqueue.Queue.PutContext.
+func (q *Queue[T]) PutContext(ctx context.Context, val T) error {
+	return q.q.PutContext(ctx, val)
+}
+
+// Return size of Queue.
+func (q *Queue[T]) Size() int {
+	return q.q.Size()
+}
+
+// Return true if Queue is empty.
+func (q *Queue[T]) IsEmpty() bool {
+	return q.q.IsEmpty()
+}
+
+// Return true if Queue is full.
+func (q *Queue[T]) IsFull() bool {
+	return q.q.IsFull()
+}
@@ -0,0 +1,34 @@
+//go:build go1.18
+// +build go1.18
+
+package typed
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/damnever/goqueue"
+)
+
+func TestTypedQueue(t *testing.T) {
+	queue := New[string](1)
+
+	fmt.Println("Test values come out typed...")
+	if err := queue.PutNoWait("hello"); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	if err := queue.PutNoWait("world"); err != goqueue.ErrFullQueue {
+		t.Fatalf("Expect %v, got %v\n", goqueue.ErrFullQueue, err)
+	}
+	val, err := queue.GetNoWait()
+	if err != nil || val != "hello" {
+		t.Fatalf("Expect hello, got %q (%v)\n", val, err)
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test the zero value is returned with an error...")
+	if val, err := queue.Get(0.05); err != goqueue.ErrEmptyQueue || val != "" {
+		t.Fatalf("Expect %v, got %q (%v)\n", goqueue.ErrEmptyQueue, val, err)
+	}
+	fmt.Println("  ...PASSED")
+}
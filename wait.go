@@ -0,0 +1,62 @@
+package goqueue
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// wake notifies at most n waiters in l, the notified waiter will try again.
+func wake(l *list.List, n int) {
+	for ; n > 0 && l.Len() != 0; n-- {
+		e := l.Front()
+		l.Remove(e)
+		e.Value.(waiter) <- true
+	}
+}
+
+// giveUp removes a timed out waiter from l, a notification which arrived
+// meanwhile is passed on to the next waiter.
+func giveUp(l *list.List, e *list.Element) {
+	l.Remove(e)
+	select {
+	case <-e.Value.(waiter):
+		wake(l, 1)
+	default:
+	}
+}
+
+// deadlineOf returns the channel which fires once timeout passes, nil if it
+// never does, and the func releasing its timer.
+func deadlineOf(timeout float64) (<-chan time.Time, func()) {
+	if timeout <= 0.0 {
+		return nil, func() {}
+	}
+	t := acquireTimer(seconds(timeout))
+	return t.C, func() { releaseTimer(t) }
+}
+
+// await blocks a Get or Put operator on a pooled waiter of l after it found
+// nothing to do, the mutex must be held and is held again when it returns
+// true, woken up to try again. It returns false, still holding the mutex,
+// if timeout says not to wait or once deadline passes.
+func await(mutex *sync.Mutex, l *list.List, timeout float64, deadline <-chan time.Time) bool {
+	if timeout < 0.0 {
+		return false
+	}
+	w := waiterPool.Get().(waiter)
+	e := l.PushBack(w)
+	mutex.Unlock()
+	select {
+	case <-w:
+		mutex.Lock()
+		waiterPool.Put(w)
+		return true
+	case <-deadline:
+		mutex.Lock()
+		// Off the list nothing is sent to w anymore, and giveUp drains it.
+		giveUp(l, e)
+		waiterPool.Put(w)
+		return false
+	}
+}
@@ -0,0 +1,138 @@
+package walqueue
+
+import (
+	"crypto/rand"
+	"encoding/gob"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+func init() {
+	gob.Register(&Blob{})
+}
+
+// chunkSize is the buffer payloads are copied with.
+const chunkSize = 64 << 10
+
+// Blob is the value put by PutReader, its payload is kept in a file of the
+// blob directory rather than in the log. Open reads it by chunks and Remove
+// deletes it once processed, the next Open of the Queue deletes the files
+// of the Blobs got already.
+type Blob struct {
+	Name string // file name in the blob directory
+	Size int64
+
+	dir string
+}
+
+func (b *Blob) path() string {
+	return filepath.Join(b.dir, b.Name)
+}
+
+// Open the payload for reading.
+func (b *Blob) Open() (io.ReadCloser, error) {
+	return os.Open(b.path())
+}
+
+// Remove deletes the payload.
+func (b *Blob) Remove() error {
+	return os.Remove(b.path())
+}
+
+func (q *Queue) blobDir() string {
+	if q.opts.BlobDir != "" {
+		return q.opts.BlobDir
+	}
+	return q.path + ".blobs"
+}
+
+// attach sets the directory of a recovered Blob.
+func (q *Queue) attach(val interface{}) {
+	if b, ok := val.(*Blob); ok {
+		b.dir = q.blobDir()
+	}
+}
+
+// Same as PutReader(r, -1).
+func (q *Queue) PutReaderNoWait(r io.Reader) (*Blob, error) {
+	return q.PutReader(r, -1)
+}
+
+// PutReader copies the payload read from r by chunks to a new file of the
+// blob directory, then puts a *Blob of it as Put does, so a large payload
+// is never held in memory. The file is synced before the put record unless
+// the SyncPolicy is SyncNever, and removed if Put fails.
+func (q *Queue) PutReader(r io.Reader, timeout float64) (*Blob, error) {
+	b, err := q.writeBlob(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := q.Put(b, timeout); err != nil {
+		b.Remove()
+		return nil, err
+	}
+	return b, nil
+}
+
+func (q *Queue) writeBlob(r io.Reader) (*Blob, error) {
+	dir := q.blobDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	name := make([]byte, 16)
+	if _, err := rand.Read(name); err != nil {
+		return nil, err
+	}
+	b := &Blob{Name: hex.EncodeToString(name), dir: dir}
+	// Written under a temporary name, the next Open removes a file cut by
+	// a crash with the other unknown ones.
+	tmp := b.path() + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return nil, err
+	}
+	b.Size, err = io.CopyBuffer(f, r, make([]byte, chunkSize))
+	if err == nil && q.opts.Sync != SyncNever {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, b.path())
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return nil, err
+	}
+	if q.opts.Sync != SyncNever {
+		syncDir(dir)
+	}
+	return b, nil
+}
+
+// removeBlobs deletes the files of the blob directory which are not the
+// payload of a live value: got already, or cut by a crash.
+func (q *Queue) removeBlobs(items []item) error {
+	files, err := ioutil.ReadDir(q.blobDir())
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	live := make(map[string]bool)
+	for _, it := range items {
+		if b, ok := it.val.(*Blob); ok {
+			live[b.Name] = true
+		}
+	}
+	for _, fi := range files {
+		if !live[fi.Name()] {
+			os.Remove(filepath.Join(q.blobDir(), fi.Name()))
+		}
+	}
+	return nil
+}
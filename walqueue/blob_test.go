@@ -0,0 +1,83 @@
+package walqueue
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/damnever/goqueue"
+)
+
+func readBlob(t *testing.T, val interface{}) []byte {
+	r, err := val.(*Blob).Open()
+	if err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	defer r.Close()
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	return data
+}
+
+func TestBlob(t *testing.T) {
+	path, cleanup := tempLog(t)
+	defer cleanup()
+	payload := bytes.Repeat([]byte("0123456789"), 200<<10)
+
+	fmt.Println("Test PutReader stores the payload next to the log...")
+	q, err := Open(path, 2, Options{})
+	if err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	b, err := q.PutReaderNoWait(bytes.NewReader(payload))
+	if err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	if b.Size != int64(len(payload)) {
+		t.Fatalf("Expect size %d, got %d\n", len(payload), b.Size)
+	}
+	if fi, _ := os.Stat(path); fi.Size() > 1024 {
+		t.Fatalf("Expect the payload out of the log, got %d bytes\n", fi.Size())
+	}
+	q.PutReaderNoWait(bytes.NewReader([]byte("small")))
+	if _, err := q.PutReaderNoWait(bytes.NewReader(payload)); err != goqueue.ErrFullQueue {
+		t.Fatalf("Expect %v, got %v\n", goqueue.ErrFullQueue, err)
+	}
+	if files, _ := ioutil.ReadDir(path + ".blobs"); len(files) != 2 {
+		t.Fatalf("Expect 2 payload files, got %d\n", len(files))
+	}
+	val, err := q.GetNoWait()
+	if err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	if !bytes.Equal(readBlob(t, val), payload) {
+		t.Fatalf("Expect the payload back\n")
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test Open keeps the live payloads and deletes the others...")
+	q.Close()
+	ioutil.WriteFile(filepath.Join(path+".blobs", "cut.tmp"), []byte("x"), 0644)
+	if q, err = Open(path, 2, Options{}); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	defer q.Close()
+	if files, _ := ioutil.ReadDir(path + ".blobs"); len(files) != 1 {
+		t.Fatalf("Expect 1 payload file, got %d\n", len(files))
+	}
+	if val, err = q.GetNoWait(); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	if string(readBlob(t, val)) != "small" {
+		t.Fatalf("Expect small, got %q\n", readBlob(t, val))
+	}
+	if err := val.(*Blob).Remove(); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	fmt.Println("  ...PASSED")
+}
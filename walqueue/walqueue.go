@@ -0,0 +1,577 @@
+/*
+Package walqueue implements a durable queue kept in memory by a
+goqueue.Queue and recorded by a write-ahead log, for a single process which
+needs its values to survive restarts without a database.
+
+The log starts with a header holding the format Version, then every Put
+appends a put record with the sequence number and the encoded value, every
+Get appends a get record with the sequence number of the value taken.
+SyncPolicy chooses when the log is flushed to disk with fsync:
+
+	SyncAlways    before Put and Get return, nothing acknowledged is lost
+	SyncInterval  every Options.Interval seconds, a crash of the machine
+	              loses at most the last interval
+	SyncNever     left to the operating system, only a crash of the
+	              process itself is survived
+
+Recovery
+
+Open replays the log before the Queue is used:
+
+ 1. The header is checked: a log of a newer version is refused with
+    ErrVersion, a log of version 0, which has no header, is read as is.
+    Records are read after it. Each record carries a CRC-32 checksum, the
+    first one which is incomplete or doesn't match is a write cut by a
+    crash: the file is truncated there and the rest is ignored.
+ 2. The values put whose sequence number has no get record are the live
+    ones, they are queued again in the order of their sequence numbers.
+    Records are matched by sequence number, not by position, so their order
+    in the log doesn't matter.
+ 3. The log is compacted: the header and the live values are written to a
+    new file which is synced and renamed over the old one, so a crash
+    during the compaction leaves either log intact. An old log is upgraded
+    to the current version this way.
+
+A value whose get record was not synced before a crash is delivered again,
+so consumers should be idempotent. The log is compacted the same way while
+the Queue runs, once Options.CompactAfter values were got.
+
+Large payloads
+
+PutReader streams a payload by chunks into its own file of Options.BlobDir
+and puts a *Blob naming it, the log and the memory only hold the Blob. Open
+deletes the files whose Blob is not live anymore.
+*/
+
+package walqueue
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/damnever/goqueue"
+)
+
+var (
+	// Queue is closed.
+	ErrClosed = errors.New("walqueue: queue is closed")
+	// The log was written by a newer version of the package.
+	ErrVersion = errors.New("walqueue: unsupported log version")
+)
+
+// SyncPolicy says when the log is synced to disk.
+type SyncPolicy int
+
+const (
+	SyncAlways SyncPolicy = iota
+	SyncInterval
+	SyncNever
+)
+
+// Options of Open, zero values are replaced by the defaults.
+type Options struct {
+	// Sync is the fsync policy, default is SyncAlways.
+	Sync SyncPolicy
+	// Interval is the seconds between two syncs with SyncInterval,
+	// default is 1.
+	Interval float64
+	// Codec used to store values, default is goqueue.GobCodec.
+	Codec goqueue.Codec
+	// CompactAfter is the number of values got before the log is
+	// compacted, default is 1000.
+	CompactAfter int
+	// BlobDir is the directory of the payloads put by PutReader, default is
+	// the path of the log with a ".blobs" suffix.
+	BlobDir string
+}
+
+const (
+	recordPut byte = 1
+	recordGet byte = 2
+
+	// type, sequence number, length of the data, checksum
+	headerSize = 1 + 8 + 4 + 4
+
+	// Version is the format version of the logs written, version 0 logs
+	// have no file header and the same records.
+	Version uint32 = 1
+	// magic starts the file header, followed by the version; it can't be
+	// mistaken for the type of a record.
+	magic          = "GQWL"
+	fileHeaderSize = len(magic) + 4
+)
+
+// item is the value stored in the in-memory queue.
+type item struct {
+	seq uint64
+	val interface{}
+}
+
+type Queue struct {
+	path  string
+	opts  Options
+	queue *goqueue.Queue
+
+	mutex  sync.Mutex // guards the fields below, the writes to file and the adds to queue
+	seq    uint64     // last sequence number
+	file   *os.File
+	gets   int   // values got since the last compaction
+	dirty  bool  // records were written since the last sync
+	err    error // first failed write, the log is unusable afterwards
+	closed bool
+	avail  chan struct{} // closed and replaced once a value is added, or by Close
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// Open the Queue logged at path, it is created if it doesn't exist, and
+// recovered as the package documentation describes otherwise. The maxSize
+// variable sets the max Queue size, if maxSize is zero, Queue will be
+// infinite size. Recovered values are kept even if there are more than
+// maxSize, Put waits until Get brings Queue below it.
+func Open(path string, maxSize int, opts Options) (*Queue, error) {
+	if opts.Interval <= 0.0 {
+		opts.Interval = 1.0
+	}
+	if opts.Codec == nil {
+		opts.Codec = goqueue.GobCodec{}
+	}
+	if opts.CompactAfter <= 0 {
+		opts.CompactAfter = 1000
+	}
+	q := &Queue{
+		path:  path,
+		opts:  opts,
+		queue: goqueue.New(0),
+		avail: make(chan struct{}),
+	}
+
+	items, err := q.recover()
+	if err != nil {
+		return nil, err
+	}
+	if err := q.removeBlobs(items); err != nil {
+		return nil, err
+	}
+	vals := make([]interface{}, len(items))
+	for i, it := range items {
+		vals[i] = it
+	}
+	q.queue.PutAll(vals)
+	q.queue.SetMaxSize(maxSize)
+
+	q.mutex.Lock()
+	err = q.compact()
+	q.mutex.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if opts.Sync == SyncInterval {
+		q.stop = make(chan struct{})
+		q.done = make(chan struct{})
+		go q.syncLoop()
+	}
+	return q, nil
+}
+
+// recover reads the log and returns the live values in order, a torn tail is
+// truncated.
+func (q *Queue) recover() ([]item, error) {
+	f, err := os.OpenFile(q.path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	puts := make(map[uint64][]byte)
+	got := make(map[uint64]bool)
+	r := bufio.NewReader(f)
+	offset, err := readFileHeader(r)
+	if err == errCorrupt {
+		return nil, f.Truncate(0)
+	} else if err != nil {
+		return nil, err
+	}
+	for {
+		typ, seq, data, n, err := readRecord(r)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// A write cut by a crash, drop it and what follows.
+			if err := f.Truncate(offset); err != nil {
+				return nil, err
+			}
+			break
+		}
+		offset += n
+		if seq > q.seq {
+			q.seq = seq
+		}
+		if typ == recordPut {
+			puts[seq] = data
+		} else {
+			got[seq] = true
+		}
+	}
+
+	seqs := make([]uint64, 0, len(puts))
+	for seq := range puts {
+		if !got[seq] {
+			seqs = append(seqs, seq)
+		}
+	}
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+	items := make([]item, len(seqs))
+	for i, seq := range seqs {
+		val, err := q.opts.Codec.Unmarshal(puts[seq])
+		if err != nil {
+			return nil, err
+		}
+		q.attach(val)
+		items[i] = item{seq: seq, val: val}
+	}
+	return items, nil
+}
+
+var errCorrupt = errors.New("walqueue: corrupt record")
+
+// readFileHeader checks the version of the log and returns the size of its
+// header, zero for a version 0 log.
+func readFileHeader(r *bufio.Reader) (int64, error) {
+	if b, _ := r.Peek(len(magic)); string(b) != magic {
+		return 0, nil
+	}
+	header := make([]byte, fileHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		// The header is written with the records by compact, a short
+		// one is a cut write too.
+		return 0, errCorrupt
+	}
+	if binary.BigEndian.Uint32(header[len(magic):]) > Version {
+		return 0, ErrVersion
+	}
+	return int64(fileHeaderSize), nil
+}
+
+func appendFileHeader(buf []byte) []byte {
+	var version [4]byte
+	binary.BigEndian.PutUint32(version[:], Version)
+	return append(append(buf, magic...), version[:]...)
+}
+
+func readRecord(r io.Reader) (typ byte, seq uint64, data []byte, n int64, err error) {
+	header := make([]byte, headerSize)
+	if _, err = io.ReadFull(r, header); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			err = errCorrupt
+		}
+		return
+	}
+	typ = header[0]
+	seq = binary.BigEndian.Uint64(header[1:9])
+	size := binary.BigEndian.Uint32(header[9:13])
+	if typ != recordPut && typ != recordGet {
+		err = errCorrupt
+		return
+	}
+	data = make([]byte, size)
+	if _, err = io.ReadFull(r, data); err != nil {
+		err = errCorrupt
+		return
+	}
+	sum := crc32.ChecksumIEEE(header[:13])
+	if crc32.Update(sum, crc32.IEEETable, data) != binary.BigEndian.Uint32(header[13:]) {
+		err = errCorrupt
+		return
+	}
+	return typ, seq, data, int64(headerSize) + int64(size), nil
+}
+
+func appendRecord(buf []byte, typ byte, seq uint64, data []byte) []byte {
+	header := make([]byte, headerSize)
+	header[0] = typ
+	binary.BigEndian.PutUint64(header[1:9], seq)
+	binary.BigEndian.PutUint32(header[9:13], uint32(len(data)))
+	sum := crc32.ChecksumIEEE(header[:13])
+	binary.BigEndian.PutUint32(header[13:], crc32.Update(sum, crc32.IEEETable, data))
+	return append(append(buf, header...), data...)
+}
+
+// write appends a record to the log and syncs it as the policy says, the
+// lock must be held.
+func (q *Queue) write(typ byte, seq uint64, data []byte) error {
+	if q.err != nil {
+		return q.err
+	}
+	if _, err := q.file.Write(appendRecord(nil, typ, seq, data)); err != nil {
+		q.err = err
+		return err
+	}
+	q.dirty = true
+	if q.opts.Sync == SyncAlways {
+		return q.sync()
+	}
+	return nil
+}
+
+func (q *Queue) sync() error {
+	if !q.dirty || q.err != nil {
+		return q.err
+	}
+	if err := q.file.Sync(); err != nil {
+		q.err = err
+		return err
+	}
+	q.dirty = false
+	return nil
+}
+
+func (q *Queue) syncLoop() {
+	defer close(q.done)
+	ticker := time.NewTicker(time.Duration(q.opts.Interval * float64(time.Second)))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-q.stop:
+			return
+		case <-ticker.C:
+			q.mutex.Lock()
+			q.sync()
+			q.mutex.Unlock()
+		}
+	}
+}
+
+// compact writes the live values to a new log which replaces the old one,
+// the lock must be held.
+func (q *Queue) compact() error {
+	buf := appendFileHeader(nil)
+	for _, v := range q.queue.ToSlice() {
+		it := v.(item)
+		data, err := q.opts.Codec.Marshal(it.val)
+		if err != nil {
+			return err
+		}
+		buf = appendRecord(buf, recordPut, it.seq, data)
+	}
+
+	tmp := q.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(buf); err == nil {
+		err = f.Sync()
+	}
+	if err == nil {
+		err = os.Rename(tmp, q.path)
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	syncDir(filepath.Dir(q.path))
+
+	if q.file != nil {
+		q.file.Close()
+	}
+	q.file = f
+	q.gets = 0
+	q.dirty = false
+	return nil
+}
+
+// syncDir makes a rename in dir durable, it isn't supported everywhere.
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
+
+// Same as Get(-1).
+func (q *Queue) GetNoWait() (interface{}, error) {
+	return q.Get(-1)
+}
+
+// Get has the same timeout semantics as goqueue.Queue.Get. The get record
+// is written once the value is taken, if it fails the value is still
+// returned, and delivered again after a restart. A Get blocked by Close
+// returns goqueue.ErrEmptyQueue.
+func (q *Queue) Get(timeout float64) (interface{}, error) {
+	var deadline <-chan time.Time
+	if timeout > 0.0 {
+		t := time.NewTimer(time.Duration(timeout * float64(time.Second)))
+		defer t.Stop()
+		deadline = t.C
+	}
+	for waited := false; ; waited = true {
+		q.mutex.Lock()
+		if q.closed {
+			q.mutex.Unlock()
+			if waited {
+				return nil, goqueue.ErrEmptyQueue
+			}
+			return nil, ErrClosed
+		}
+		// The value is taken and its get record written under the lock, so
+		// a compaction can't run in between and drop a value only taken.
+		if v, err := q.queue.GetNoWait(); err == nil {
+			it := v.(item)
+			q.record(it)
+			q.mutex.Unlock()
+			return it.val, nil
+		}
+		avail := q.avail
+		q.mutex.Unlock()
+
+		if timeout < 0.0 {
+			return nil, goqueue.ErrEmptyQueue
+		}
+		select {
+		case <-avail:
+		case <-deadline:
+			return nil, goqueue.ErrEmptyQueue
+		}
+	}
+}
+
+// record writes the get record of it and compacts the log when it is due,
+// the lock must be held.
+func (q *Queue) record(it item) {
+	if q.write(recordGet, it.seq, nil) != nil {
+		return
+	}
+	q.gets++
+	if q.gets >= q.opts.CompactAfter && q.gets >= q.queue.Size() {
+		if err := q.compact(); err != nil {
+			q.err = err
+		}
+	}
+}
+
+// wake tells the blocked Get operators to try again, the lock must be
+// held.
+func (q *Queue) wake() {
+	close(q.avail)
+	q.avail = make(chan struct{})
+}
+
+// Same as Put(val, -1).
+func (q *Queue) PutNoWait(val interface{}) error {
+	return q.Put(val, -1)
+}
+
+// Put has the same timeout semantics as goqueue.Queue.Put. The put record
+// is written, and synced as the SyncPolicy says, before the value can be
+// got, so a value is never consumed unless its Put succeeds.
+func (q *Queue) Put(val interface{}, timeout float64) error {
+	data, err := q.opts.Codec.Marshal(val)
+	if err != nil {
+		return err
+	}
+	deadline := time.Now().Add(time.Duration(timeout * float64(time.Second)))
+	for {
+		q.mutex.Lock()
+		if q.closed {
+			q.mutex.Unlock()
+			return ErrClosed
+		}
+		if !q.queue.IsFull() {
+			q.seq++
+			err := q.write(recordPut, q.seq, data)
+			if err == nil {
+				// Values are only added with the lock held, so it fits.
+				q.queue.PutNoWait(item{seq: q.seq, val: val})
+				q.wake()
+			}
+			q.mutex.Unlock()
+			return err
+		}
+		q.mutex.Unlock()
+
+		if timeout < 0.0 {
+			return goqueue.ErrFullQueue
+		}
+		wait := 0.0
+		if timeout > 0.0 {
+			if wait = time.Until(deadline).Seconds(); wait <= 0.0 {
+				return goqueue.ErrFullQueue
+			}
+		}
+		q.queue.WaitSizeBelow(q.queue.MaxSize(), wait)
+	}
+}
+
+// Sync flushes the log to disk whatever the SyncPolicy, and returns the
+// first write error if any.
+func (q *Queue) Sync() error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if q.closed {
+		return ErrClosed
+	}
+	return q.sync()
+}
+
+// Compact rewrites the log with only the live values.
+func (q *Queue) Compact() error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if q.closed {
+		return ErrClosed
+	}
+	return q.compact()
+}
+
+// Close syncs and closes the log, blocked operators are aborted as
+// goqueue.Queue.Reset does. Values left in Queue are recovered by the next
+// Open.
+func (q *Queue) Close() error {
+	q.mutex.Lock()
+	if q.closed {
+		q.mutex.Unlock()
+		return ErrClosed
+	}
+	q.closed = true
+	q.wake()
+	q.mutex.Unlock()
+	q.queue.Reset(nil)
+	if q.stop != nil {
+		close(q.stop)
+		<-q.done
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	err := q.sync()
+	if cerr := q.file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Return size of Queue.
+func (q *Queue) Size() int {
+	return q.queue.Size()
+}
+
+// Return true if Queue is empty.
+func (q *Queue) IsEmpty() bool {
+	return q.queue.IsEmpty()
+}
+
+// Return true if Queue is full.
+func (q *Queue) IsFull() bool {
+	return q.queue.IsFull()
+}
@@ -0,0 +1,295 @@
+package walqueue
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/damnever/goqueue"
+)
+
+var (
+	_ goqueue.Interface = (*Queue)(nil)
+	_ goqueue.Closer    = (*Queue)(nil)
+)
+
+func tempLog(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "walqueue")
+	if err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	return filepath.Join(dir, "queue.wal"), func() { os.RemoveAll(dir) }
+}
+
+func TestQueue(t *testing.T) {
+	path, cleanup := tempLog(t)
+	defer cleanup()
+
+	fmt.Println("Test Put and Get...")
+	q, err := Open(path, 2, Options{})
+	if err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	q.PutNoWait("a")
+	q.PutNoWait("b")
+	if !q.IsFull() {
+		t.Fatalf("Expect full\n")
+	}
+	if err := q.Put("c", 0.01); err != goqueue.ErrFullQueue {
+		t.Fatalf("Expect ErrFullQueue, got %v\n", err)
+	}
+	if val, err := q.Get(0); err != nil || val != "a" {
+		t.Fatalf("Expect a, got %v (%v)\n", val, err)
+	}
+	q.PutNoWait("c")
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test recovery after Close...")
+	if err := q.Close(); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	if err := q.PutNoWait("d"); err != ErrClosed {
+		t.Fatalf("Expect ErrClosed, got %v\n", err)
+	}
+	q, err = Open(path, 2, Options{})
+	if err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	for _, expect := range []string{"b", "c"} {
+		if val, err := q.GetNoWait(); err != nil || val != expect {
+			t.Fatalf("Expect %s, got %v (%v)\n", expect, val, err)
+		}
+	}
+	if _, err := q.GetNoWait(); err != goqueue.ErrEmptyQueue {
+		t.Fatalf("Expect ErrEmptyQueue, got %v\n", err)
+	}
+	q.PutNoWait("e")
+	q.Close()
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test a torn record is dropped...")
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	f.Write(appendRecord(nil, recordPut, 100, []byte("torn"))[:10])
+	f.Close()
+	q, err = Open(path, 0, Options{})
+	if err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	if q.Size() != 1 {
+		t.Fatalf("Expect 1 value, got %d\n", q.Size())
+	}
+	if val, _ := q.GetNoWait(); val != "e" {
+		t.Fatalf("Expect e, got %v\n", val)
+	}
+	q.PutNoWait("f")
+	q.Close()
+	q, _ = Open(path, 0, Options{})
+	if val, _ := q.GetNoWait(); val != "f" {
+		t.Fatalf("Expect f after the torn tail, got %v\n", val)
+	}
+	q.Close()
+	fmt.Println("  ...PASSED")
+}
+
+func TestSyncPolicies(t *testing.T) {
+	for _, policy := range []SyncPolicy{SyncAlways, SyncInterval, SyncNever} {
+		path, cleanup := tempLog(t)
+		fmt.Printf("Test sync policy %d...\n", policy)
+		q, err := Open(path, 0, Options{Sync: policy, Interval: 0.01, CompactAfter: 3})
+		if err != nil {
+			t.Fatalf("Unexpect error: %v\n", err)
+		}
+		for i := 0; i < 10; i++ {
+			q.PutNoWait(i)
+		}
+		for i := 0; i < 7; i++ {
+			q.GetNoWait()
+		}
+		time.Sleep(20 * time.Millisecond)
+		if err := q.Sync(); err != nil {
+			t.Fatalf("Unexpect error: %v\n", err)
+		}
+		// The log was compacted, and the rest written after.
+		if fi, _ := os.Stat(path); fi.Size() > 10*int64(headerSize+64) {
+			t.Fatalf("Expect a compacted log, got %d bytes\n", fi.Size())
+		}
+		q.Close()
+
+		q, _ = Open(path, 0, Options{})
+		for i := 7; i < 10; i++ {
+			if val, err := q.GetNoWait(); err != nil || val != i {
+				t.Fatalf("Expect %d, got %v (%v)\n", i, val, err)
+			}
+		}
+		q.Close()
+		cleanup()
+		fmt.Println("  ...PASSED")
+	}
+}
+
+func TestClose(t *testing.T) {
+	path, cleanup := tempLog(t)
+	defer cleanup()
+	q, _ := Open(path, 0, Options{})
+
+	fmt.Println("Test Close aborts blocked Get...")
+	done := make(chan error)
+	go func() {
+		_, err := q.Get(0)
+		done <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	q.Close()
+	select {
+	case err := <-done:
+		if err != goqueue.ErrEmptyQueue {
+			t.Fatalf("Expect ErrEmptyQueue, got %v\n", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expect Get to return\n")
+	}
+	if err := q.Close(); err != ErrClosed {
+		t.Fatalf("Expect ErrClosed, got %v\n", err)
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestConcurrentCompaction(t *testing.T) {
+	path, cleanup := tempLog(t)
+	defer cleanup()
+	q, _ := Open(path, 4, Options{Sync: SyncNever, CompactAfter: 5})
+
+	fmt.Println("Test the log matches Queue with concurrent compactions...")
+	done := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		go func(i int) {
+			for j := 0; j < 100; j++ {
+				if err := q.Put(i*100+j, 5); err != nil {
+					t.Errorf("Unexpect error: %v\n", err)
+				}
+			}
+			done <- struct{}{}
+		}(i)
+	}
+	got := 0
+	for got < 396 {
+		if _, err := q.Get(5); err != nil {
+			t.Fatalf("Unexpect error: %v\n", err)
+		}
+		got++
+	}
+	for i := 0; i < 4; i++ {
+		<-done
+	}
+	size := q.Size()
+	if size != 4 {
+		t.Fatalf("Expect %d values left, got %d\n", 4, size)
+	}
+	q.Close()
+	q, _ = Open(path, 4, Options{})
+	defer q.Close()
+	if q.Size() != size {
+		t.Fatalf("Expect %d values recovered, got %d\n", size, q.Size())
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test Close aborts blocked Put...")
+	errs := make(chan error)
+	go func() { errs <- q.Put(-1, 0) }()
+	time.Sleep(10 * time.Millisecond)
+	q.Close()
+	select {
+	case err := <-errs:
+		if err != ErrClosed {
+			t.Fatalf("Expect ErrClosed, got %v\n", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expect Put to return\n")
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestVersion(t *testing.T) {
+	path, cleanup := tempLog(t)
+	defer cleanup()
+
+	fmt.Println("Test a log of version 0 is read and upgraded...")
+	var buf []byte
+	for i, val := range []string{"a", "b"} {
+		data, _ := goqueue.GobCodec{}.Marshal(val)
+		buf = appendRecord(buf, recordPut, uint64(i+1), data)
+	}
+	buf = appendRecord(buf, recordGet, 1, nil)
+	if err := ioutil.WriteFile(path, buf, 0644); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	q, err := Open(path, 0, Options{})
+	if err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	if val, err := q.GetNoWait(); err != nil || val.(string) != "b" {
+		t.Fatalf("Expect b, got %v (%v)\n", val, err)
+	}
+	q.Close()
+	data, _ := ioutil.ReadFile(path)
+	if string(data[:len(magic)]) != magic {
+		t.Fatalf("Expect the log upgraded with a header\n")
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test a log of a newer version is refused...")
+	header := appendFileHeader(nil)
+	header[len(header)-1]++
+	if err := ioutil.WriteFile(path, header, 0644); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	if _, err := Open(path, 0, Options{}); err != ErrVersion {
+		t.Fatalf("Expect %v, got %v\n", ErrVersion, err)
+	}
+	fmt.Println("  ...PASSED")
+}
+
+func TestRequestReply(t *testing.T) {
+	path, cleanup := tempLog(t)
+	defer cleanup()
+
+	fmt.Println("Test a request survives a reopen and is replied by name...")
+	q, err := Open(path, 0, Options{})
+	if err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	replies := goqueue.New(0)
+	requester := goqueue.NewRequester(replies, "replies")
+	defer requester.Close()
+	id, err := requester.Request(q, "job", -1)
+	if err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	q.Close()
+
+	if q, err = Open(path, 0, Options{}); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	defer q.Close()
+	val, err := q.GetNoWait()
+	if err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	req := val.(*goqueue.Request)
+	if req.CorrelationID != id || req.ReplyTo != "replies" || req.Body.(string) != "job" {
+		t.Fatalf("Expect the request back, got %+v\n", req)
+	}
+	resolver := goqueue.ResolverFunc(func(name string) (goqueue.Putter, error) {
+		return replies, nil
+	})
+	if err := req.Reply(resolver, "done", nil, -1); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	if val, err := requester.AwaitReply(id, 1); err != nil || val.(string) != "done" {
+		t.Fatalf("Expect done, got %v (%v)\n", val, err)
+	}
+	fmt.Println("  ...PASSED")
+}
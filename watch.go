@@ -0,0 +1,66 @@
+package goqueue
+
+type sizeWaiter struct {
+	n    int
+	done chan struct{}
+}
+
+// notifySizeWaiters releases the waiters whose size was reached, it must
+// be called with the mutex held after the size changed.
+func (q *Queue) notifySizeWaiters() {
+	for e := q.sizeWait.Front(); e != nil; {
+		next := e.Next()
+		if w := e.Value.(*sizeWaiter); q.size() < w.n {
+			q.sizeWait.Remove(e)
+			close(w.done)
+		}
+		e = next
+	}
+}
+
+// WaitSizeBelow waits until Queue holds less than n values, and returns
+// false if it didn't in time.
+//
+// * If timeout less than 0, it doesn't wait.
+//
+// * If timeout equals to 0, block until the size is below n.
+//
+// * If timeout greater than 0, wait timeout seconds until the size is
+// below n.
+func (q *Queue) WaitSizeBelow(n int, timeout float64) bool {
+	q.mutex.Lock()
+	if q.size() < n {
+		q.unlock()
+		return true
+	}
+	if timeout < 0.0 {
+		q.unlock()
+		return false
+	}
+	w := &sizeWaiter{n: n, done: make(chan struct{})}
+	e := q.sizeWait.PushBack(w)
+	q.unlock()
+
+	deadline, stop := deadlineOf(timeout)
+	defer stop()
+	select {
+	case <-w.done:
+		return true
+	case <-deadline:
+	}
+	q.mutex.Lock()
+	defer q.unlock()
+	select {
+	case <-w.done:
+		return true
+	default:
+	}
+	q.sizeWait.Remove(e)
+	return false
+}
+
+// WaitEmpty is WaitSizeBelow(1, timeout). Unlike Join it doesn't wait for
+// the values got to be processed.
+func (q *Queue) WaitEmpty(timeout float64) bool {
+	return q.WaitSizeBelow(1, timeout)
+}
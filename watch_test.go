@@ -0,0 +1,46 @@
+package goqueue
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestWaitSizeBelow(t *testing.T) {
+	queue := New(0)
+	queue.PutAll([]interface{}{1, 2, 3})
+
+	fmt.Println("Test WaitSizeBelow without waiting...")
+	if !queue.WaitSizeBelow(4, -1) || queue.WaitSizeBelow(3, -1) {
+		t.Fatalf("Expect the size is below 4 but not 3\n")
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test WaitSizeBelow waits for Get...")
+	go func() {
+		for i := 0; i < 3; i++ {
+			time.Sleep(5 * time.Millisecond)
+			queue.GetNoWait()
+		}
+	}()
+	if !queue.WaitSizeBelow(2, 1) || queue.Size() >= 2 {
+		t.Fatalf("Expect the size below 2, got %d\n", queue.Size())
+	}
+	if !queue.WaitEmpty(1) || !queue.IsEmpty() {
+		t.Fatalf("Expect Queue is empty, got %d\n", queue.Size())
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test WaitEmpty with timeout...")
+	queue.PutNoWait(1)
+	if queue.WaitEmpty(0.01) {
+		t.Fatalf("Expect WaitEmpty times out\n")
+	}
+	queue.mutex.Lock()
+	n := queue.sizeWait.Len()
+	queue.mutex.Unlock()
+	if n != 0 {
+		t.Fatalf("Expect the waiter is removed\n")
+	}
+	fmt.Println("  ...PASSED")
+}
@@ -0,0 +1,131 @@
+package goqueue
+
+import (
+	"container/list"
+	"sync"
+)
+
+type weightedItem struct {
+	value  interface{}
+	weight int
+}
+
+// WeightedQueue is a GoRoutine safe FIFO queue bounded by the total weight
+// of its values, e.g. their size in bytes, rather than by their number.
+type WeightedQueue struct {
+	maxWeight int
+	weight    int
+	mutex     sync.Mutex
+	items     *list.List // store *weightedItem
+	putters   *list.List // store blocked Put operators
+	getters   *list.List // store blocked Get operators
+}
+
+// NewWeighted create a new WeightedQueue, the maxWeight variable sets the
+// max total weight. If maxWeight is zero, WeightedQueue will be infinite
+// size, and Put always no wait.
+func NewWeighted(maxWeight int) *WeightedQueue {
+	q := new(WeightedQueue)
+	q.maxWeight = maxWeight
+	q.items = list.New()
+	q.putters = list.New()
+	q.getters = list.New()
+	return q
+}
+
+// fits reports whether a value of weight can be put, a value heavier than
+// maxWeight fits an empty WeightedQueue so it doesn't wait forever.
+func (q *WeightedQueue) fits(weight int) bool {
+	return q.maxWeight <= 0 || q.weight+weight <= q.maxWeight || q.items.Len() == 0
+}
+
+// Same as Get(-1).
+func (q *WeightedQueue) GetNoWait() (interface{}, error) {
+	return q.Get(-1)
+}
+
+// Get has the same timeout semantics as Queue.Get.
+func (q *WeightedQueue) Get(timeout float64) (interface{}, error) {
+	deadline, stop := deadlineOf(timeout)
+	defer stop()
+
+	q.mutex.Lock()
+	for {
+		if q.items.Len() > 0 {
+			item := q.items.Remove(q.items.Front()).(*weightedItem)
+			q.weight -= item.weight
+			wake(q.getters, q.items.Len())
+			// The weight freed may let several putters in.
+			wake(q.putters, q.putters.Len())
+			q.mutex.Unlock()
+			return item.value, nil
+		}
+		if !await(&q.mutex, q.getters, timeout, deadline) {
+			q.mutex.Unlock()
+			return nil, ErrEmptyQueue
+		}
+	}
+}
+
+// Same as Put(val, -1).
+func (q *WeightedQueue) PutNoWait(val interface{}) error {
+	return q.Put(val, -1)
+}
+
+// Put a value of weight 1.
+func (q *WeightedQueue) Put(val interface{}, timeout float64) error {
+	return q.PutWeighted(val, 1, timeout)
+}
+
+// PutWeighted puts a value of weight, it waits until the total weight
+// leaves room for it. The timeout semantics are the same as Queue.Put.
+func (q *WeightedQueue) PutWeighted(val interface{}, weight int, timeout float64) error {
+	deadline, stop := deadlineOf(timeout)
+	defer stop()
+
+	q.mutex.Lock()
+	for {
+		if q.fits(weight) {
+			q.items.PushBack(&weightedItem{value: val, weight: weight})
+			q.weight += weight
+			wake(q.getters, 1)
+			q.mutex.Unlock()
+			return nil
+		}
+		if !await(&q.mutex, q.putters, timeout, deadline) {
+			q.mutex.Unlock()
+			return ErrFullQueue
+		}
+	}
+}
+
+// Return the number of values in WeightedQueue.
+func (q *WeightedQueue) Size() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.items.Len()
+}
+
+// Return the total weight of the values in WeightedQueue.
+func (q *WeightedQueue) Weight() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.weight
+}
+
+// Return true if WeightedQueue is empty.
+func (q *WeightedQueue) IsEmpty() bool {
+	return q.Size() == 0
+}
+
+// Return true if WeightedQueue is full, even a value of weight 1 must wait.
+func (q *WeightedQueue) IsFull() bool {
+	return q.IsFullFor(1)
+}
+
+// Return true if a value of weight must wait.
+func (q *WeightedQueue) IsFullFor(weight int) bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return !q.fits(weight)
+}
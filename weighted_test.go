@@ -0,0 +1,53 @@
+package goqueue
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+var _ Interface = (*WeightedQueue)(nil)
+
+func TestWeightedQueue(t *testing.T) {
+	queue := NewWeighted(10)
+
+	fmt.Println("Test the total weight bounds the queue...")
+	if err := queue.PutWeighted("a", 6, -1); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	if err := queue.PutWeighted("b", 5, -1); err != ErrFullQueue {
+		t.Fatalf("Expect %v, got %v\n", ErrFullQueue, err)
+	}
+	if !queue.IsFullFor(5) || queue.IsFullFor(4) || queue.IsFull() {
+		t.Fatalf("Expect room for a weight of 4 only\n")
+	}
+	queue.PutWeighted("c", 4, -1)
+	if queue.Weight() != 10 || !queue.IsFull() {
+		t.Fatalf("Expect a weight of 10, got %d\n", queue.Weight())
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test Put waits until the weight leaves room...")
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		queue.GetNoWait()
+	}()
+	if err := queue.PutWeighted("b", 5, 1); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	if queue.Weight() != 9 || queue.Size() != 2 {
+		t.Fatalf("Expect 2 values of weight 9, got %d of weight %d\n", queue.Size(), queue.Weight())
+	}
+	fmt.Println("  ...PASSED")
+
+	fmt.Println("Test a value heavier than max fits an empty queue...")
+	queue.GetNoWait()
+	queue.GetNoWait()
+	if err := queue.PutWeighted("huge", 20, -1); err != nil {
+		t.Fatalf("Unexpect error: %v\n", err)
+	}
+	if val, err := queue.Get(1); err != nil || val.(string) != "huge" {
+		t.Fatalf("Expect huge, got %v (%v)\n", val, err)
+	}
+	fmt.Println("  ...PASSED")
+}
@@ -0,0 +1,43 @@
+package goqueue
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// WorkerError is the failure of a worker started by RunWorkers on a value.
+type WorkerError struct {
+	Worker int
+	Value  interface{}
+	Err    error
+}
+
+func (e *WorkerError) Error() string {
+	return fmt.Sprintf("worker %d: %v", e.Worker, e.Err)
+}
+
+// RunWorkers starts n goroutines which call fn for every value got from
+// Queue, and blocks until ctx is done and every running fn has returned.
+// Blocked workers stop right away when ctx is done, and a value got is
+// always passed to fn. Errors returned by fn are passed to onError if it is
+// not nil, the worker then carries on; use NewConsumer to survive panics.
+func (q *Queue) RunWorkers(ctx context.Context, n int, fn func(val interface{}) error, onError func(err *WorkerError)) {
+	wg := &sync.WaitGroup{}
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for {
+				val, err := q.getUntil(0, ctx.Done(), q.lifo)
+				if err != nil {
+					return
+				}
+				if err := fn(val); err != nil && onError != nil {
+					onError(&WorkerError{Worker: worker, Value: val, Err: err})
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
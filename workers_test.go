@@ -0,0 +1,57 @@
+package goqueue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRunWorkers(t *testing.T) {
+	queue := New(0)
+	for i := 0; i < 10; i++ {
+		queue.PutNoWait(i)
+	}
+
+	fmt.Println("Test workers consume every value and report errors...")
+	ctx, cancel := context.WithCancel(context.Background())
+	mutex := sync.Mutex{}
+	sum := 0
+	var failed []*WorkerError
+	finished := make(chan bool)
+	go func() {
+		queue.RunWorkers(ctx, 3, func(val interface{}) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			sum += val.(int)
+			if val.(int) == 7 {
+				return errors.New("seven")
+			}
+			return nil
+		}, func(err *WorkerError) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			failed = append(failed, err)
+		})
+		finished <- true
+	}()
+	for !queue.IsEmpty() {
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	select {
+	case <-finished:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Expect workers stopped on cancel\n")
+	}
+	if sum != 45 {
+		t.Fatalf("Expect sum 45, got %d\n", sum)
+	}
+	if len(failed) != 1 || failed[0].Value.(int) != 7 || failed[0].Err.Error() != "seven" {
+		t.Fatalf("Expect one error on 7, got %v\n", failed)
+	}
+	fmt.Println("  ...PASSED")
+}